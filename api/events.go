@@ -0,0 +1,97 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/mrjdainc/da-inc/events"
+)
+
+const eventsKeepAlive = 15 * time.Second
+
+// Events streams events with Server-Sent Events.
+// Client can resume with Last-Event-ID header (or "last_event_id" param),
+// and filter events with "types" param, like "torrent.,player.started".
+func Events(ctx *gin.Context) {
+	lastEventID, _ := strconv.ParseUint(ctx.GetHeader("Last-Event-ID"), 10, 64)
+	if id := ctx.Query("last_event_id"); id != "" {
+		lastEventID, _ = strconv.ParseUint(id, 10, 64)
+	}
+
+	types := []string{}
+	for _, t := range strings.Split(ctx.Query("types"), ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			types = append(types, t)
+		}
+	}
+
+	missed, c, done := events.Subscribe(lastEventID)
+	defer close(done)
+
+	ctx.Writer.Header().Set("Access-Control-Allow-Origin", "*")
+	ctx.Writer.Header().Set("Content-Type", "text/event-stream")
+	ctx.Writer.Header().Set("Cache-Control", "no-cache")
+	ctx.Writer.Header().Set("Connection", "keep-alive")
+	ctx.Writer.WriteHeader(200)
+
+	fmt.Fprintf(ctx.Writer, "retry: 3000\n\n")
+	for _, e := range missed {
+		if !writeEvent(ctx, e, types) {
+			return
+		}
+	}
+	ctx.Writer.Flush()
+
+	keepAlive := time.NewTicker(eventsKeepAlive)
+	defer keepAlive.Stop()
+
+	closing := ctx.Request.Context().Done()
+	for {
+		select {
+		case <-closing:
+			return
+		case <-keepAlive.C:
+			if _, err := fmt.Fprintf(ctx.Writer, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			ctx.Writer.Flush()
+		case v, ok := <-c:
+			if !ok {
+				return
+			}
+			if e, ok := v.(*events.Event); ok && !writeEvent(ctx, e, types) {
+				return
+			}
+			ctx.Writer.Flush()
+		}
+	}
+}
+
+func writeEvent(ctx *gin.Context, e *events.Event, types []string) bool {
+	if len(types) > 0 {
+		matched := false
+		for _, t := range types {
+			if strings.HasPrefix(e.Type, t) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return true
+		}
+	}
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		log.Warningf("Could not encode event %s: %s", e.Type, err)
+		return true
+	}
+
+	_, err = fmt.Fprintf(ctx.Writer, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data)
+	return err == nil
+}
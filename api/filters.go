@@ -0,0 +1,85 @@
+package api
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/mrjdainc/da-inc/database"
+	"github.com/mrjdainc/da-inc/providers"
+)
+
+// ListFilterRules ...
+func ListFilterRules(ctx *gin.Context) {
+	ctx.Writer.Header().Set("Access-Control-Allow-Origin", "*")
+	ctx.JSON(200, database.GetStorm().GetFilterRules())
+}
+
+// AddFilterRule ...
+func AddFilterRule(ctx *gin.Context) {
+	saveFilterRule(ctx, &database.FilterRule{Enabled: true})
+}
+
+// UpdateFilterRule ...
+func UpdateFilterRule(ctx *gin.Context) {
+	rule := getFilterRule(ctx)
+	if rule == nil {
+		return
+	}
+
+	saveFilterRule(ctx, rule)
+}
+
+// RemoveFilterRule ...
+func RemoveFilterRule(ctx *gin.Context) {
+	rule := getFilterRule(ctx)
+	if rule == nil {
+		return
+	}
+
+	if err := database.GetStorm().DeleteFilterRule(rule.ID); err != nil {
+		ctx.String(500, err.Error())
+		return
+	}
+
+	ctx.Writer.Header().Set("Access-Control-Allow-Origin", "*")
+	ctx.String(200, "")
+}
+
+func getFilterRule(ctx *gin.Context) *database.FilterRule {
+	id, _ := strconv.Atoi(ctx.Params.ByName("ruleId"))
+	rule := database.GetStorm().GetFilterRule(id)
+	if rule == nil {
+		ctx.String(404, fmt.Sprintf("Filter rule %d not found", id))
+	}
+
+	return rule
+}
+
+func saveFilterRule(ctx *gin.Context, rule *database.FilterRule) {
+	id := rule.ID
+	if err := ctx.ShouldBindJSON(rule); err != nil {
+		ctx.String(400, err.Error())
+		return
+	}
+	rule.ID = id
+
+	if rule.Name == "" {
+		ctx.String(400, "Filter rule has no name")
+		return
+	} else if err := providers.ValidateFilterRule(rule); err != nil {
+		ctx.String(400, err.Error())
+		return
+	} else if rule.MediaType != "" && rule.MediaType != movieType && rule.MediaType != showType {
+		ctx.String(400, fmt.Sprintf("Unknown media type: %s", rule.MediaType))
+		return
+	}
+	if err := database.GetStorm().SaveFilterRule(rule); err != nil {
+		ctx.String(500, err.Error())
+		return
+	}
+
+	ctx.Writer.Header().Set("Access-Control-Allow-Origin", "*")
+	ctx.JSON(200, rule)
+}
@@ -0,0 +1,143 @@
+package api
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/mrjdainc/da-inc/config"
+	"github.com/mrjdainc/da-inc/database"
+	"github.com/mrjdainc/da-inc/hooks"
+)
+
+// ListHooks returns all hooks, or hooks for an event with "event" param.
+// Hooks may contain secrets, so the list is not readable by other origins.
+func ListHooks(ctx *gin.Context) {
+	ctx.JSON(200, database.GetStorm().GetHooks(ctx.Query("event")))
+}
+
+// AddHook ...
+func AddHook(ctx *gin.Context) {
+	hook := &database.Hook{Enabled: true}
+	saveHook(ctx, hook)
+}
+
+// UpdateHook ...
+func UpdateHook(ctx *gin.Context) {
+	hook := getHook(ctx)
+	if hook == nil {
+		return
+	}
+
+	saveHook(ctx, hook)
+}
+
+// RemoveHook ...
+func RemoveHook(ctx *gin.Context) {
+	hook := getHook(ctx)
+	if hook == nil {
+		return
+	}
+
+	if err := database.GetStorm().DeleteHook(hook.ID); err != nil {
+		ctx.String(500, err.Error())
+		return
+	}
+
+	ctx.String(200, "")
+}
+
+// TestHook runs hook with a sample payload and returns the result
+func TestHook(ctx *gin.Context) {
+	if !config.Get().HooksEnabled {
+		ctx.String(403, "Hooks are disabled")
+		return
+	}
+
+	hook := getHook(ctx)
+	if hook == nil {
+		return
+	} else if hook.Command != "" && !isLocalRequest(ctx) {
+		ctx.String(403, "Hooks with commands can be run only from local requests")
+		return
+	}
+
+	p := &hooks.Payload{
+		Event:    hook.Event,
+		InfoHash: "0000000000000000000000000000000000000000",
+		Name:     "Test",
+	}
+
+	if err := hooks.Run(hook, p); err != nil {
+		ctx.String(502, err.Error())
+		return
+	}
+
+	ctx.String(200, "")
+}
+
+func getHook(ctx *gin.Context) *database.Hook {
+	id, _ := strconv.Atoi(ctx.Params.ByName("hookId"))
+	hook := database.GetStorm().GetHook(id)
+	if hook == nil {
+		ctx.String(404, fmt.Sprintf("Hook %d not found", id))
+	}
+
+	return hook
+}
+
+// isLocalRequest checks that request comes from this host and not from a web page,
+// browsers always send Origin with cross-origin requests
+func isLocalRequest(ctx *gin.Context) bool {
+	host, _, err := net.SplitHostPort(ctx.Request.RemoteAddr)
+	if err != nil {
+		return false
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback() && ctx.GetHeader("Origin") == ""
+}
+
+func saveHook(ctx *gin.Context, hook *database.Hook) {
+	// Plain text and form posts can be sent by any web page without a preflight
+	if ctx.ContentType() != "application/json" {
+		ctx.String(415, "Content-Type should be application/json")
+		return
+	}
+
+	id := hook.ID
+	command := hook.Command
+	if err := ctx.ShouldBindJSON(hook); err != nil {
+		ctx.String(400, err.Error())
+		return
+	}
+	hook.ID = id
+
+	if hook.Command != command && !isLocalRequest(ctx) {
+		ctx.String(403, "Commands can be set only from local requests")
+		return
+	} else if !hooks.IsValidEvent(hook.Event) {
+		ctx.String(400, fmt.Sprintf("Unknown event: %s", hook.Event))
+		return
+	} else if hook.Command == "" && hook.URL == "" {
+		ctx.String(400, "Hook has no command and no url")
+		return
+	} else if hook.Timeout != nil && *hook.Timeout <= 0 {
+		ctx.String(400, "Timeout should be positive")
+		return
+	} else if hook.Retries != nil && *hook.Retries < 0 {
+		ctx.String(400, "Retries should not be negative")
+		return
+	}
+	if hook.Name == "" {
+		hook.Name = hook.Event
+	}
+
+	if err := database.GetStorm().SaveHook(hook); err != nil {
+		ctx.String(500, err.Error())
+		return
+	}
+
+	ctx.JSON(200, hook)
+}
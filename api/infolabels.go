@@ -103,6 +103,9 @@ func InfoLabelsEpisode(s *bittorrent.Service) gin.HandlerFunc {
 		episodeNumber, _ := strconv.Atoi(ctx.Params.ByName("episode"))
 
 		if item, err := GetEpisodeLabels(showID, seasonNumber, episodeNumber); err == nil {
+			if episode := tmdb.GetEpisode(showID, seasonNumber, episodeNumber, config.Get().Language); episode != nil {
+				setPlayerStreamInfo(s, item, episode.ID)
+			}
 			saveEncoded(encodeItem(item))
 			ctx.JSON(200, item)
 		} else {
@@ -117,6 +120,7 @@ func InfoLabelsMovie(s *bittorrent.Service) gin.HandlerFunc {
 		tmdbID := ctx.Params.ByName("tmdbId")
 
 		if item, err := GetMovieLabels(tmdbID); err == nil {
+			setPlayerStreamInfo(s, item, strToInt(tmdbID, 0))
 			saveEncoded(encodeItem(item))
 			ctx.JSON(200, item)
 		} else {
@@ -139,6 +143,19 @@ func InfoLabelsSearch(s *bittorrent.Service) gin.HandlerFunc {
 	}
 }
 
+// setPlayerStreamInfo replaces stream details of the item with probed ones, if the item is being played
+func setPlayerStreamInfo(s *bittorrent.Service, item *xbmc.ListItem, tmdbID int) {
+	if tmdbID == 0 {
+		return
+	}
+
+	if p := s.GetPlayer(0, tmdbID); p != nil {
+		if info := p.GetTorrent().GetMediaInfo(); info != nil {
+			item.StreamInfo = bittorrent.MediaStreamInfo(info)
+		}
+	}
+}
+
 // GetEpisodeLabels returnes listitem for an episode
 func GetEpisodeLabels(showID, seasonNumber, episodeNumber int) (item *xbmc.ListItem, err error) {
 	show := tmdb.GetShow(showID, config.Get().Language)
@@ -222,7 +239,8 @@ func GetSearchLabels(s *bittorrent.Service, tmdbID string) (item *xbmc.ListItem,
 			DBTYPE:        "episode",
 			Mediatype:     "episode",
 		},
-		Art: &xbmc.ListItemArt{},
+		Art:        &xbmc.ListItemArt{},
+		StreamInfo: bittorrent.MediaStreamInfo(torrent.GetMediaInfo()),
 	}
 
 	return
@@ -0,0 +1,28 @@
+package api
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/mrjdainc/da-inc/metrics"
+)
+
+var requestDurationMetric = metrics.NewHistogram("http_request_duration_seconds", "Latency of HTTP requests by route.", nil, "method", "route", "status")
+
+// Metrics measures requests latency, grouped by route pattern, not by actual path,
+// to keep number of series limited.
+func Metrics() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+		ctx.Next()
+
+		route := ctx.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		requestDurationMetric.Since(start, ctx.Request.Method, route, strconv.Itoa(ctx.Writer.Status()))
+	}
+}
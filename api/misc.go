@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/mrjdainc/da-inc/bittorrent"
 	"github.com/mrjdainc/da-inc/config"
 	"github.com/mrjdainc/da-inc/database"
 	"github.com/mrjdainc/da-inc/library"
@@ -57,11 +58,12 @@ func Settings(ctx *gin.Context) {
 }
 
 // Status display
-func Status(ctx *gin.Context) {
-	title := "LOCALIZE[30393]"
-	text := ""
+func Status(s *bittorrent.Service) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		title := "LOCALIZE[30393]"
+		text := ""
 
-	text += `[B]LOCALIZE[30394]:[/B] %s
+		text += `[B]LOCALIZE[30394]:[/B] %s
 
 [B]LOCALIZE[30395]:[/B] %s
 [B]LOCALIZE[30396]:[/B] %d
@@ -81,49 +83,52 @@ func Status(ctx *gin.Context) {
     [B]LOCALIZE[30405]:[/B] %d
     [B]LOCALIZE[30458]:[/B] %d
     [B]LOCALIZE[30459]:[/B] %d
+    [B]LOCALIZE[30611]:[/B] %d
 `
 
-	ip := "127.0.0.1"
-	if localIP, err := util.LocalIP(); err == nil {
-		ip = localIP.String()
-	}
-
-	port := config.Args.LocalPort
-	webAddress := fmt.Sprintf("http://%s:%d/web", ip, port)
-	debugAllAddress := fmt.Sprintf("http://%s:%d/debug/all", ip, port)
-	debugBundleAddress := fmt.Sprintf("http://%s:%d/debug/bundle", ip, port)
-	infoAddress := fmt.Sprintf("http://%s:%d/info", ip, port)
-
-	appSize := fileSize(filepath.Join(config.Get().Info.Profile, database.GetStorm().GetFilename()))
-	cacheSize := fileSize(filepath.Join(config.Get().Info.Profile, database.GetCache().GetFilename()))
-
-	torrentsCount, _ := database.GetStormDB().Count(&database.TorrentAssignMetadata{})
-	queriesCount, _ := database.GetStormDB().Count(&database.QueryHistory{})
-	deletedMoviesCount, _ := database.GetStormDB().Select(q.Eq("MediaType", library.MovieType), q.Eq("State", library.StateDeleted)).Count(&database.LibraryItem{})
-	deletedShowsCount, _ := database.GetStormDB().Select(q.Eq("MediaType", library.ShowType), q.Eq("State", library.StateDeleted)).Count(&database.LibraryItem{})
-
-	text = fmt.Sprintf(text,
-		util.GetVersion(),
-		ip,
-		port,
-		proxy.ProxyPort,
-
-		webAddress,
-		infoAddress,
-		debugAllAddress,
-		debugBundleAddress,
-
-		appSize,
-		cacheSize,
-
-		torrentsCount,
-		queriesCount,
-		deletedMoviesCount,
-		deletedShowsCount,
-	)
+		ip := "127.0.0.1"
+		if localIP, err := util.LocalIP(); err == nil {
+			ip = localIP.String()
+		}
 
-	xbmc.DialogText(title, string(text))
-	ctx.String(200, "")
+		port := config.Args.LocalPort
+		webAddress := fmt.Sprintf("http://%s:%d/web", ip, port)
+		debugAllAddress := fmt.Sprintf("http://%s:%d/debug/all", ip, port)
+		debugBundleAddress := fmt.Sprintf("http://%s:%d/debug/bundle", ip, port)
+		infoAddress := fmt.Sprintf("http://%s:%d/info", ip, port)
+
+		appSize := fileSize(filepath.Join(config.Get().Info.Profile, database.GetStorm().GetFilename()))
+		cacheSize := fileSize(filepath.Join(config.Get().Info.Profile, database.GetCache().GetFilename()))
+
+		torrentsCount, _ := database.GetStormDB().Count(&database.TorrentAssignMetadata{})
+		queriesCount, _ := database.GetStormDB().Count(&database.QueryHistory{})
+		deletedMoviesCount, _ := database.GetStormDB().Select(q.Eq("MediaType", library.MovieType), q.Eq("State", library.StateDeleted)).Count(&database.LibraryItem{})
+		deletedShowsCount, _ := database.GetStormDB().Select(q.Eq("MediaType", library.ShowType), q.Eq("State", library.StateDeleted)).Count(&database.LibraryItem{})
+
+		text = fmt.Sprintf(text,
+			util.GetVersion(),
+			ip,
+			port,
+			proxy.ProxyPort,
+
+			webAddress,
+			infoAddress,
+			debugAllAddress,
+			debugBundleAddress,
+
+			appSize,
+			cacheSize,
+
+			torrentsCount,
+			queriesCount,
+			deletedMoviesCount,
+			deletedShowsCount,
+			s.GetBlocklist().Count(),
+		)
+
+		xbmc.DialogText(title, string(text))
+		ctx.String(200, "")
+	}
 }
 
 func fileSize(path string) string {
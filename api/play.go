@@ -7,10 +7,14 @@ import (
 	"strings"
 
 	"github.com/mrjdainc/da-inc/bittorrent"
+	"github.com/mrjdainc/da-inc/config"
 	"github.com/mrjdainc/da-inc/database"
+	"github.com/mrjdainc/da-inc/providers"
+	"github.com/mrjdainc/da-inc/tmdb"
 	"github.com/mrjdainc/da-inc/util"
 	"github.com/mrjdainc/da-inc/xbmc"
 
+	"github.com/cespare/xxhash"
 	"github.com/gin-gonic/gin"
 	"github.com/sanity-io/litter"
 )
@@ -48,6 +52,7 @@ func Play(s *bittorrent.Service) gin.HandlerFunc {
 		season := ctx.Query("season")
 		episode := ctx.Query("episode")
 		background := ctx.DefaultQuery("background", "false")
+		position, _ := strconv.ParseFloat(ctx.Query("position"), 64)
 
 		if uri == "" && resume == "" {
 			return
@@ -79,6 +84,7 @@ func Play(s *bittorrent.Service) gin.HandlerFunc {
 			Season:            seasonNumber,
 			Episode:           episodeNumber,
 			Query:             query,
+			StartPosition:     position,
 			Background:        background == "true",
 		}
 
@@ -173,6 +179,68 @@ func PlayURI(s *bittorrent.Service) gin.HandlerFunc {
 	}
 }
 
+// alternativeLink is used when playback stalls, it takes the best link, that was not tried yet,
+// from the stored link and cached search results, or searches providers silently
+func alternativeLink(p *bittorrent.PlayerParams, skip func(infoHash string) bool) *bittorrent.TorrentFile {
+	var cacheKey, linkKey string
+	switch p.ContentType {
+	case movieType:
+		cacheKey = strconv.Itoa(p.TMDBId)
+		linkKey = cacheKey
+	case episodeType:
+		cacheKey = strconv.Itoa(p.ShowID) + "_" + strconv.Itoa(p.Season) + "_" + strconv.Itoa(p.Episode)
+		linkKey = strconv.Itoa(p.TMDBId)
+	case "search":
+		cacheKey = strconv.Itoa(int(xxhash.Sum64String(p.Query)))
+		linkKey = cacheKey
+	default:
+		return nil
+	}
+
+	choose := func(torrents []*bittorrent.TorrentFile) *bittorrent.TorrentFile {
+		for _, torrent := range torrents {
+			if torrent == nil || torrent.InfoHash == "" || torrent.URI == "" || skip(torrent.InfoHash) {
+				continue
+			}
+
+			AddToTorrentsMap(linkKey, torrent)
+			return torrent
+		}
+		return nil
+	}
+
+	candidates := []*bittorrent.TorrentFile{}
+	if torrent, _ := storedTorrentLink(linkKey); torrent != nil {
+		candidates = append(candidates, torrent)
+	}
+	if cached, err := GetCachedTorrents(cacheKey); err == nil {
+		candidates = append(candidates, cached...)
+	}
+	if torrent := choose(candidates); torrent != nil {
+		return torrent
+	}
+
+	var torrents []*bittorrent.TorrentFile
+	switch p.ContentType {
+	case movieType:
+		if movie := tmdb.GetMovie(p.TMDBId, config.Get().Language); movie != nil {
+			torrents = providers.SearchMovieSilent(providers.GetMovieSearchers(), movie, false)
+		}
+	case episodeType:
+		show := tmdb.GetShow(p.ShowID, config.Get().Language)
+		episode := tmdb.GetEpisode(p.ShowID, p.Season, p.Episode, config.Get().Language)
+		if show != nil && episode != nil {
+			torrents = providers.SearchEpisodeSilent(providers.GetEpisodeSearchers(), show, episode, false)
+		}
+	}
+	if len(torrents) == 0 {
+		return nil
+	}
+
+	SetCachedTorrents(cacheKey, torrents)
+	return choose(torrents)
+}
+
 // strToInt parses string to int, and returning default value is no int found
 func strToInt(str string, def int) int {
 	if str != "" {
@@ -0,0 +1,119 @@
+package api
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/mrjdainc/da-inc/database"
+	"github.com/mrjdainc/da-inc/xbmc"
+)
+
+// ListQualityProfiles ...
+func ListQualityProfiles(ctx *gin.Context) {
+	ctx.Writer.Header().Set("Access-Control-Allow-Origin", "*")
+	ctx.JSON(200, database.GetStorm().GetQualityProfiles())
+}
+
+// AddQualityProfile ...
+func AddQualityProfile(ctx *gin.Context) {
+	saveQualityProfile(ctx, &database.QualityProfile{})
+}
+
+// UpdateQualityProfile ...
+func UpdateQualityProfile(ctx *gin.Context) {
+	profile := getQualityProfile(ctx)
+	if profile == nil {
+		return
+	}
+
+	saveQualityProfile(ctx, profile)
+}
+
+// RemoveQualityProfile ...
+func RemoveQualityProfile(ctx *gin.Context) {
+	profile := getQualityProfile(ctx)
+	if profile == nil {
+		return
+	}
+
+	if err := database.GetStorm().DeleteQualityProfile(profile.ID); err != nil {
+		ctx.String(500, err.Error())
+		return
+	}
+
+	ctx.Writer.Header().Set("Access-Control-Allow-Origin", "*")
+	ctx.String(200, "")
+}
+
+// SetShowQualityProfile assigns a profile to the library show,
+// without "profile" param a dialog with profiles is shown
+func SetShowQualityProfile(ctx *gin.Context) {
+	showID, _ := strconv.Atoi(ctx.Params.ByName("tmdbId"))
+
+	profileID := 0
+	if p := ctx.Query("profile"); p != "" {
+		profileID, _ = strconv.Atoi(p)
+	} else {
+		profiles := database.GetStorm().GetQualityProfiles()
+		items := []string{"LOCALIZE[30614]"}
+		for _, p := range profiles {
+			items = append(items, p.Name)
+		}
+
+		choice := xbmc.ListDialog("LOCALIZE[30615]", items...)
+		if choice < 0 {
+			return
+		} else if choice > 0 {
+			profileID = profiles[choice-1].ID
+		}
+	}
+
+	if profileID != 0 && database.GetStorm().GetQualityProfile(profileID) == nil {
+		ctx.String(404, fmt.Sprintf("Quality profile %d not found", profileID))
+		return
+	}
+	if err := database.GetStorm().SetShowQualityProfile(showID, profileID); err != nil {
+		ctx.String(500, err.Error())
+		return
+	}
+
+	ctx.Writer.Header().Set("Access-Control-Allow-Origin", "*")
+	ctx.String(200, "")
+}
+
+func getQualityProfile(ctx *gin.Context) *database.QualityProfile {
+	id, _ := strconv.Atoi(ctx.Params.ByName("profileId"))
+	profile := database.GetStorm().GetQualityProfile(id)
+	if profile == nil {
+		ctx.String(404, fmt.Sprintf("Quality profile %d not found", id))
+	}
+
+	return profile
+}
+
+func saveQualityProfile(ctx *gin.Context, profile *database.QualityProfile) {
+	id := profile.ID
+	if err := ctx.ShouldBindJSON(profile); err != nil {
+		ctx.String(400, err.Error())
+		return
+	}
+	profile.ID = id
+
+	if profile.Name == "" {
+		ctx.String(400, "Quality profile has no name")
+		return
+	} else if profile.MaxSizePerMinute > 0 && profile.MinSizePerMinute > profile.MaxSizePerMinute {
+		ctx.String(400, "Minimum size is bigger than maximum size")
+		return
+	}
+
+	if err := database.GetStorm().SaveQualityProfile(profile); err != nil {
+		ctx.String(500, err.Error())
+		return
+	}
+
+	ctx.Writer.Header().Set("Access-Control-Allow-Origin", "*")
+	ctx.JSON(200, profile)
+}
@@ -4,8 +4,11 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/mrjdainc/da-inc/config"
+	"github.com/mrjdainc/da-inc/database"
+	"github.com/mrjdainc/da-inc/providers"
 	"github.com/mrjdainc/da-inc/xbmc"
 	"github.com/gin-gonic/gin"
 )
@@ -90,6 +93,7 @@ func ProviderList(ctx *gin.Context) {
 		item.ContextMenu = append(item.ContextMenu,
 			[]string{"LOCALIZE[30274]", fmt.Sprintf("XBMC.RunPlugin(%s)", URLForXBMC("/providers/enable"))},
 			[]string{"LOCALIZE[30275]", fmt.Sprintf("XBMC.RunPlugin(%s)", URLForXBMC("/providers/disable"))},
+			[]string{"LOCALIZE[30617]", fmt.Sprintf("Container.Update(%s)", URLForXBMC("/providers/health"))},
 		)
 		items = append(items, item)
 	}
@@ -108,6 +112,9 @@ func ProviderSettings(ctx *gin.Context) {
 func ProviderCheck(ctx *gin.Context) {
 	addonID := ctx.Params.ByName("provider")
 	failures := xbmc.AddonCheck(addonID)
+	if err := providers.ResetProviderCircuit(addonID); err != nil {
+		log.Warningf("Could not reset stats of %s: %s", addonID, err)
+	}
 	translated := xbmc.GetLocalizedString(30243)
 	xbmc.Notify("dainc", fmt.Sprintf("%s: %d", translated, failures), config.AddonIcon())
 	ctx.String(200, "")
@@ -117,6 +124,7 @@ func ProviderCheck(ctx *gin.Context) {
 func ProviderFailure(ctx *gin.Context) {
 	addonID := ctx.Params.ByName("provider")
 	xbmc.AddonFailure(addonID)
+	providers.RecordFailure(addonID)
 	ctx.String(200, "")
 }
 
@@ -169,3 +177,67 @@ func ProvidersDisableAll(ctx *gin.Context) {
 	}
 	ctx.String(200, "")
 }
+
+type providerStatsResponse struct {
+	database.ProviderStats
+	MedianLatency int64 `json:"median_latency"`
+	Skipped       bool  `json:"skipped"`
+}
+
+// ProvidersStats returns health stats of all providers, latency is in milliseconds
+func ProvidersStats(ctx *gin.Context) {
+	ctx.Writer.Header().Set("Access-Control-Allow-Origin", "*")
+
+	stats := database.GetStorm().GetProviderStats()
+	ret := make([]providerStatsResponse, 0, len(stats))
+	for _, s := range stats {
+		ret = append(ret, providerStatsResponse{
+			ProviderStats: s,
+			MedianLatency: int64(providers.MedianLatency(&s) / time.Millisecond),
+			Skipped:       time.Now().Before(s.SkipUntil),
+		})
+	}
+	ctx.JSON(200, ret)
+}
+
+// ProvidersStatsClear removes health stats of all providers
+func ProvidersStatsClear(ctx *gin.Context) {
+	ctx.Writer.Header().Set("Access-Control-Allow-Origin", "*")
+
+	if err := database.GetStorm().ClearProviderStats(); err != nil {
+		ctx.String(500, err.Error())
+		return
+	}
+	ctx.String(200, "")
+}
+
+// ProvidersHealth lists providers with their health stats
+func ProvidersHealth(ctx *gin.Context) {
+	stats := database.GetStorm().GetProviderStats()
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Name < stats[j].Name })
+
+	items := make(xbmc.ListItems, 0, len(stats))
+	for _, s := range stats {
+		status := "[COLOR FF009900]OK[/COLOR]"
+		if time.Now().Before(s.SkipUntil) {
+			status = fmt.Sprintf("[COLOR FF990000]SKIPPED until %s[/COLOR]", s.SkipUntil.Format("15:04"))
+		} else if s.TimeoutsInRow > 0 {
+			status = "[COLOR FF999900]SLOW[/COLOR]"
+		}
+
+		item := &xbmc.ListItem{
+			Label: fmt.Sprintf("%s - %s - %d calls, %d timeouts, %d errors, %d empty, %d distinct, median %s",
+				status, s.Name, s.Calls, s.Timeouts, s.Errors, s.Empty, s.Distinct, providers.MedianLatency(&s)),
+			Path:       URLForXBMC("/providers/health"),
+			IsPlayable: false,
+		}
+		if strings.HasPrefix(s.Name, "script.dainc.") {
+			item.ContextMenu = [][]string{
+				[]string{"LOCALIZE[30242]", fmt.Sprintf("XBMC.RunPlugin(%s)", URLForXBMC("/provider/%s/check", s.Name))},
+			}
+		}
+		items = append(items, item)
+	}
+
+	ctx.JSON(200, xbmc.NewView("", items))
+}
@@ -0,0 +1,685 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	lt "github.com/da-inc/libtorrent-go"
+	"github.com/gin-gonic/gin"
+
+	"github.com/mrjdainc/da-inc/bittorrent"
+	"github.com/mrjdainc/da-inc/config"
+	"github.com/mrjdainc/da-inc/database"
+)
+
+// Subset of qBittorrent WebUI API v2, enough for Sonarr/Radarr and mobile clients.
+// https://github.com/qbittorrent/qBittorrent/wiki/WebUI-API-(qBittorrent-4.1)
+
+const (
+	qbAppVersion    = "v4.2.5"
+	qbAPIVersion    = "2.5.1"
+	qbCookieName    = "SID"
+	qbSessionExpiry = 1 * time.Hour
+	qbInfiniteETA   = 8640000
+)
+
+var (
+	qbSessions   = map[string]time.Time{}
+	qbSessionsMu = sync.Mutex{}
+)
+
+// QBTorrent ...
+type QBTorrent struct {
+	Hash          string  `json:"hash"`
+	Name          string  `json:"name"`
+	Size          int64   `json:"size"`
+	TotalSize     int64   `json:"total_size"`
+	Progress      float64 `json:"progress"`
+	DlSpeed       int     `json:"dlspeed"`
+	UpSpeed       int     `json:"upspeed"`
+	Priority      int     `json:"priority"`
+	NumSeeds      int     `json:"num_seeds"`
+	NumComplete   int     `json:"num_complete"`
+	NumLeechs     int     `json:"num_leechs"`
+	NumIncomplete int     `json:"num_incomplete"`
+	Ratio         float64 `json:"ratio"`
+	Eta           int64   `json:"eta"`
+	State         string  `json:"state"`
+	Category      string  `json:"category"`
+	Tags          string  `json:"tags"`
+	SavePath      string  `json:"save_path"`
+	ContentPath   string  `json:"content_path"`
+	AddedOn       int64   `json:"added_on"`
+	CompletionOn  int64   `json:"completion_on"`
+	AmountLeft    int64   `json:"amount_left"`
+	Completed     int64   `json:"completed"`
+	Downloaded    int64   `json:"downloaded"`
+	Uploaded      int64   `json:"uploaded"`
+	SeedingTime   int64   `json:"seeding_time"`
+	SeqDl         bool    `json:"seq_dl"`
+	DlLimit       int     `json:"dl_limit"`
+	UpLimit       int     `json:"up_limit"`
+}
+
+// QBFile ...
+type QBFile struct {
+	Index      int     `json:"index"`
+	Name       string  `json:"name"`
+	Size       int64   `json:"size"`
+	Progress   float64 `json:"progress"`
+	Priority   int     `json:"priority"`
+	IsSeed     bool    `json:"is_seed"`
+	PieceRange []int   `json:"piece_range"`
+}
+
+// QBCategory ...
+// Torrents are always saved to the download path, so save path of categories is empty,
+// which means the default one for qBittorrent clients
+type QBCategory struct {
+	Name     string `json:"name"`
+	SavePath string `json:"savePath"`
+}
+
+// QBittorrentAuth checks SID cookie, if credentials are configured,
+// without credentials only local requests are allowed
+func QBittorrentAuth() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if config.Get().QBittorrentUsername == "" {
+			if !isLocalRequest(ctx) {
+				ctx.AbortWithStatus(http.StatusForbidden)
+			}
+			return
+		}
+
+		sid, err := ctx.Cookie(qbCookieName)
+		if err == nil {
+			qbSessionsMu.Lock()
+			expires, ok := qbSessions[sid]
+			if ok && time.Now().Before(expires) {
+				qbSessions[sid] = time.Now().Add(qbSessionExpiry)
+				qbSessionsMu.Unlock()
+				return
+			}
+			delete(qbSessions, sid)
+			qbSessionsMu.Unlock()
+		}
+
+		ctx.AbortWithStatus(http.StatusForbidden)
+	}
+}
+
+// QBittorrentLogin ...
+func QBittorrentLogin(ctx *gin.Context) {
+	username := ctx.PostForm("username")
+	password := ctx.PostForm("password")
+
+	if config.Get().QBittorrentUsername == "" && !isLocalRequest(ctx) {
+		ctx.String(200, "Fails.")
+		return
+	} else if config.Get().QBittorrentUsername != "" && (username != config.Get().QBittorrentUsername || password != config.Get().QBittorrentPassword) {
+		ctx.String(200, "Fails.")
+		return
+	}
+
+	b := make([]byte, 16)
+	rand.Read(b)
+	sid := hex.EncodeToString(b)
+
+	qbSessionsMu.Lock()
+	qbSessions[sid] = time.Now().Add(qbSessionExpiry)
+	qbSessionsMu.Unlock()
+
+	ctx.SetCookie(qbCookieName, sid, 0, "/", "", false, true)
+	ctx.String(200, "Ok.")
+}
+
+// QBittorrentLogout ...
+func QBittorrentLogout(ctx *gin.Context) {
+	if sid, err := ctx.Cookie(qbCookieName); err == nil {
+		qbSessionsMu.Lock()
+		delete(qbSessions, sid)
+		qbSessionsMu.Unlock()
+	}
+
+	ctx.String(200, "")
+}
+
+// QBittorrentVersion ...
+func QBittorrentVersion(ctx *gin.Context) {
+	ctx.String(200, qbAppVersion)
+}
+
+// QBittorrentAPIVersion ...
+func QBittorrentAPIVersion(ctx *gin.Context) {
+	ctx.String(200, qbAPIVersion)
+}
+
+// QBittorrentPreferences ...
+func QBittorrentPreferences(ctx *gin.Context) {
+	ctx.JSON(200, gin.H{
+		"save_path":                config.Get().DownloadPath,
+		"temp_path_enabled":        false,
+		"dl_limit":                 config.Get().DownloadRateLimit,
+		"up_limit":                 config.Get().UploadRateLimit,
+		"max_ratio_enabled":        !config.Get().SeedForever && config.Get().ShareRatioLimit > 0,
+		"max_ratio":                float64(config.Get().ShareRatioLimit) / 100,
+		"max_ratio_act":            0,
+		"queueing_enabled":         false,
+		"dht":                      !config.Get().DisableDHT,
+		"upnp":                     !config.Get().DisableUPNP,
+		"listen_port":              config.Get().ListenPortMin,
+		"web_ui_username":          config.Get().QBittorrentUsername,
+		"max_seeding_time":         config.Get().SeedTimeLimit / 60,
+		"max_seeding_time_enabled": !config.Get().SeedForever && config.Get().SeedTimeLimit > 0,
+	})
+}
+
+// QBittorrentTorrentsInfo ...
+func QBittorrentTorrentsInfo(s *bittorrent.Service) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		filter := ctx.Query("filter")
+		category, hasCategory := ctx.GetQuery("category")
+		hashes := qbHashes(ctx.Query("hashes"))
+
+		ret := make([]*QBTorrent, 0)
+		for _, t := range s.GetTorrents() {
+			if t == nil || t.Closer.IsSet() {
+				continue
+			}
+			if len(hashes) > 0 && !hashes[t.InfoHash()] {
+				continue
+			}
+
+			qt := qbTorrent(t)
+			if hasCategory && qt.Category != category {
+				continue
+			}
+			if !qbFilterMatch(filter, qt) {
+				continue
+			}
+
+			ret = append(ret, qt)
+		}
+
+		ctx.JSON(200, ret)
+	}
+}
+
+// QBittorrentTorrentsProperties ...
+func QBittorrentTorrentsProperties(s *bittorrent.Service) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		t := s.GetTorrentByHash(strings.ToLower(ctx.Query("hash")))
+		if t == nil {
+			ctx.String(404, "Torrent hash was not found")
+			return
+		}
+
+		qt := qbTorrent(t)
+		seeds, seedsTotal, peers, peersTotal := t.GetConnections()
+
+		ctx.JSON(200, gin.H{
+			"save_path":        qt.SavePath,
+			"creation_date":    qt.AddedOn,
+			"addition_date":    qt.AddedOn,
+			"completion_date":  qt.CompletionOn,
+			"total_size":       qt.TotalSize,
+			"total_downloaded": qt.Downloaded,
+			"total_uploaded":   qt.Uploaded,
+			"dl_speed":         qt.DlSpeed,
+			"up_speed":         qt.UpSpeed,
+			"seeds":            seeds,
+			"seeds_total":      seedsTotal,
+			"peers":            peers,
+			"peers_total":      peersTotal,
+			"share_ratio":      qt.Ratio,
+			"eta":              qt.Eta,
+			"seeding_time":     qt.SeedingTime,
+			"dl_limit":         -1,
+			"up_limit":         -1,
+			"piece_size":       t.GetPieceLength(),
+			"pieces_num":       t.GetPieceCount(),
+			"comment":          "",
+		})
+	}
+}
+
+// QBittorrentTorrentsFiles ...
+func QBittorrentTorrentsFiles(s *bittorrent.Service) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		t := s.GetTorrentByHash(strings.ToLower(ctx.Query("hash")))
+		if t == nil {
+			ctx.String(404, "Torrent hash was not found")
+			return
+		}
+
+		ret := make([]*QBFile, 0, len(t.GetFiles()))
+		for _, f := range t.GetFiles() {
+			progress := t.GetFileProgress(f) / 100
+			priority := 0
+			if f.Selected {
+				priority = 1
+			}
+
+			ret = append(ret, &QBFile{
+				Index:      f.Index,
+				Name:       f.Path,
+				Size:       f.Size,
+				Progress:   progress,
+				Priority:   priority,
+				IsSeed:     progress >= 1,
+				PieceRange: []int{f.PieceStart, f.PieceEnd},
+			})
+		}
+
+		ctx.JSON(200, ret)
+	}
+}
+
+// QBittorrentTorrentsAdd ...
+func QBittorrentTorrentsAdd(s *bittorrent.Service) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		uris := []string{}
+		for _, u := range strings.Split(ctx.PostForm("urls"), "\n") {
+			if u = strings.TrimSpace(u); u != "" {
+				uris = append(uris, u)
+			}
+		}
+
+		if form, err := ctx.MultipartForm(); err == nil && form != nil {
+			for _, header := range form.File["torrents"] {
+				file, err := header.Open()
+				if err != nil {
+					continue
+				}
+
+				path, err := saveTorrentFile(file, header)
+				file.Close()
+				if err != nil {
+					torrentsLog.Warningf("Could not save uploaded torrent: %s", err)
+					continue
+				}
+				uris = append(uris, path)
+			}
+		}
+
+		if len(uris) == 0 {
+			ctx.String(415, "Torrent file is not valid")
+			return
+		}
+
+		paused := ctx.PostForm("paused") == "true" || ctx.PostForm("stopped") == "true"
+		category := ctx.PostForm("category")
+
+		// Magnets wait for metadata, so torrents are added in background, as qBittorrent does
+		go func() {
+			for _, uri := range uris {
+				t, err := s.AddTorrent(uri, paused)
+				if err != nil {
+					torrentsLog.Warningf("Could not add torrent %s: %s", uri, err)
+					continue
+				}
+
+				t.DownloadAllFiles()
+				if category != "" {
+					database.GetStorm().SetTorrentCategory(t.InfoHash(), category)
+				}
+			}
+		}()
+
+		ctx.String(200, "Ok.")
+	}
+}
+
+// QBittorrentTorrentsPause ...
+func QBittorrentTorrentsPause(s *bittorrent.Service) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		for _, t := range qbTorrentsByHashes(s, ctx.PostForm("hashes")) {
+			t.Pause()
+		}
+
+		ctx.String(200, "")
+	}
+}
+
+// QBittorrentTorrentsResume ...
+func QBittorrentTorrentsResume(s *bittorrent.Service) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		for _, t := range qbTorrentsByHashes(s, ctx.PostForm("hashes")) {
+			t.Resume()
+		}
+
+		ctx.String(200, "")
+	}
+}
+
+// QBittorrentTorrentsDelete ...
+func QBittorrentTorrentsDelete(s *bittorrent.Service) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		deleteFiles := ctx.PostForm("deleteFiles") == "true"
+
+		for _, t := range qbTorrentsByHashes(s, ctx.PostForm("hashes")) {
+			infoHash := t.InfoHash()
+			if s.DeleteTorrent(t, deleteFiles) {
+				database.GetStorm().SetTorrentCategory(infoHash, "")
+			}
+		}
+
+		ctx.String(200, "")
+	}
+}
+
+// QBittorrentTorrentsFilePrio ...
+func QBittorrentTorrentsFilePrio(s *bittorrent.Service) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		t := s.GetTorrentByHash(strings.ToLower(ctx.PostForm("hash")))
+		if t == nil {
+			ctx.String(404, "Torrent hash was not found")
+			return
+		}
+
+		priority, err := strconv.Atoi(ctx.PostForm("priority"))
+		if err != nil {
+			ctx.String(400, "Priority is not valid")
+			return
+		}
+
+		for _, id := range strings.Split(ctx.PostForm("id"), "|") {
+			idx, err := strconv.Atoi(id)
+			if err != nil {
+				ctx.String(400, "File IDs are not valid")
+				return
+			}
+
+			f := t.GetFileByIndex(idx)
+			if f == nil {
+				ctx.String(409, "File IDs are not valid")
+				return
+			}
+
+			if priority == 0 {
+				t.UnDownloadFile(f)
+			} else if !f.Selected {
+				t.DownloadFile(f)
+			}
+		}
+
+		t.SaveDBFiles()
+		ctx.String(200, "")
+	}
+}
+
+// QBittorrentTorrentsCategories ...
+func QBittorrentTorrentsCategories(ctx *gin.Context) {
+	ret := map[string]*QBCategory{}
+	for _, c := range database.GetStorm().GetTorrentCategories() {
+		ret[c.Name] = &QBCategory{
+			Name: c.Name,
+		}
+	}
+
+	ctx.JSON(200, ret)
+}
+
+// QBittorrentTorrentsCreateCategory ...
+func QBittorrentTorrentsCreateCategory(ctx *gin.Context) {
+	category := strings.TrimSpace(ctx.PostForm("category"))
+	if category == "" {
+		ctx.String(400, "Category name is empty")
+		return
+	}
+
+	if err := database.GetStorm().AddTorrentCategory(category); err != nil {
+		ctx.String(409, err.Error())
+		return
+	}
+
+	ctx.String(200, "")
+}
+
+// QBittorrentTorrentsRemoveCategories ...
+func QBittorrentTorrentsRemoveCategories(ctx *gin.Context) {
+	for _, category := range strings.Split(ctx.PostForm("categories"), "\n") {
+		if category = strings.TrimSpace(category); category != "" {
+			database.GetStorm().DeleteTorrentCategory(category)
+		}
+	}
+
+	ctx.String(200, "")
+}
+
+// QBittorrentTorrentsSetCategory ...
+func QBittorrentTorrentsSetCategory(s *bittorrent.Service) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		category := ctx.PostForm("category")
+		if category != "" {
+			found := false
+			for _, c := range database.GetStorm().GetTorrentCategories() {
+				if c.Name == category {
+					found = true
+					break
+				}
+			}
+			if !found {
+				ctx.String(409, "Category name does not exist")
+				return
+			}
+		}
+
+		for _, t := range qbTorrentsByHashes(s, ctx.PostForm("hashes")) {
+			database.GetStorm().SetTorrentCategory(t.InfoHash(), category)
+		}
+
+		ctx.String(200, "")
+	}
+}
+
+// QBittorrentTransferInfo ...
+func QBittorrentTransferInfo(s *bittorrent.Service) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var dlSpeed, upSpeed int
+		var dlData, upData int64
+
+		for _, t := range s.GetTorrents() {
+			if t == nil || t.Closer.IsSet() {
+				continue
+			}
+
+			qt := qbTorrent(t)
+			dlSpeed += qt.DlSpeed
+			upSpeed += qt.UpSpeed
+			dlData += qt.Downloaded
+			upData += qt.Uploaded
+		}
+
+		status := "connected"
+		if s.Session == nil || s.Session.IsPaused() {
+			status = "disconnected"
+		}
+
+		ctx.JSON(200, gin.H{
+			"dl_info_speed":     dlSpeed,
+			"dl_info_data":      dlData,
+			"up_info_speed":     upSpeed,
+			"up_info_data":      upData,
+			"dl_rate_limit":     s.GetLimitsProfile().Download,
+			"up_rate_limit":     s.GetLimitsProfile().Upload,
+			"dht_nodes":         0,
+			"connection_status": status,
+		})
+	}
+}
+
+// QBittorrentSpeedLimitsMode ...
+func QBittorrentSpeedLimitsMode(s *bittorrent.Service) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if s.IsAltSpeed() {
+			ctx.String(200, "1")
+		} else {
+			ctx.String(200, "0")
+		}
+	}
+}
+
+// QBittorrentToggleSpeedLimitsMode ...
+func QBittorrentToggleSpeedLimitsMode(s *bittorrent.Service) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		s.ToggleAltSpeed()
+		ctx.String(200, "")
+	}
+}
+
+func qbTorrent(t *bittorrent.Torrent) *QBTorrent {
+	qt := &QBTorrent{
+		Hash:      t.InfoHash(),
+		Name:      t.Name(),
+		TotalSize: t.Length(),
+		SavePath:  config.Get().DownloadPath,
+		Category:  database.GetStorm().GetTorrentCategory(t.InfoHash()),
+		AddedOn:   t.GetAddedTime().Unix(),
+		Eta:       qbInfiniteETA,
+		Priority:  t.GetQueuePosition(),
+		SeqDl:     !t.Service.IsMemoryStorage(),
+		DlLimit:   -1,
+		UpLimit:   -1,
+	}
+
+	for _, f := range t.GetFiles() {
+		if f.Selected {
+			qt.Size += f.Size
+		}
+	}
+	if len(t.GetFiles()) > 1 {
+		qt.ContentPath = config.Get().DownloadPath + "/" + qt.Name
+	} else if len(t.GetFiles()) == 1 {
+		qt.ContentPath = config.Get().DownloadPath + "/" + t.GetFiles()[0].Path
+	}
+
+	qt.NumSeeds, qt.NumComplete, qt.NumLeechs, qt.NumIncomplete = t.GetConnections()
+	qt.NumIncomplete -= qt.NumComplete
+	if qt.NumIncomplete < 0 {
+		qt.NumIncomplete = 0
+	}
+
+	th := t.GetHandle()
+	if th == nil || th.Swigcptr() == 0 {
+		qt.State = "metaDL"
+		return qt
+	}
+
+	ts := th.Status()
+	defer lt.DeleteTorrentStatus(ts)
+
+	qt.Progress = float64(ts.GetProgress())
+	qt.DlSpeed = ts.GetDownloadPayloadRate()
+	qt.UpSpeed = ts.GetUploadPayloadRate()
+	qt.Downloaded = int64(ts.GetAllTimeDownload())
+	qt.Uploaded = int64(ts.GetAllTimeUpload())
+	qt.SeedingTime = int64(ts.GetSeedingTime())
+	qt.Completed = int64(float64(qt.Size) * qt.Progress)
+	qt.AmountLeft = qt.Size - qt.Completed
+	if qt.Downloaded > 0 {
+		qt.Ratio = float64(qt.Uploaded) / float64(qt.Downloaded)
+	}
+	if qt.Progress >= 1 {
+		qt.Eta = 0
+		qt.CompletionOn = time.Now().Add(-time.Duration(ts.GetFinishedTime()) * time.Second).Unix()
+	} else if qt.DlSpeed > 0 {
+		qt.Eta = qt.AmountLeft / int64(qt.DlSpeed)
+	}
+
+	qt.State = qbState(t.GetStateString(), qt.Progress >= 1)
+	return qt
+}
+
+func qbState(status string, completed bool) string {
+	switch status {
+	case bittorrent.StatusStrings[bittorrent.StatusQueued]:
+		if completed {
+			return "queuedUP"
+		}
+		return "queuedDL"
+	case bittorrent.StatusStrings[bittorrent.StatusChecking]:
+		if completed {
+			return "checkingUP"
+		}
+		return "checkingDL"
+	case bittorrent.StatusStrings[bittorrent.StatusFinding]:
+		return "metaDL"
+	case bittorrent.StatusStrings[bittorrent.StatusAllocating]:
+		return "allocating"
+	case bittorrent.StatusStrings[bittorrent.StatusStalled]:
+		return "stalledDL"
+	case bittorrent.StatusStrings[bittorrent.StatusPaused]:
+		if completed {
+			return "pausedUP"
+		}
+		return "pausedDL"
+	case bittorrent.StatusStrings[bittorrent.StatusFinished]:
+		return "pausedUP"
+	case bittorrent.StatusStrings[bittorrent.StatusSeeding]:
+		return "uploading"
+	}
+
+	if completed {
+		return "uploading"
+	}
+	return "downloading"
+}
+
+func qbFilterMatch(filter string, qt *QBTorrent) bool {
+	switch filter {
+	case "", "all":
+		return true
+	case "downloading":
+		return strings.HasSuffix(qt.State, "DL") || qt.State == "downloading"
+	case "seeding":
+		return qt.State == "uploading" || qt.State == "stalledUP" || qt.State == "queuedUP"
+	case "completed":
+		return qt.Progress >= 1
+	case "paused":
+		return strings.HasPrefix(qt.State, "paused")
+	case "active":
+		return qt.DlSpeed > 0 || qt.UpSpeed > 0
+	case "inactive":
+		return qt.DlSpeed == 0 && qt.UpSpeed == 0
+	case "resumed":
+		return !strings.HasPrefix(qt.State, "paused")
+	case "stalled":
+		return strings.HasPrefix(qt.State, "stalled")
+	}
+
+	return true
+}
+
+func qbHashes(param string) map[string]bool {
+	ret := map[string]bool{}
+	if param == "" || param == "all" {
+		return ret
+	}
+
+	for _, h := range strings.Split(param, "|") {
+		ret[strings.ToLower(strings.TrimSpace(h))] = true
+	}
+	return ret
+}
+
+func qbTorrentsByHashes(s *bittorrent.Service, param string) []*bittorrent.Torrent {
+	ret := []*bittorrent.Torrent{}
+	if param == "all" {
+		return append(ret, s.GetTorrents()...)
+	}
+
+	for h := range qbHashes(param) {
+		if t := s.GetTorrentByHash(h); t != nil {
+			ret = append(ret, t)
+		} else {
+			torrentsLog.Debugf("Torrent %s is not found", h)
+		}
+	}
+	return ret
+}
@@ -0,0 +1,43 @@
+package api
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/mrjdainc/da-inc/database"
+)
+
+// ListResume returns in-progress movies and episodes, last played go first
+func ListResume(ctx *gin.Context) {
+	ctx.Writer.Header().Set("Access-Control-Allow-Origin", "*")
+	ctx.JSON(200, database.GetStorm().GetResumeItems())
+}
+
+// RemoveResume clears stored position of a single item
+func RemoveResume(ctx *gin.Context) {
+	id := ctx.Params.ByName("resumeId")
+	if database.GetStorm().GetResumeItem(id) == nil {
+		ctx.String(404, fmt.Sprintf("Resume item %s not found", id))
+		return
+	}
+
+	if err := database.GetStorm().DeleteResumeItem(id); err != nil {
+		ctx.String(500, err.Error())
+		return
+	}
+
+	ctx.Writer.Header().Set("Access-Control-Allow-Origin", "*")
+	ctx.String(200, "")
+}
+
+// ClearResume removes all stored positions
+func ClearResume(ctx *gin.Context) {
+	if err := database.GetStorm().ClearResumeItems(); err != nil {
+		ctx.String(500, err.Error())
+		return
+	}
+
+	ctx.Writer.Header().Set("Access-Control-Allow-Origin", "*")
+	ctx.String(200, "")
+}
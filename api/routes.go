@@ -19,17 +19,22 @@ var log = logging.MustGetLogger("api")
 func Routes(s *bittorrent.Service) *gin.Engine {
 	r := gin.New()
 	r.Use(gin.Recovery())
+	r.Use(Metrics())
 	r.Use(gin.LoggerWithWriter(gin.DefaultWriter, "/torrents/list", "/notification"))
 
 	gin.SetMode(gin.ReleaseMode)
 
+	s.SetEpisodeSearch(nextEpisodeLink)
+	s.SetAlternativeSearch(alternativeLink)
+
 	r.GET("/", Index(s))
 	r.GET("/playtorrent", PlayTorrent)
 	r.GET("/infolabels", InfoLabelsStored(s))
 	r.GET("/changelog", Changelog)
 	r.GET("/donate", Donate)
 	r.GET("/settings/:addon", Settings)
-	r.GET("/status", Status)
+	r.GET("/status", Status(s))
+	r.GET("/events", Events)
 
 	history := r.Group("/history")
 	{
@@ -46,7 +51,10 @@ func Routes(s *bittorrent.Service) *gin.Engine {
 		search.GET("/infolabels/:tmdbId", InfoLabelsSearch(s))
 	}
 
-	r.LoadHTMLGlob(filepath.Join(config.Get().Info.Path, "resources", "web", "*.html"))
+	// Web templates can be missing when running headless without addon files
+	if matches, _ := filepath.Glob(filepath.Join(config.Get().Info.Path, "resources", "web", "*.html")); len(matches) > 0 {
+		r.LoadHTMLGlob(filepath.Join(config.Get().Info.Path, "resources", "web", "*.html"))
+	}
 	web := r.Group("/web")
 	{
 		web.GET("/", func(c *gin.Context) {
@@ -62,6 +70,7 @@ func Routes(s *bittorrent.Service) *gin.Engine {
 		torrents.Any("/add", AddTorrent(s))
 		torrents.GET("/pause", PauseSession(s))
 		torrents.GET("/resume", ResumeSession(s))
+		torrents.GET("/altspeed", AltSpeed(s))
 		torrents.GET("/move/:torrentId", MoveTorrent(s))
 		torrents.GET("/pause/:torrentId", PauseTorrent(s))
 		torrents.GET("/resume/:torrentId", ResumeTorrent(s))
@@ -69,11 +78,95 @@ func Routes(s *bittorrent.Service) *gin.Engine {
 		torrents.GET("/downloadall/:torrentId", DownloadAllTorrent(s))
 		torrents.GET("/undownloadall/:torrentId", UnDownloadAllTorrent(s))
 		torrents.GET("/selectfile/:torrentId", SelectFileTorrent(s))
+		torrents.GET("/queue/:torrentId/:direction", QueueTorrent(s))
+		torrents.GET("/priority/:torrentId/:priority", SetTorrentPriority(s))
+		torrents.GET("/stalltimeout/:torrentId/:timeout", SetTorrentStallTimeout(s))
+		torrents.GET("/peers/:torrentId", TorrentPeers(s))
+		torrents.GET("/trackers/:torrentId", TorrentTrackers(s))
+		torrents.GET("/pieces/:torrentId", TorrentPieces(s))
 
 		// Web UI json
 		torrents.GET("/list", ListTorrentsWeb(s))
 	}
 
+	rss := r.Group("/rss")
+	{
+		rss.GET("/feeds", ListRSSFeeds)
+		rss.POST("/feeds", AddRSSFeed)
+		rss.PUT("/feeds/:feedId", UpdateRSSFeed)
+		rss.DELETE("/feeds/:feedId", RemoveRSSFeed)
+		rss.GET("/feeds/:feedId/check", CheckRSSFeed(s))
+
+		rss.GET("/rules", ListRSSRules)
+		rss.POST("/rules", AddRSSRule)
+		rss.PUT("/rules/:ruleId", UpdateRSSRule)
+		rss.DELETE("/rules/:ruleId", RemoveRSSRule)
+	}
+
+	hooks := r.Group("/hooks")
+	{
+		hooks.GET("", ListHooks)
+		hooks.POST("", AddHook)
+		hooks.PUT("/:hookId", UpdateHook)
+		hooks.DELETE("/:hookId", RemoveHook)
+		hooks.POST("/:hookId/test", TestHook)
+	}
+
+	filters := r.Group("/filters")
+	{
+		filters.GET("", ListFilterRules)
+		filters.POST("", AddFilterRule)
+		filters.PUT("/:ruleId", UpdateFilterRule)
+		filters.DELETE("/:ruleId", RemoveFilterRule)
+	}
+
+	profiles := r.Group("/profiles")
+	{
+		profiles.GET("", ListQualityProfiles)
+		profiles.POST("", AddQualityProfile)
+		profiles.PUT("/:profileId", UpdateQualityProfile)
+		profiles.DELETE("/:profileId", RemoveQualityProfile)
+	}
+
+	resume := r.Group("/resume")
+	{
+		resume.GET("", ListResume)
+		resume.DELETE("", ClearResume)
+		resume.DELETE("/:resumeId", RemoveResume)
+	}
+
+	// qBittorrent compatible API, for Sonarr/Radarr and similar clients
+	qbittorrent := r.Group("/api/v2")
+	{
+		qbittorrent.POST("/auth/login", QBittorrentLogin)
+		qbittorrent.Any("/auth/logout", QBittorrentLogout)
+
+		qbapi := qbittorrent.Group("")
+		qbapi.Use(QBittorrentAuth())
+		{
+			qbapi.GET("/app/version", QBittorrentVersion)
+			qbapi.GET("/app/webapiVersion", QBittorrentAPIVersion)
+			qbapi.GET("/app/preferences", QBittorrentPreferences)
+
+			qbapi.GET("/transfer/info", QBittorrentTransferInfo(s))
+			qbapi.GET("/transfer/speedLimitsMode", QBittorrentSpeedLimitsMode(s))
+			qbapi.POST("/transfer/toggleSpeedLimitsMode", QBittorrentToggleSpeedLimitsMode(s))
+
+			qbapi.GET("/torrents/info", QBittorrentTorrentsInfo(s))
+			qbapi.GET("/torrents/properties", QBittorrentTorrentsProperties(s))
+			qbapi.GET("/torrents/files", QBittorrentTorrentsFiles(s))
+			qbapi.POST("/torrents/add", QBittorrentTorrentsAdd(s))
+			qbapi.POST("/torrents/pause", QBittorrentTorrentsPause(s))
+			qbapi.POST("/torrents/resume", QBittorrentTorrentsResume(s))
+			qbapi.POST("/torrents/delete", QBittorrentTorrentsDelete(s))
+			qbapi.POST("/torrents/filePrio", QBittorrentTorrentsFilePrio(s))
+			qbapi.GET("/torrents/categories", QBittorrentTorrentsCategories)
+			qbapi.POST("/torrents/createCategory", QBittorrentTorrentsCreateCategory)
+			qbapi.POST("/torrents/removeCategories", QBittorrentTorrentsRemoveCategories)
+			qbapi.POST("/torrents/setCategory", QBittorrentTorrentsSetCategory(s))
+		}
+	}
+
 	movies := r.Group("/movies")
 	{
 		movies.GET("/", MoviesIndex)
@@ -240,6 +333,7 @@ func Routes(s *bittorrent.Service) *gin.Engine {
 		library.GET("/movie/play/:tmdbId", PlayMovie(s))
 		library.GET("/show/add/:tmdbId", AddShow)
 		library.GET("/show/remove/:tmdbId", RemoveShow)
+		library.GET("/show/profile/:tmdbId", SetShowQualityProfile)
 		library.GET("/show/list/add/:listId", AddShowsList)
 		library.GET("/show/play/:showId/:season/:episode", PlayShow(s))
 
@@ -272,6 +366,9 @@ func Routes(s *bittorrent.Service) *gin.Engine {
 	{
 		allproviders.GET("/enable", ProvidersEnableAll)
 		allproviders.GET("/disable", ProvidersDisableAll)
+		allproviders.GET("/stats", ProvidersStats)
+		allproviders.DELETE("/stats", ProvidersStatsClear)
+		allproviders.GET("/health", ProvidersHealth)
 	}
 
 	repo := r.Group("/repository")
@@ -0,0 +1,185 @@
+package api
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/op/go-logging"
+
+	"github.com/mrjdainc/da-inc/bittorrent"
+	"github.com/mrjdainc/da-inc/database"
+	"github.com/mrjdainc/da-inc/rss"
+)
+
+var rssLog = logging.MustGetLogger("rss")
+
+// ListRSSFeeds ...
+func ListRSSFeeds(ctx *gin.Context) {
+	ctx.Writer.Header().Set("Access-Control-Allow-Origin", "*")
+	ctx.JSON(200, database.GetStorm().GetRSSFeeds())
+}
+
+// AddRSSFeed ...
+func AddRSSFeed(ctx *gin.Context) {
+	feed := &database.RSSFeed{Enabled: true}
+	saveRSSFeed(ctx, feed)
+}
+
+// UpdateRSSFeed ...
+func UpdateRSSFeed(ctx *gin.Context) {
+	feed := getRSSFeed(ctx)
+	if feed == nil {
+		return
+	}
+
+	saveRSSFeed(ctx, feed)
+}
+
+// RemoveRSSFeed removes feed together with its rules
+func RemoveRSSFeed(ctx *gin.Context) {
+	feed := getRSSFeed(ctx)
+	if feed == nil {
+		return
+	}
+
+	if err := database.GetStorm().DeleteRSSFeed(feed.ID); err != nil {
+		ctx.String(500, err.Error())
+		return
+	}
+
+	rssLog.Infof("Removed feed %s", feed.URL)
+	ctx.Writer.Header().Set("Access-Control-Allow-Origin", "*")
+	ctx.String(200, "")
+}
+
+// CheckRSSFeed polls the feed right away
+func CheckRSSFeed(s *bittorrent.Service) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		feed := getRSSFeed(ctx)
+		if feed == nil {
+			return
+		}
+
+		added, err := rss.CheckFeed(s, feed)
+		if err != nil {
+			ctx.String(502, err.Error())
+			return
+		}
+
+		ctx.Writer.Header().Set("Access-Control-Allow-Origin", "*")
+		ctx.JSON(200, gin.H{"added": added})
+	}
+}
+
+// ListRSSRules returns all rules, or rules of a feed with "feed" param
+func ListRSSRules(ctx *gin.Context) {
+	feedID, _ := strconv.Atoi(ctx.Query("feed"))
+
+	ctx.Writer.Header().Set("Access-Control-Allow-Origin", "*")
+	ctx.JSON(200, database.GetStorm().GetRSSRules(feedID))
+}
+
+// AddRSSRule ...
+func AddRSSRule(ctx *gin.Context) {
+	rule := &database.RSSRule{Enabled: true}
+	saveRSSRule(ctx, rule)
+}
+
+// UpdateRSSRule ...
+func UpdateRSSRule(ctx *gin.Context) {
+	rule := getRSSRule(ctx)
+	if rule == nil {
+		return
+	}
+
+	saveRSSRule(ctx, rule)
+}
+
+// RemoveRSSRule ...
+func RemoveRSSRule(ctx *gin.Context) {
+	rule := getRSSRule(ctx)
+	if rule == nil {
+		return
+	}
+
+	if err := database.GetStorm().DeleteRSSRule(rule.ID); err != nil {
+		ctx.String(500, err.Error())
+		return
+	}
+
+	ctx.Writer.Header().Set("Access-Control-Allow-Origin", "*")
+	ctx.String(200, "")
+}
+
+func getRSSFeed(ctx *gin.Context) *database.RSSFeed {
+	id, _ := strconv.Atoi(ctx.Params.ByName("feedId"))
+	feed := database.GetStorm().GetRSSFeed(id)
+	if feed == nil {
+		ctx.String(404, fmt.Sprintf("Feed %d not found", id))
+	}
+
+	return feed
+}
+
+func getRSSRule(ctx *gin.Context) *database.RSSRule {
+	id, _ := strconv.Atoi(ctx.Params.ByName("ruleId"))
+	rule := database.GetStorm().GetRSSRule(id)
+	if rule == nil {
+		ctx.String(404, fmt.Sprintf("Rule %d not found", id))
+	}
+
+	return rule
+}
+
+func saveRSSFeed(ctx *gin.Context, feed *database.RSSFeed) {
+	id := feed.ID
+	if err := ctx.ShouldBindJSON(feed); err != nil {
+		ctx.String(400, err.Error())
+		return
+	}
+	feed.ID = id
+
+	if feed.URL == "" {
+		ctx.String(400, "Feed url is empty")
+		return
+	}
+	if feed.Name == "" {
+		feed.Name = feed.URL
+	}
+
+	if err := database.GetStorm().SaveRSSFeed(feed); err != nil {
+		ctx.String(409, err.Error())
+		return
+	}
+
+	rssLog.Infof("Saved feed %s", feed.URL)
+	ctx.Writer.Header().Set("Access-Control-Allow-Origin", "*")
+	ctx.JSON(200, feed)
+}
+
+func saveRSSRule(ctx *gin.Context, rule *database.RSSRule) {
+	id := rule.ID
+	if err := ctx.ShouldBindJSON(rule); err != nil {
+		ctx.String(400, err.Error())
+		return
+	}
+	rule.ID = id
+
+	if rule.FeedID != 0 && database.GetStorm().GetRSSFeed(rule.FeedID) == nil {
+		ctx.String(400, fmt.Sprintf("Feed %d not found", rule.FeedID))
+		return
+	}
+	if err := rss.ValidateRule(rule); err != nil {
+		ctx.String(400, err.Error())
+		return
+	}
+
+	if err := database.GetStorm().SaveRSSRule(rule); err != nil {
+		ctx.String(500, err.Error())
+		return
+	}
+
+	ctx.Writer.Header().Set("Access-Control-Allow-Origin", "*")
+	ctx.JSON(200, rule)
+}
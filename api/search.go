@@ -68,12 +68,17 @@ func Search(s *bittorrent.Service) gin.HandlerFunc {
 			SetCachedTorrents(fakeTmdbID, torrents)
 		}
 
+		torrents, dropped := providers.SplitDropped(torrents)
 		if len(torrents) == 0 {
-			xbmc.Notify("dainc", "LOCALIZE[30205]", config.AddonIcon())
+			if len(dropped) > 0 {
+				showDropped(dropped)
+			} else {
+				xbmc.Notify("dainc", "LOCALIZE[30205]", config.AddonIcon())
+			}
 			return
 		}
 
-		choices := make([]string, 0, len(torrents))
+		choices := make([]string, 0, len(torrents)+len(dropped))
 		for _, torrent := range torrents {
 			resolution := ""
 			if torrent.Resolution > 0 {
@@ -93,6 +98,15 @@ func Search(s *bittorrent.Service) gin.HandlerFunc {
 			if torrent.AudioCodec > 0 {
 				info = append(info, bittorrent.Codecs[torrent.AudioCodec])
 			}
+			if torrent.Score != 0 {
+				info = append(info, fmt.Sprintf("[COLOR gold]%+d[/COLOR]", torrent.Score))
+			}
+			if torrent.Freeleech {
+				info = append(info, "[COLOR green]Freeleech[/COLOR]")
+			}
+			if torrent.Flagged != "" {
+				info = append(info, fmt.Sprintf("[COLOR red]%s[/COLOR]", torrent.Flagged))
+			}
 			if torrent.Provider != "" {
 				info = append(info, fmt.Sprintf(" - [B]%s[/B]", torrent.Provider))
 			}
@@ -107,12 +121,13 @@ func Search(s *bittorrent.Service) gin.HandlerFunc {
 				torrent.Seeds,
 				torrent.Peers,
 				strings.Join(info, " "),
-				torrent.Name,
+				torrent.Name+torrentContents(torrent),
 				torrent.Icon,
 				multi,
 			)
 			choices = append(choices, label)
 		}
+		choices = append(choices, droppedChoices(dropped)...)
 
 		choice := -1
 		if detectPlayAction("") == "play" {
@@ -121,6 +136,11 @@ func Search(s *bittorrent.Service) gin.HandlerFunc {
 			choice = xbmc.ListDialogLarge("LOCALIZE[30228]", query, choices...)
 		}
 
+		if choice >= len(torrents) {
+			showDropped(dropped[choice-len(torrents) : choice-len(torrents)+1])
+			return
+		}
+
 		if choice >= 0 {
 			AddToTorrentsMap(fakeTmdbID, torrents[choice])
 
@@ -591,12 +591,17 @@ func ShowSeasonLinks(action string, s *bittorrent.Service) gin.HandlerFunc {
 			return
 		}
 
+		torrents, dropped := providers.SplitDropped(torrents)
 		if len(torrents) == 0 {
-			xbmc.Notify("dainc", "LOCALIZE[30205]", config.AddonIcon())
+			if len(dropped) > 0 {
+				showDropped(dropped)
+			} else {
+				xbmc.Notify("dainc", "LOCALIZE[30205]", config.AddonIcon())
+			}
 			return
 		}
 
-		choices := make([]string, 0, len(torrents))
+		choices := make([]string, 0, len(torrents)+len(dropped))
 		for _, torrent := range torrents {
 			resolution := ""
 			if torrent.Resolution > 0 {
@@ -616,6 +621,15 @@ func ShowSeasonLinks(action string, s *bittorrent.Service) gin.HandlerFunc {
 			if torrent.AudioCodec > 0 {
 				info = append(info, bittorrent.Codecs[torrent.AudioCodec])
 			}
+			if torrent.Score != 0 {
+				info = append(info, fmt.Sprintf("[COLOR gold]%+d[/COLOR]", torrent.Score))
+			}
+			if torrent.Freeleech {
+				info = append(info, "[COLOR green]Freeleech[/COLOR]")
+			}
+			if torrent.Flagged != "" {
+				info = append(info, fmt.Sprintf("[COLOR red]%s[/COLOR]", torrent.Flagged))
+			}
 			if torrent.Provider != "" {
 				info = append(info, fmt.Sprintf(" - [B]%s[/B]", torrent.Provider))
 			}
@@ -630,12 +644,13 @@ func ShowSeasonLinks(action string, s *bittorrent.Service) gin.HandlerFunc {
 				torrent.Seeds,
 				torrent.Peers,
 				strings.Join(info, " "),
-				torrent.Name,
+				torrent.Name+torrentContents(torrent),
 				torrent.Icon,
 				multi,
 			)
 			choices = append(choices, label)
 		}
+		choices = append(choices, droppedChoices(dropped)...)
 
 		choice := -1
 		if action == "play" {
@@ -644,6 +659,11 @@ func ShowSeasonLinks(action string, s *bittorrent.Service) gin.HandlerFunc {
 			choice = xbmc.ListDialogLarge("LOCALIZE[30228]", longName, choices...)
 		}
 
+		if choice >= len(torrents) {
+			showDropped(dropped[choice-len(torrents) : choice-len(torrents)+1])
+			return
+		}
+
 		if choice >= 0 {
 			AddToTorrentsMap(strconv.Itoa(season.ID), torrents[choice])
 
@@ -689,6 +709,41 @@ func showEpisodeLinks(showID int, seasonNumber int, episodeNumber int) ([]*bitto
 	return providers.SearchEpisode(searchers, show, episode), nil
 }
 
+// nextEpisodeLink silently searches links for the episode, that would be played after current one,
+// the first link is taken, as with automatic stream choosing
+func nextEpisodeLink(showID int, seasonNumber int, episodeNumber int) *bittorrent.TorrentFile {
+	episode := tmdb.GetEpisode(showID, seasonNumber, episodeNumber, config.Get().Language)
+	if episode == nil {
+		return nil
+	}
+
+	tmdbID := strconv.Itoa(episode.ID)
+	if config.Get().UseCacheSelection {
+		if torrent, _ := storedTorrentLink(tmdbID); torrent != nil && len(torrent.URI) > 0 {
+			return torrent
+		}
+	}
+
+	fakeTmdbID := strconv.Itoa(showID) + "_" + strconv.Itoa(seasonNumber) + "_" + strconv.Itoa(episodeNumber)
+	torrents, err := GetCachedTorrents(fakeTmdbID)
+	if err != nil || len(torrents) == 0 {
+		show := tmdb.GetShow(showID, config.Get().Language)
+		if show == nil {
+			return nil
+		}
+
+		torrents = providers.SearchEpisodeSilent(providers.GetEpisodeSearchers(), show, episode, false)
+		SetCachedTorrents(fakeTmdbID, torrents)
+	}
+
+	if len(torrents) == 0 {
+		return nil
+	}
+
+	AddToTorrentsMap(tmdbID, torrents[0])
+	return torrents[0]
+}
+
 // ShowEpisodeRun ...
 func ShowEpisodeRun(action string, s *bittorrent.Service) gin.HandlerFunc {
 	return ShowEpisodeLinks(detectPlayAction(action), s)
@@ -775,12 +830,17 @@ func ShowEpisodeLinks(action string, s *bittorrent.Service) gin.HandlerFunc {
 			return
 		}
 
+		torrents, dropped := providers.SplitDropped(torrents)
 		if len(torrents) == 0 {
-			xbmc.Notify("dainc", "LOCALIZE[30205]", config.AddonIcon())
+			if len(dropped) > 0 {
+				showDropped(dropped)
+			} else {
+				xbmc.Notify("dainc", "LOCALIZE[30205]", config.AddonIcon())
+			}
 			return
 		}
 
-		choices := make([]string, 0, len(torrents))
+		choices := make([]string, 0, len(torrents)+len(dropped))
 		for _, torrent := range torrents {
 			resolution := ""
 			if torrent.Resolution > 0 {
@@ -800,6 +860,15 @@ func ShowEpisodeLinks(action string, s *bittorrent.Service) gin.HandlerFunc {
 			if torrent.AudioCodec > 0 {
 				info = append(info, bittorrent.Codecs[torrent.AudioCodec])
 			}
+			if torrent.Score != 0 {
+				info = append(info, fmt.Sprintf("[COLOR gold]%+d[/COLOR]", torrent.Score))
+			}
+			if torrent.Freeleech {
+				info = append(info, "[COLOR green]Freeleech[/COLOR]")
+			}
+			if torrent.Flagged != "" {
+				info = append(info, fmt.Sprintf("[COLOR red]%s[/COLOR]", torrent.Flagged))
+			}
 			if torrent.Provider != "" {
 				info = append(info, fmt.Sprintf(" - [B]%s[/B]", torrent.Provider))
 			}
@@ -814,12 +883,13 @@ func ShowEpisodeLinks(action string, s *bittorrent.Service) gin.HandlerFunc {
 				torrent.Seeds,
 				torrent.Peers,
 				strings.Join(info, " "),
-				torrent.Name,
+				torrent.Name+torrentContents(torrent),
 				torrent.Icon,
 				multi,
 			)
 			choices = append(choices, label)
 		}
+		choices = append(choices, droppedChoices(dropped)...)
 
 		choice := -1
 		if action == "play" {
@@ -828,6 +898,11 @@ func ShowEpisodeLinks(action string, s *bittorrent.Service) gin.HandlerFunc {
 			choice = xbmc.ListDialogLarge("LOCALIZE[30228]", longName, choices...)
 		}
 
+		if choice >= len(torrents) {
+			showDropped(dropped[choice-len(torrents) : choice-len(torrents)+1])
+			return
+		}
+
 		if choice >= 0 {
 			AddToTorrentsMap(strconv.Itoa(episode.ID), torrents[choice])
 
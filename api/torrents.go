@@ -20,6 +20,7 @@ import (
 	"github.com/mrjdainc/da-inc/bittorrent"
 	"github.com/mrjdainc/da-inc/config"
 	"github.com/mrjdainc/da-inc/database"
+	"github.com/mrjdainc/da-inc/providers"
 	"github.com/mrjdainc/da-inc/util"
 	"github.com/mrjdainc/da-inc/xbmc"
 )
@@ -47,6 +48,8 @@ type TorrentsWeb struct {
 	SeedersTotal  int     `json:"seeders_total"`
 	Peers         int     `json:"peers"`
 	PeersTotal    int     `json:"peers_total"`
+	QueuePosition int     `json:"queue_position"`
+	Priority      string  `json:"priority"`
 }
 
 // AddToTorrentsMap ...
@@ -75,14 +78,31 @@ func InTorrentsMap(tmdbID string) *bittorrent.TorrentFile {
 		return nil
 	}
 
+	torrent, ti := storedTorrentLink(tmdbID)
+	if torrent == nil {
+		return nil
+	}
+
+	if len(torrent.URI) > 0 && (config.Get().SilentStreamStart || xbmc.DialogConfirmFocused("dainc", fmt.Sprintf("LOCALIZE[30260];;[COLOR gold]%s[/COLOR]", torrent.Title))) {
+		return torrent
+	}
+
+	database.GetStormDB().DeleteStruct(ti)
+	database.GetStorm().CleanupTorrentLink(ti.InfoHash)
+
+	return nil
+}
+
+// storedTorrentLink loads torrent, saved for tmdbID with AddToTorrentsMap, without asking the user
+func storedTorrentLink(tmdbID string) (*bittorrent.TorrentFile, *database.TorrentAssignItem) {
 	tmdbInt, _ := strconv.Atoi(tmdbID)
 	var ti database.TorrentAssignItem
 	var tm database.TorrentAssignMetadata
 	if err := database.GetStormDB().One("TmdbID", tmdbInt, &ti); err != nil {
-		return nil
+		return nil, nil
 	}
-	if err := database.GetStormDB().One("InfoHash", ti.InfoHash, &tm); err != nil {
-		return nil
+	if err := database.GetStormDB().One("InfoHash", ti.InfoHash, &tm); err != nil || len(tm.Metadata) == 0 {
+		return nil, nil
 	}
 
 	torrent := &bittorrent.TorrentFile{}
@@ -92,14 +112,7 @@ func InTorrentsMap(tmdbID string) *bittorrent.TorrentFile {
 		torrent.LoadFromBytes(tm.Metadata)
 	}
 
-	if len(torrent.URI) > 0 && (config.Get().SilentStreamStart || xbmc.DialogConfirmFocused("dainc", fmt.Sprintf("LOCALIZE[30260];;[COLOR gold]%s[/COLOR]", torrent.Title))) {
-		return torrent
-	}
-
-	database.GetStormDB().DeleteStruct(&ti)
-	database.GetStorm().CleanupTorrentLink(ti.InfoHash)
-
-	return nil
+	return torrent, &ti
 }
 
 // InTorrentsHistory ...
@@ -156,6 +169,9 @@ func GetCachedTorrents(tmdbID string) ([]*bittorrent.TorrentFile, error) {
 func SetCachedTorrents(tmdbID string, torrents []*bittorrent.TorrentFile) error {
 	cacheDB := database.GetCache()
 
+	// Dropped results are shown only right after the search, so they are never chosen later
+	torrents, _ = providers.SplitDropped(torrents)
+
 	return cacheDB.SetCachedObject(database.CommonBucket, config.Get().CacheSearchDuration, tmdbID, torrents)
 }
 
@@ -181,6 +197,10 @@ func ListTorrents(s *bittorrent.Service) gin.HandlerFunc {
 
 			torrentAction := []string{"LOCALIZE[30231]", fmt.Sprintf("XBMC.RunPlugin(%s)", URLForXBMC("/torrents/pause/%s", t.InfoHash()))}
 			sessionAction := []string{"LOCALIZE[30233]", fmt.Sprintf("XBMC.RunPlugin(%s)", URLForXBMC("/torrents/pause"))}
+			altSpeedAction := []string{"LOCALIZE[30609]", fmt.Sprintf("XBMC.RunPlugin(%s)", URLForXBMC("/torrents/altspeed"))}
+			if s.IsAltSpeed() {
+				altSpeedAction = []string{"LOCALIZE[30610]", fmt.Sprintf("XBMC.RunPlugin(%s)", URLForXBMC("/torrents/altspeed"))}
+			}
 
 			if s.Session.IsPaused() {
 				sessionAction = []string{"LOCALIZE[30234]", fmt.Sprintf("XBMC.RunPlugin(%s)", URLForXBMC("/torrents/resume"))}
@@ -219,6 +239,10 @@ func ListTorrents(s *bittorrent.Service) gin.HandlerFunc {
 					Title: torrentName,
 				},
 			}
+			if info := t.GetMediaInfo(); info != nil {
+				item.Info.Duration = int(info.Duration)
+				item.StreamInfo = bittorrent.MediaStreamInfo(info)
+			}
 
 			item.ContextMenu = [][]string{
 				[]string{"LOCALIZE[30230]", fmt.Sprintf("XBMC.PlayMedia(%s)", playURL)},
@@ -227,6 +251,7 @@ func ListTorrents(s *bittorrent.Service) gin.HandlerFunc {
 				[]string{"LOCALIZE[30276]", fmt.Sprintf("XBMC.RunPlugin(%s)", URLForXBMC("/torrents/delete/%s?files=true", t.InfoHash()))},
 				[]string{"LOCALIZE[30308]", fmt.Sprintf("XBMC.RunPlugin(%s)", URLForXBMC("/torrents/move/%s", t.InfoHash()))},
 				sessionAction,
+				altSpeedAction,
 			}
 
 			if !s.IsMemoryStorage() {
@@ -322,6 +347,8 @@ func ListTorrentsWeb(s *bittorrent.Service) gin.HandlerFunc {
 				SeedersTotal:  seedersTotal,
 				Peers:         peers,
 				PeersTotal:    peersTotal,
+				QueuePosition: t.GetQueuePosition(),
+				Priority:      bittorrent.PriorityStrings[t.GetPriority()],
 			}
 			torrents = append(torrents, ti)
 		}
@@ -351,12 +378,35 @@ func ResumeSession(s *bittorrent.Service) gin.HandlerFunc {
 	}
 }
 
+// AltSpeed toggles alternative speed limits, or sets them with "state" param,
+// and returns currently active limits profile
+func AltSpeed(s *bittorrent.Service) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		switch ctx.Query("state") {
+		case "on":
+			s.SetAltSpeed(true)
+		case "off":
+			s.SetAltSpeed(false)
+		case "":
+			s.ToggleAltSpeed()
+		}
+
+		xbmc.Refresh()
+		ctx.Writer.Header().Set("Access-Control-Allow-Origin", "*")
+		ctx.JSON(200, gin.H{
+			"alt_speed": s.IsAltSpeed(),
+			"profile":   s.GetLimitsProfile(),
+		})
+	}
+}
+
 // AddTorrent ...
 func AddTorrent(s *bittorrent.Service) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		uri := ctx.Request.FormValue("uri")
 		file, header, fileError := ctx.Request.FormFile("file")
 		allFiles := ctx.Request.FormValue("all")
+		priority, _ := strconv.Atoi(ctx.Request.FormValue("priority"))
 
 		if file != nil && header != nil && fileError == nil {
 			t, err := saveTorrentFile(file, header)
@@ -379,6 +429,9 @@ func AddTorrent(s *bittorrent.Service) gin.HandlerFunc {
 			ctx.String(404, err.Error())
 			return
 		}
+		if priority != bittorrent.PriorityDefault {
+			t.SetPriority(priority)
+		}
 
 		torrentsLog.Infof("Downloading %s", uri)
 		if allFiles == "1" {
@@ -436,6 +489,93 @@ func MoveTorrent(s *bittorrent.Service) gin.HandlerFunc {
 	}
 }
 
+// QueueTorrent moves torrent up, down, to the top or to the bottom of the queue
+func QueueTorrent(s *bittorrent.Service) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		torrentID := ctx.Params.ByName("torrentId")
+		torrent, err := GetTorrentFromParam(s, torrentID)
+		if err != nil {
+			ctx.Error(fmt.Errorf("Unable to move torrent with index %s", torrentID))
+			return
+		}
+
+		switch direction := ctx.Params.ByName("direction"); direction {
+		case "up":
+			s.GetQueue().MoveUp(torrent)
+		case "down":
+			s.GetQueue().MoveDown(torrent)
+		case "top":
+			s.GetQueue().MoveTop(torrent)
+		case "bottom":
+			s.GetQueue().MoveBottom(torrent)
+		default:
+			ctx.String(400, fmt.Sprintf("Unknown direction: %s", direction))
+			return
+		}
+
+		xbmc.Refresh()
+		ctx.Writer.Header().Set("Access-Control-Allow-Origin", "*")
+		ctx.String(200, "")
+	}
+}
+
+// SetTorrentPriority changes priority class of a torrent
+func SetTorrentPriority(s *bittorrent.Service) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		torrentID := ctx.Params.ByName("torrentId")
+		torrent, err := GetTorrentFromParam(s, torrentID)
+		if err != nil {
+			ctx.Error(fmt.Errorf("Unable to change priority of torrent with index %s", torrentID))
+			return
+		}
+
+		priority := -1
+		for k, v := range bittorrent.PriorityStrings {
+			if v == ctx.Params.ByName("priority") && k != bittorrent.PriorityDefault {
+				priority = k
+			}
+		}
+		if priority < 0 {
+			ctx.String(400, fmt.Sprintf("Unknown priority: %s", ctx.Params.ByName("priority")))
+			return
+		}
+
+		torrentsLog.Infof("Setting %s priority for %s", bittorrent.PriorityStrings[priority], torrent.Name())
+		torrent.SetPriority(priority)
+
+		xbmc.Refresh()
+		ctx.Writer.Header().Set("Access-Control-Allow-Origin", "*")
+		ctx.String(200, "")
+	}
+}
+
+// SetTorrentStallTimeout ...
+func SetTorrentStallTimeout(s *bittorrent.Service) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		torrentID := ctx.Params.ByName("torrentId")
+		torrent, err := GetTorrentFromParam(s, torrentID)
+		if err != nil {
+			ctx.Error(fmt.Errorf("Unable to change stall timeout of torrent with index %s", torrentID))
+			return
+		}
+
+		timeout, err := strconv.Atoi(ctx.Params.ByName("timeout"))
+		if err != nil || timeout < -1 {
+			ctx.String(400, fmt.Sprintf("Wrong stall timeout: %s", ctx.Params.ByName("timeout")))
+			return
+		}
+
+		torrentsLog.Infof("Setting stall timeout %d for %s", timeout, torrent.Name())
+		if err := torrent.SetStallTimeout(timeout); err != nil {
+			ctx.Error(fmt.Errorf("Unable to change stall timeout of %s: %s", torrent.Name(), err))
+			return
+		}
+
+		ctx.Writer.Header().Set("Access-Control-Allow-Origin", "*")
+		ctx.String(200, "")
+	}
+}
+
 // PauseTorrent ...
 func PauseTorrent(s *bittorrent.Service) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
@@ -534,6 +674,51 @@ func SelectFileTorrent(s *bittorrent.Service) gin.HandlerFunc {
 	}
 }
 
+// TorrentPeers returns connected peers of a torrent
+func TorrentPeers(s *bittorrent.Service) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		torrentID := ctx.Params.ByName("torrentId")
+		torrent, err := GetTorrentFromParam(s, torrentID)
+		if err != nil {
+			ctx.String(404, fmt.Sprintf("Unable to find torrent with index %s", torrentID))
+			return
+		}
+
+		ctx.Writer.Header().Set("Access-Control-Allow-Origin", "*")
+		ctx.JSON(200, torrent.GetPeers())
+	}
+}
+
+// TorrentTrackers returns trackers of a torrent with their announce status
+func TorrentTrackers(s *bittorrent.Service) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		torrentID := ctx.Params.ByName("torrentId")
+		torrent, err := GetTorrentFromParam(s, torrentID)
+		if err != nil {
+			ctx.String(404, fmt.Sprintf("Unable to find torrent with index %s", torrentID))
+			return
+		}
+
+		ctx.Writer.Header().Set("Access-Control-Allow-Origin", "*")
+		ctx.JSON(200, torrent.GetTrackers())
+	}
+}
+
+// TorrentPieces returns pieces map of a torrent
+func TorrentPieces(s *bittorrent.Service) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		torrentID := ctx.Params.ByName("torrentId")
+		torrent, err := GetTorrentFromParam(s, torrentID)
+		if err != nil {
+			ctx.String(404, fmt.Sprintf("Unable to find torrent with index %s", torrentID))
+			return
+		}
+
+		ctx.Writer.Header().Set("Access-Control-Allow-Origin", "*")
+		ctx.JSON(200, torrent.GetPiecesMap())
+	}
+}
+
 // Versions ...
 func Versions(s *bittorrent.Service) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
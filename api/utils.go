@@ -3,8 +3,10 @@ package api
 import (
 	"fmt"
 	"net/url"
+	"path/filepath"
 	"strings"
 
+	"github.com/mrjdainc/da-inc/bittorrent"
 	"github.com/mrjdainc/da-inc/config"
 	"github.com/mrjdainc/da-inc/util"
 	"github.com/mrjdainc/da-inc/xbmc"
@@ -95,4 +97,35 @@ func detectPlayAction(action string) string {
 	}
 
 	return action
-}
\ No newline at end of file
+}
+
+// torrentContents describes files of the torrent, if they are known, for links dialog
+func torrentContents(t *bittorrent.TorrentFile) string {
+	if len(t.Files) == 0 {
+		return ""
+	}
+
+	video := t.LargestVideoFile()
+	if video == nil {
+		return fmt.Sprintf(" [COLOR gray](%d files)[/COLOR]", len(t.Files))
+	}
+	return fmt.Sprintf(" [COLOR gray](%d files, %s)[/COLOR]", len(t.Files), filepath.Base(video.Name))
+}
+
+// droppedChoices returns links dialog items for results, dropped by filter rules
+func droppedChoices(dropped []*bittorrent.TorrentFile) []string {
+	ret := make([]string, 0, len(dropped))
+	for _, t := range dropped {
+		ret = append(ret, fmt.Sprintf("[COLOR gray]%s[/COLOR]\n[COLOR red]%s[/COLOR]", t.Name, t.Dropped))
+	}
+	return ret
+}
+
+// showDropped explains why results were dropped by filter rules
+func showDropped(dropped []*bittorrent.TorrentFile) {
+	lines := make([]string, 0, len(dropped))
+	for _, t := range dropped {
+		lines = append(lines, fmt.Sprintf("[B]%s[/B]\n%s", t.Name, t.Dropped))
+	}
+	xbmc.DialogText("LOCALIZE[30616]", strings.Join(lines, "\n\n"))
+}
@@ -0,0 +1,713 @@
+package bittorrent
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Archive types
+const (
+	ArchiveRar = iota
+	ArchiveZip
+)
+
+const (
+	archiveHeaderChunk = 4096
+	// RAR5 headers can't be bigger than 2MB
+	rar5MaxHeaderSize = 2 * 1024 * 1024
+)
+
+var (
+	rarPartRegex   = regexp.MustCompile(`(?i)^(.*)\.part0*(\d+)\.rar$`)
+	rarOldRegex    = regexp.MustCompile(`(?i)^(.*)\.(rar|[r-y]\d{2})$`)
+	zipRegex       = regexp.MustCompile(`(?i)^(.*)\.(zip|z\d{2})$`)
+	videoFileRegex = regexp.MustCompile(`(?i).*\.(mkv|mp4|mov|avi|m4v|wmv|ts|m2ts)$`)
+
+	rar4Signature = []byte("Rar!\x1a\x07\x00")
+	rar5Signature = []byte("Rar!\x1a\x07\x01\x00")
+
+	errArchiveCompressed = errors.New("Archive is compressed, only store mode is supported")
+	errArchiveEncrypted  = errors.New("Archive is encrypted")
+)
+
+// ArchiveSegment is a part of archived file, that is stored in one volume
+type ArchiveSegment struct {
+	Volume *File
+	// Offset of the data inside the volume
+	Offset int64
+	Length int64
+	// Start is a position of this part in the archived file
+	Start int64
+}
+
+// ArchiveEntry is a file, stored without compression in a (multi-volume) archive
+type ArchiveEntry struct {
+	Type     int
+	Name     string
+	Size     int64
+	Segments []*ArchiveSegment
+	Volumes  []*File
+
+	// File is a virtual file, that is served by TorrentFS
+	File *File
+
+	compressed bool
+	encrypted  bool
+	isDir      bool
+}
+
+// IsArchiveVolume checks if file looks like a RAR or ZIP volume
+func IsArchiveVolume(f *File) bool {
+	if f == nil {
+		return false
+	}
+
+	name := filepath.Base(f.Path)
+	return rarPartRegex.MatchString(name) || rarOldRegex.MatchString(name) || zipRegex.MatchString(name)
+}
+
+// segment returns the segment, containing the position
+func (a *ArchiveEntry) segment(pos int64) *ArchiveSegment {
+	if len(a.Segments) == 0 {
+		return nil
+	}
+
+	i := sort.Search(len(a.Segments), func(i int) bool {
+		return a.Segments[i].Start+a.Segments[i].Length > pos
+	})
+	if i >= len(a.Segments) {
+		i = len(a.Segments) - 1
+	}
+	return a.Segments[i]
+}
+
+// TorrentOffset maps position in the archived file to the offset in torrent
+func (a *ArchiveEntry) TorrentOffset(pos int64) int64 {
+	s := a.segment(pos)
+	if s == nil {
+		return 0
+	}
+
+	return s.Volume.Offset + s.Offset + pos - s.Start
+}
+
+// ContiguousLeft returns the number of bytes, stored contiguously in a torrent from the position
+func (a *ArchiveEntry) ContiguousLeft(pos int64) int64 {
+	s := a.segment(pos)
+	if s == nil {
+		return 0
+	}
+
+	left := s.Start + s.Length - pos
+	if left < 0 {
+		return 0
+	}
+	return left
+}
+
+// HasVolume checks if file is one of archive volumes
+func (a *ArchiveEntry) HasVolume(f *File) bool {
+	for _, v := range a.Volumes {
+		if v == f {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *ArchiveEntry) addSegment(vol *File, offset, length int64) {
+	start := int64(0)
+	if len(a.Segments) > 0 {
+		last := a.Segments[len(a.Segments)-1]
+		start = last.Start + last.Length
+	}
+
+	a.Segments = append(a.Segments, &ArchiveSegment{
+		Volume: vol,
+		Offset: offset,
+		Length: length,
+		Start:  start,
+	})
+}
+
+func (a *ArchiveEntry) packedSize() (size int64) {
+	for _, s := range a.Segments {
+		size += s.Length
+	}
+	return
+}
+
+// GetArchive returns opened archive entry for the virtual file
+func (t *Torrent) GetArchive(f *File) *ArchiveEntry {
+	if t.archive != nil && t.archive.File == f {
+		return t.archive
+	}
+	return nil
+}
+
+func (t *Torrent) getArchiveByPath(path string) *ArchiveEntry {
+	if t.archive != nil && t.archive.File.Path == path {
+		return t.archive
+	}
+	return nil
+}
+
+// OpenArchive reads headers of the archive, that file belongs to,
+// and prepares a virtual file for the biggest video inside
+func (t *Torrent) OpenArchive(f *File) (*ArchiveEntry, error) {
+	kind, volumes := t.archiveVolumes(f)
+	if len(volumes) == 0 {
+		return nil, fmt.Errorf("No archive volumes found for %s", f.Path)
+	}
+
+	log.Infof("Opening archive %s with %d volumes", volumes[0].Path, len(volumes))
+
+	r := &archiveReader{
+		readAt:  t.readFileAt,
+		volumes: volumes,
+	}
+
+	// Headers are at the start of each volume, so we request them all together
+	for _, v := range volumes {
+		t.requestFileRegion(v, 0, archiveHeaderChunk)
+	}
+
+	var entries []*ArchiveEntry
+	var err error
+	if kind == ArchiveRar {
+		entries, err = r.parseRar()
+	} else {
+		entries, err = r.parseZip()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var chosen *ArchiveEntry
+	for _, e := range entries {
+		if e.isDir || e.Size == 0 {
+			continue
+		}
+		// Videos win over other files, then the biggest one
+		if chosen == nil {
+			chosen = e
+		} else if isVideo, chosenVideo := videoFileRegex.MatchString(e.Name), videoFileRegex.MatchString(chosen.Name); isVideo != chosenVideo {
+			if isVideo {
+				chosen = e
+			}
+		} else if e.Size > chosen.Size {
+			chosen = e
+		}
+	}
+
+	if chosen == nil {
+		return nil, errors.New("No files found in archive")
+	} else if chosen.encrypted {
+		return nil, errArchiveEncrypted
+	} else if chosen.compressed {
+		return nil, errArchiveCompressed
+	} else if chosen.packedSize() != chosen.Size {
+		return nil, fmt.Errorf("Archived file %s is incomplete: %d of %d bytes found", chosen.Name, chosen.packedSize(), chosen.Size)
+	}
+
+	first := chosen.Segments[0]
+	last := chosen.Segments[len(chosen.Segments)-1]
+	start := first.Volume.Offset + first.Offset
+	end := last.Volume.Offset + last.Offset + last.Length
+	chosen.Type = kind
+	chosen.Volumes = volumes
+	chosen.File = &File{
+		Index:      first.Volume.Index,
+		Name:       filepath.Base(chosen.Name),
+		Size:       chosen.Size,
+		Path:       filepath.Join(filepath.Dir(volumes[0].Path), filepath.Base(chosen.Name)),
+		Offset:     start,
+		PieceStart: int(start / t.pieceLength),
+		PieceEnd:   int((end - 1) / t.pieceLength),
+	}
+
+	log.Infof("Streaming %s (%d bytes in %d parts) from archive %s", chosen.Name, chosen.Size, len(chosen.Segments), volumes[0].Path)
+
+	t.archive = chosen
+	return chosen, nil
+}
+
+// archiveVolumes returns all volumes of the archive, that file belongs to, in order
+func (t *Torrent) archiveVolumes(f *File) (kind int, volumes []*File) {
+	dir := filepath.Dir(f.Path)
+	name := filepath.Base(f.Path)
+
+	var re *regexp.Regexp
+	if rarPartRegex.MatchString(name) {
+		kind, re = ArchiveRar, rarPartRegex
+	} else if rarOldRegex.MatchString(name) {
+		kind, re = ArchiveRar, rarOldRegex
+	} else if zipRegex.MatchString(name) {
+		kind, re = ArchiveZip, zipRegex
+	} else {
+		return
+	}
+	base := strings.ToLower(re.FindStringSubmatch(name)[1])
+
+	order := map[*File]int{}
+	for _, v := range t.files {
+		if filepath.Dir(v.Path) != dir {
+			continue
+		}
+
+		m := re.FindStringSubmatch(filepath.Base(v.Path))
+		if m == nil || strings.ToLower(m[1]) != base {
+			continue
+		}
+
+		volumes = append(volumes, v)
+		order[v] = volumeOrder(re, strings.ToLower(m[2]))
+	}
+
+	sort.SliceStable(volumes, func(i, j int) bool {
+		return order[volumes[i]] < order[volumes[j]]
+	})
+	return
+}
+
+// volumeOrder returns position of the volume by its suffix:
+// name.part1.rar, name.part2.rar or name.rar, name.r00, name.s00 or name.z01, name.zip
+func volumeOrder(re *regexp.Regexp, suffix string) int {
+	if re == rarPartRegex {
+		n, _ := strconv.Atoi(suffix)
+		return n
+	}
+
+	switch suffix {
+	case "rar":
+		return -1
+	case "zip":
+		return 1 << 20
+	}
+
+	n, _ := strconv.Atoi(suffix[1:])
+	if re == rarOldRegex {
+		n += int(suffix[0]-'r') * 100
+	}
+	return n
+}
+
+type archiveReader struct {
+	readAt  func(f *File, b []byte, off int64) error
+	volumes []*File
+}
+
+// readHeader reads up to size bytes, limited by the volume end
+func (r *archiveReader) readHeader(vol *File, off, size int64) ([]byte, error) {
+	if off+size > vol.Size {
+		size = vol.Size - off
+	}
+	if size <= 0 {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	b := make([]byte, size)
+	return b, r.readAt(vol, b, off)
+}
+
+func (r *archiveReader) parseRar() ([]*ArchiveEntry, error) {
+	entries := []*ArchiveEntry{}
+	byName := map[string]*ArchiveEntry{}
+
+	add := func(name string) *ArchiveEntry {
+		if e, ok := byName[name]; ok {
+			return e
+		}
+		e := &ArchiveEntry{Name: name}
+		byName[name] = e
+		entries = append(entries, e)
+		return e
+	}
+
+	for _, vol := range r.volumes {
+		sig, err := r.readHeader(vol, 0, int64(len(rar5Signature)))
+		if err != nil {
+			return nil, err
+		}
+
+		if bytes.Equal(sig, rar5Signature) {
+			err = r.parseRar5Volume(vol, add)
+		} else if bytes.HasPrefix(sig, rar4Signature) {
+			err = r.parseRar4Volume(vol, add)
+		} else {
+			err = fmt.Errorf("%s is not a RAR volume", vol.Path)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return entries, nil
+}
+
+func (r *archiveReader) parseRar4Volume(vol *File, add func(string) *ArchiveEntry) error {
+	pos := int64(len(rar4Signature))
+
+	for pos+7 <= vol.Size {
+		hdr, err := r.readHeader(vol, pos, archiveHeaderChunk)
+		if err != nil {
+			return err
+		} else if len(hdr) < 7 {
+			return io.ErrUnexpectedEOF
+		}
+
+		blockType := hdr[2]
+		flags := binary.LittleEndian.Uint16(hdr[3:])
+		size := int64(binary.LittleEndian.Uint16(hdr[5:]))
+		if size < 7 {
+			return fmt.Errorf("Broken RAR header in %s", vol.Path)
+		}
+		if size > int64(len(hdr)) {
+			if hdr, err = r.readHeader(vol, pos, size); err != nil {
+				return err
+			}
+		}
+		// Header is cut by the end of the volume
+		if size > int64(len(hdr)) {
+			return io.ErrUnexpectedEOF
+		}
+
+		addSize := int64(0)
+		if flags&0x8000 != 0 && size >= 11 {
+			addSize = int64(binary.LittleEndian.Uint32(hdr[7:]))
+		}
+
+		switch blockType {
+		case 0x74:
+			if size < 32 {
+				return fmt.Errorf("Broken RAR file header in %s", vol.Path)
+			}
+
+			packSize := int64(binary.LittleEndian.Uint32(hdr[7:]))
+			unpSize := int64(binary.LittleEndian.Uint32(hdr[11:]))
+			method := hdr[25]
+			nameSize := int64(binary.LittleEndian.Uint16(hdr[26:]))
+			nameOffset := int64(32)
+			if flags&0x100 != 0 {
+				if size < 40 {
+					return fmt.Errorf("Broken RAR file header in %s", vol.Path)
+				}
+				packSize |= int64(binary.LittleEndian.Uint32(hdr[32:])) << 32
+				unpSize |= int64(binary.LittleEndian.Uint32(hdr[36:])) << 32
+				nameOffset = 40
+			}
+			if nameOffset+nameSize > size {
+				return fmt.Errorf("Broken RAR file name in %s", vol.Path)
+			} else if packSize < 0 {
+				return fmt.Errorf("Broken RAR file size in %s", vol.Path)
+			}
+
+			name := hdr[nameOffset : nameOffset+nameSize]
+			if idx := bytes.IndexByte(name, 0); idx >= 0 {
+				name = name[:idx]
+			}
+
+			e := add(string(name))
+			e.Size = unpSize
+			e.isDir = flags&0xe0 == 0xe0
+			e.encrypted = e.encrypted || flags&0x04 != 0
+			e.compressed = e.compressed || method != 0x30
+			e.addSegment(vol, pos+size, packSize)
+
+			pos += size + packSize
+		case 0x7b:
+			return nil
+		default:
+			pos += size + addSize
+		}
+	}
+
+	return nil
+}
+
+func (r *archiveReader) parseRar5Volume(vol *File, add func(string) *ArchiveEntry) error {
+	pos := int64(len(rar5Signature))
+
+	for pos+7 <= vol.Size {
+		hdr, err := r.readHeader(vol, pos, archiveHeaderChunk)
+		if err != nil {
+			return err
+		}
+
+		// CRC32 goes before the header size
+		if len(hdr) <= 4 {
+			return io.ErrUnexpectedEOF
+		}
+		headerSize, n := readVint(hdr[4:])
+		if n == 0 || headerSize == 0 || headerSize > rar5MaxHeaderSize {
+			return fmt.Errorf("Broken RAR header in %s", vol.Path)
+		}
+		start := int64(4 + n)
+		size := start + int64(headerSize)
+		if size > int64(len(hdr)) {
+			if hdr, err = r.readHeader(vol, pos, size); err != nil {
+				return err
+			}
+		}
+		// Header is cut by the end of the volume
+		if size > int64(len(hdr)) {
+			return io.ErrUnexpectedEOF
+		}
+
+		v := &vintReader{b: hdr[start:size]}
+		blockType := v.read()
+		flags := v.read()
+		extraSize := uint64(0)
+		if flags&0x01 != 0 {
+			extraSize = v.read()
+		}
+		dataSize := int64(0)
+		if flags&0x02 != 0 {
+			dataSize = int64(v.read())
+		}
+		if v.err || dataSize < 0 {
+			return fmt.Errorf("Broken RAR header in %s", vol.Path)
+		}
+
+		switch blockType {
+		case 2:
+			fileFlags := v.read()
+			unpSize := int64(v.read())
+			v.read() // attributes
+			if fileFlags&0x02 != 0 {
+				v.skip(4)
+			}
+			if fileFlags&0x04 != 0 {
+				v.skip(4)
+			}
+			compression := v.read()
+			v.read() // host OS
+			nameSize := int(v.read())
+			name := v.bytes(nameSize)
+			if v.err {
+				return fmt.Errorf("Broken RAR file header in %s", vol.Path)
+			}
+
+			e := add(string(name))
+			e.Size = unpSize
+			e.isDir = fileFlags&0x01 != 0
+			e.compressed = e.compressed || (compression>>7)&0x07 != 0
+			if extraSize > 0 && extraSize <= uint64(len(v.b)) {
+				e.encrypted = e.encrypted || rar5HasEncryption(v.b[uint64(len(v.b))-extraSize:])
+			}
+			e.addSegment(vol, pos+size, dataSize)
+		case 4:
+			return errArchiveEncrypted
+		case 5:
+			return nil
+		}
+
+		pos += size + dataSize
+	}
+
+	return nil
+}
+
+// rar5HasEncryption looks for the encryption record in the extra area of the file header
+func rar5HasEncryption(extra []byte) bool {
+	v := &vintReader{b: extra}
+	for len(v.b) > 0 && !v.err {
+		size := int(v.read())
+		if v.err || size <= 0 || size > len(v.b) {
+			return false
+		}
+
+		record := &vintReader{b: v.b[:size]}
+		if record.read() == 0x01 {
+			return true
+		}
+		v.skip(size)
+	}
+	return false
+}
+
+func readVint(b []byte) (value uint64, n int) {
+	for i := 0; i < len(b) && i < 10; i++ {
+		value |= uint64(b[i]&0x7f) << (7 * uint(i))
+		if b[i]&0x80 == 0 {
+			return value, i + 1
+		}
+	}
+	return 0, 0
+}
+
+type vintReader struct {
+	b   []byte
+	err bool
+}
+
+func (v *vintReader) read() uint64 {
+	value, n := readVint(v.b)
+	if n == 0 {
+		v.err = true
+		return 0
+	}
+	v.b = v.b[n:]
+	return value
+}
+
+func (v *vintReader) skip(n int) {
+	if n > len(v.b) {
+		v.err = true
+		n = len(v.b)
+	}
+	v.b = v.b[n:]
+}
+
+func (v *vintReader) bytes(n int) []byte {
+	if n > len(v.b) {
+		v.err = true
+		return nil
+	}
+	ret := v.b[:n]
+	v.b = v.b[n:]
+	return ret
+}
+
+// locate maps position in concatenated volumes to the volume and offset in it
+func (r *archiveReader) locate(pos int64) (*File, int64) {
+	for _, v := range r.volumes {
+		if pos < v.Size {
+			return v, pos
+		}
+		pos -= v.Size
+	}
+	return nil, 0
+}
+
+// readSpanned reads from concatenated volumes, as split ZIP archives are continuous
+func (r *archiveReader) readSpanned(pos, size int64) ([]byte, error) {
+	ret := []byte{}
+	for size > 0 {
+		vol, off := r.locate(pos)
+		if vol == nil {
+			return nil, io.ErrUnexpectedEOF
+		}
+
+		n := size
+		if off+n > vol.Size {
+			n = vol.Size - off
+		}
+		b, err := r.readHeader(vol, off, n)
+		if err != nil {
+			return nil, err
+		}
+
+		ret = append(ret, b...)
+		pos += n
+		size -= n
+	}
+	return ret, nil
+}
+
+func (r *archiveReader) parseZip() ([]*ArchiveEntry, error) {
+	entries := []*ArchiveEntry{}
+
+	total := int64(0)
+	for _, v := range r.volumes {
+		total += v.Size
+	}
+
+	pos := int64(0)
+	if sig, err := r.readSpanned(0, 4); err != nil {
+		return nil, err
+	} else if binary.LittleEndian.Uint32(sig) == 0x08074b50 {
+		// Split archive marker
+		pos = 4
+	}
+
+	for pos+30 <= total {
+		hdr, err := r.readSpanned(pos, 30)
+		if err != nil {
+			return nil, err
+		}
+		if binary.LittleEndian.Uint32(hdr) != 0x04034b50 {
+			break
+		}
+
+		flags := binary.LittleEndian.Uint16(hdr[6:])
+		method := binary.LittleEndian.Uint16(hdr[8:])
+		packSize := int64(binary.LittleEndian.Uint32(hdr[18:]))
+		unpSize := int64(binary.LittleEndian.Uint32(hdr[22:]))
+		nameSize := int64(binary.LittleEndian.Uint16(hdr[26:]))
+		extraSize := int64(binary.LittleEndian.Uint16(hdr[28:]))
+
+		rest, err := r.readSpanned(pos+30, nameSize+extraSize)
+		if err != nil {
+			return nil, err
+		}
+		name := string(rest[:nameSize])
+
+		// Zip64 extended information
+		extra := rest[nameSize:]
+		for len(extra) >= 4 {
+			id := binary.LittleEndian.Uint16(extra)
+			size := int(binary.LittleEndian.Uint16(extra[2:]))
+			if 4+size > len(extra) {
+				break
+			}
+			if id == 0x0001 {
+				field := extra[4 : 4+size]
+				if unpSize == 0xffffffff && len(field) >= 8 {
+					unpSize = int64(binary.LittleEndian.Uint64(field))
+					field = field[8:]
+				}
+				if packSize == 0xffffffff && len(field) >= 8 {
+					packSize = int64(binary.LittleEndian.Uint64(field))
+				}
+			}
+			extra = extra[4+size:]
+		}
+
+		if flags&0x08 != 0 && packSize == 0 {
+			return nil, fmt.Errorf("ZIP entry %s has no size in local header", name)
+		}
+
+		e := &ArchiveEntry{
+			Name:       name,
+			Size:       unpSize,
+			isDir:      strings.HasSuffix(name, "/"),
+			encrypted:  flags&0x01 != 0,
+			compressed: method != 0,
+		}
+
+		dataStart := pos + 30 + nameSize + extraSize
+		for left, p := packSize, dataStart; left > 0; {
+			vol, off := r.locate(p)
+			if vol == nil {
+				break
+			}
+
+			n := left
+			if off+n > vol.Size {
+				n = vol.Size - off
+			}
+			e.addSegment(vol, off, n)
+
+			left -= n
+			p += n
+		}
+		entries = append(entries, e)
+
+		if flags&0x08 != 0 {
+			// Data descriptor size is not fixed, so we stop here
+			break
+		}
+		pos = dataStart + packSize
+	}
+
+	return entries, nil
+}
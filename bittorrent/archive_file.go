@@ -0,0 +1,163 @@
+package bittorrent
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	lt "github.com/da-inc/libtorrent-go"
+)
+
+// ArchiveFile serves a file, stored inside the archive volumes
+type ArchiveFile struct {
+	tfs *TorrentFS
+	a   *ArchiveEntry
+	ms  lt.MemoryStorage
+
+	mu      sync.Mutex
+	pos     int64
+	volumes map[int]*os.File
+}
+
+// NewArchiveFile ...
+func NewArchiveFile(tfs *TorrentFS, t *Torrent, a *ArchiveEntry) *ArchiveFile {
+	af := &ArchiveFile{
+		tfs:     tfs,
+		a:       a,
+		volumes: map[int]*os.File{},
+	}
+	if tfs.s.IsMemoryStorage() {
+		af.ms = t.th.GetMemoryStorage().(lt.MemoryStorage)
+	}
+
+	return af
+}
+
+// Close ...
+func (af *ArchiveFile) Close() (err error) {
+	af.mu.Lock()
+	defer af.mu.Unlock()
+
+	for idx, f := range af.volumes {
+		if e := f.Close(); e != nil {
+			err = e
+		}
+		delete(af.volumes, idx)
+	}
+	return
+}
+
+// Read reads from the volume files, not crossing the segment boundary
+func (af *ArchiveFile) Read(b []byte) (n int, err error) {
+	af.mu.Lock()
+	defer af.mu.Unlock()
+
+	if af.pos >= af.a.Size {
+		return 0, io.EOF
+	}
+
+	s := af.a.segment(af.pos)
+	if left := af.a.ContiguousLeft(af.pos); int64(len(b)) > left {
+		b = b[:left]
+	}
+
+	f, ok := af.volumes[s.Volume.Index]
+	if !ok {
+		if f, err = os.Open(filepath.Join(string(af.tfs.Dir), s.Volume.Path)); err != nil {
+			return
+		}
+		if err := unlockFile(f); err != nil {
+			log.Errorf("Unable to unlock file because: %s", err)
+		}
+		af.volumes[s.Volume.Index] = f
+	}
+
+	n, err = f.ReadAt(b, s.Offset+af.pos-s.Start)
+	af.pos += int64(n)
+	if err == io.EOF && af.pos < af.a.Size {
+		err = io.ErrUnexpectedEOF
+	}
+	return
+}
+
+// ReadPiece reads from the memory storage
+func (af *ArchiveFile) ReadPiece(b []byte, piece int, pieceOffset int) (n int, err error) {
+	af.mu.Lock()
+	defer af.mu.Unlock()
+
+	n = af.ms.Read(b, len(b), piece, pieceOffset)
+	if n == -1 {
+		return 0, io.ErrShortBuffer
+	} else if len(b) != n {
+		return n, io.ErrUnexpectedEOF
+	}
+
+	af.pos += int64(n)
+	if af.pos >= af.a.Size {
+		err = io.EOF
+	}
+	return
+}
+
+// Seek ...
+func (af *ArchiveFile) Seek(off int64, whence int) (ret int64, err error) {
+	af.mu.Lock()
+	defer af.mu.Unlock()
+
+	switch whence {
+	case io.SeekStart:
+		af.pos = off
+	case io.SeekCurrent:
+		af.pos += off
+	case io.SeekEnd:
+		af.pos = af.a.Size + off
+	default:
+		err = errors.New("bad whence")
+	}
+	ret = af.pos
+
+	return
+}
+
+// Readdir ...
+func (af *ArchiveFile) Readdir(count int) (ret []os.FileInfo, err error) {
+	return
+}
+
+// Stat ...
+func (af *ArchiveFile) Stat() (ret os.FileInfo, err error) {
+	return af, nil
+}
+
+// Name ...
+func (af *ArchiveFile) Name() string {
+	return af.a.File.Name
+}
+
+// Size ...
+func (af *ArchiveFile) Size() int64 {
+	return af.a.Size
+}
+
+// Mode ...
+func (af *ArchiveFile) Mode() os.FileMode {
+	return 0777
+}
+
+// ModTime ...
+func (af *ArchiveFile) ModTime() time.Time {
+	return time.Now()
+}
+
+// IsDir ...
+func (af *ArchiveFile) IsDir() bool {
+	return false
+}
+
+// Sys ...
+func (af *ArchiveFile) Sys() interface{} {
+	return nil
+}
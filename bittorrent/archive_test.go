@@ -0,0 +1,196 @@
+package bittorrent
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"testing"
+)
+
+var archiveData = bytes.Repeat([]byte("0123456789abcdef"), 4)
+
+// newMemoryArchive returns reader of a single volume, which is kept in memory
+func newMemoryArchive(b []byte) (*archiveReader, *File) {
+	vol := &File{Path: "test.rar", Size: int64(len(b))}
+	return &archiveReader{
+		readAt: func(f *File, buf []byte, off int64) error {
+			if off < 0 || off+int64(len(buf)) > int64(len(b)) {
+				return io.ErrUnexpectedEOF
+			}
+			copy(buf, b[off:])
+			return nil
+		},
+		volumes: []*File{vol},
+	}, vol
+}
+
+func rar4Header(blockType byte, flags uint16, body []byte) []byte {
+	hdr := []byte{0, 0, blockType}
+	hdr = append(hdr, 0, 0, 0, 0)
+	binary.LittleEndian.PutUint16(hdr[3:], flags)
+	binary.LittleEndian.PutUint16(hdr[5:], uint16(7+len(body)))
+	return append(hdr, body...)
+}
+
+func rar4FileHeader(name string, size int, large bool) []byte {
+	flags := uint16(0x8000)
+	body := make([]byte, 25)
+	binary.LittleEndian.PutUint32(body[0:], uint32(size))
+	binary.LittleEndian.PutUint32(body[4:], uint32(size))
+	body[18] = 0x30
+	binary.LittleEndian.PutUint16(body[19:], uint16(len(name)))
+	if large {
+		flags |= 0x100
+		body = append(body, make([]byte, 8)...)
+	}
+	return rar4Header(0x74, flags, append(body, name...))
+}
+
+func rar4Volume(headers ...[]byte) []byte {
+	b := append([]byte{}, rar4Signature...)
+	for _, h := range headers {
+		b = append(b, h...)
+	}
+	return append(b, rar4Header(0x7b, 0, nil)...)
+}
+
+func rar5Header(fields ...[]byte) []byte {
+	body := []byte{}
+	for _, f := range fields {
+		body = append(body, f...)
+	}
+	hdr := []byte{0, 0, 0, 0, byte(len(body))}
+	return append(hdr, body...)
+}
+
+func rar5FileHeader(name string, size int) []byte {
+	return rar5Header(
+		[]byte{2, 0x02, byte(size)}, // type, flags, data size
+		[]byte{0, byte(size), 0},    // file flags, unpacked size, attributes
+		[]byte{0, 0, byte(len(name))},
+		[]byte(name),
+	)
+}
+
+func rar5Volume(headers ...[]byte) []byte {
+	b := append([]byte{}, rar5Signature...)
+	for _, h := range headers {
+		b = append(b, h...)
+	}
+	return append(b, rar5Header([]byte{5, 0})...)
+}
+
+func parseRarEntries(b []byte) ([]*ArchiveEntry, error) {
+	r, _ := newMemoryArchive(b)
+	return r.parseRar()
+}
+
+func TestParseRar4Volume(t *testing.T) {
+	file := append(rar4FileHeader("movie.mkv", len(archiveData), false), archiveData...)
+	large := append(rar4FileHeader("movie.mkv", len(archiveData), true), archiveData...)
+
+	shortLarge := rar4FileHeader("", len(archiveData), false)
+	binary.LittleEndian.PutUint16(shortLarge[3:], 0x8100)
+
+	longName := rar4FileHeader("movie.mkv", len(archiveData), false)
+	binary.LittleEndian.PutUint16(longName[26:], 0xff)
+
+	valid := rar4Volume(file)
+
+	tests := []struct {
+		name    string
+		input   []byte
+		entries int
+		wantErr bool
+	}{
+		{"stored file", valid, 1, false},
+		{"large file", rar4Volume(large), 1, false},
+		{"empty archive", rar4Volume(), 0, false},
+		{"two files", rar4Volume(file, append(rar4FileHeader("sample.mkv", len(archiveData), false), archiveData...)), 2, false},
+		{"header cut in the middle", valid[:len(rar4Signature)+20], 0, true},
+		{"large header cut before high sizes", rar4Volume(large)[:len(rar4Signature)+34], 0, true},
+		{"large flag on a short header", rar4Volume(append(shortLarge, archiveData...)), 0, true},
+		{"name beyond the header", rar4Volume(append(longName, archiveData...)), 0, true},
+		{"header size below minimum", append(append([]byte{}, rar4Signature...), 0, 0, 0x74, 0, 0, 3, 0), 0, true},
+	}
+
+	for _, test := range tests {
+		entries, err := parseRarEntries(test.input)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: got error %v, want error %v", test.name, err, test.wantErr)
+			continue
+		}
+		if err == nil && len(entries) != test.entries {
+			t.Errorf("%s: got %d entries, want %d", test.name, len(entries), test.entries)
+		}
+	}
+
+	entries, _ := parseRarEntries(valid)
+	if e := entries[0]; e.Name != "movie.mkv" || e.Size != int64(len(archiveData)) || e.compressed || e.packedSize() != e.Size {
+		t.Errorf("Wrong entry: %+v", e)
+	}
+}
+
+func TestParseRar5Volume(t *testing.T) {
+	file := append(rar5FileHeader("movie.mkv", len(archiveData)), archiveData...)
+	valid := rar5Volume(file)
+
+	hugeHeader := append([]byte{}, rar5Signature...)
+	hugeHeader = append(hugeHeader, 0, 0, 0, 0, 0xff, 0xff, 0xff, 0x7f, 2, 0)
+
+	brokenVint := append([]byte{}, rar5Signature...)
+	brokenVint = append(brokenVint, 0, 0, 0, 0, 0x80, 0x80, 0x80)
+
+	tests := []struct {
+		name    string
+		input   []byte
+		entries int
+		wantErr bool
+	}{
+		{"stored file", valid, 1, false},
+		{"empty archive", rar5Volume(), 0, false},
+		{"header cut in the middle", valid[:len(rar5Signature)+12], 0, true},
+		{"name beyond the header", rar5Volume(rar5Header([]byte{2, 0, 0, 0, 0, 0, 0, 20}, []byte("movie"))), 0, true},
+		{"header size over the limit", hugeHeader, 0, true},
+		{"unterminated header size", brokenVint, 0, true},
+		{"encrypted archive", rar5Volume(rar5Header([]byte{4, 0})), 0, true},
+	}
+
+	for _, test := range tests {
+		entries, err := parseRarEntries(test.input)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: got error %v, want error %v", test.name, err, test.wantErr)
+			continue
+		}
+		if err == nil && len(entries) != test.entries {
+			t.Errorf("%s: got %d entries, want %d", test.name, len(entries), test.entries)
+		}
+	}
+
+	entries, _ := parseRarEntries(valid)
+	if e := entries[0]; e.Name != "movie.mkv" || e.Size != int64(len(archiveData)) || e.compressed || e.packedSize() != e.Size {
+		t.Errorf("Wrong entry: %+v", e)
+	}
+}
+
+// Volumes, cut at any byte, should not crash the parsers
+func TestParseRarTruncated(t *testing.T) {
+	volumes := map[string][]byte{
+		"rar4":       rar4Volume(append(rar4FileHeader("movie.mkv", len(archiveData), false), archiveData...)),
+		"rar4 large": rar4Volume(append(rar4FileHeader("movie.mkv", len(archiveData), true), archiveData...)),
+		"rar5":       rar5Volume(append(rar5FileHeader("movie.mkv", len(archiveData)), archiveData...)),
+	}
+
+	for name, b := range volumes {
+		for i := len(rar5Signature); i < len(b); i++ {
+			func() {
+				defer func() {
+					if r := recover(); r != nil {
+						t.Errorf("%s: panic on volume cut at %d bytes: %v", name, i, r)
+					}
+				}()
+				parseRarEntries(b[:i])
+			}()
+		}
+	}
+}
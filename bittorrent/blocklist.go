@@ -0,0 +1,285 @@
+package bittorrent
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	lt "github.com/da-inc/libtorrent-go"
+
+	"github.com/mrjdainc/da-inc/proxy"
+)
+
+const blocklistCheckInterval = 1 * time.Hour
+
+// IPRange is an inclusive range of blocked addresses
+type IPRange struct {
+	Start net.IP
+	End   net.IP
+}
+
+// Blocklist keeps ranges, loaded from all configured sources
+type Blocklist struct {
+	Ranges  []IPRange
+	Sources string
+	Updated time.Time
+
+	mu sync.RWMutex
+}
+
+// Count returns number of blocked ranges
+func (b *Blocklist) Count() int {
+	if b == nil {
+		return 0
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return len(b.Ranges)
+}
+
+// LastUpdate returns time of the last successful load of all sources
+func (b *Blocklist) LastUpdate() time.Time {
+	if b == nil {
+		return time.Time{}
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return b.Updated
+}
+
+// ParseBlocklist reads ranges in PeerGuardian (.p2p), eMule (ipfilter.dat) or CIDR format,
+// format is detected for each line, gzipped input is unpacked.
+func ParseBlocklist(r io.Reader) ([]IPRange, error) {
+	br := bufio.NewReader(r)
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+
+		br = bufio.NewReader(gz)
+	}
+
+	ret := []IPRange{}
+	scanner := bufio.NewScanner(br)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "//") {
+			continue
+		}
+
+		if r := parseBlocklistLine(line); r != nil {
+			ret = append(ret, *r)
+		}
+	}
+
+	return ret, scanner.Err()
+}
+
+func parseBlocklistLine(line string) *IPRange {
+	// eMule: "001.002.003.004 - 001.002.003.255 , 000 , Description",
+	// ranges with access level above 127 are allowed.
+	if strings.Contains(line, ",") {
+		fields := strings.Split(line, ",")
+		if len(fields) >= 2 {
+			if level, err := strconv.Atoi(strings.TrimSpace(fields[1])); err == nil && level > 127 {
+				return nil
+			}
+		}
+		if r := parseBlocklistRange(fields[0]); r != nil {
+			return r
+		}
+	}
+
+	// PeerGuardian: "Description:1.2.3.4-1.2.3.255"
+	if idx := strings.LastIndex(line, ":"); idx >= 0 && strings.Contains(line[idx:], "-") {
+		if r := parseBlocklistRange(line[idx+1:]); r != nil {
+			return r
+		}
+	}
+
+	// CIDR or single address
+	if _, network, err := net.ParseCIDR(line); err == nil {
+		start := network.IP
+		end := make(net.IP, len(start))
+		for i := range start {
+			end[i] = start[i] | ^network.Mask[i]
+		}
+		return &IPRange{Start: start, End: end}
+	}
+
+	return parseBlocklistRange(line)
+}
+
+func parseBlocklistRange(value string) *IPRange {
+	tokens := strings.SplitN(value, "-", 2)
+
+	start := parseBlocklistIP(tokens[0])
+	if start == nil {
+		return nil
+	}
+	if len(tokens) == 1 {
+		return &IPRange{Start: start, End: start}
+	}
+
+	end := parseBlocklistIP(tokens[1])
+	if end == nil || (start.To4() == nil) != (end.To4() == nil) {
+		return nil
+	}
+
+	return &IPRange{Start: start, End: end}
+}
+
+// Addresses in ipfilter.dat are zero-padded, like 001.002.003.004
+func parseBlocklistIP(value string) net.IP {
+	value = strings.TrimSpace(value)
+	if strings.Count(value, ".") == 3 && !strings.Contains(value, ":") {
+		octets := strings.Split(value, ".")
+		for i, o := range octets {
+			if trimmed := strings.TrimLeft(o, "0"); trimmed != "" {
+				octets[i] = trimmed
+			} else {
+				octets[i] = "0"
+			}
+		}
+		value = strings.Join(octets, ".")
+	}
+
+	ip := net.ParseIP(value)
+	if ip4 := ip.To4(); ip4 != nil {
+		return ip4
+	}
+	return ip
+}
+
+func openBlocklistSource(source string) (io.ReadCloser, error) {
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		resp, err := proxy.GetClient().Get(source)
+		if err != nil {
+			return nil, err
+		} else if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, fmt.Errorf("Bad status: %d", resp.StatusCode)
+		}
+
+		return resp.Body, nil
+	}
+
+	return os.Open(source)
+}
+
+func blocklistSources(sources string) []string {
+	return strings.FieldsFunc(sources, func(r rune) bool {
+		return r == ',' || r == ';' || r == '\n'
+	})
+}
+
+// GetBlocklist ...
+func (s *Service) GetBlocklist() *Blocklist {
+	return s.blocklist
+}
+
+// LoadBlocklist reads ranges from all sources, if cached list is outdated, and applies them to the session
+func (s *Service) LoadBlocklist(force bool) {
+	s.blocklist.mu.Lock()
+	defer s.blocklist.mu.Unlock()
+
+	if !s.config.BlocklistEnabled {
+		if len(s.blocklist.Ranges) > 0 {
+			s.blocklist.Ranges = []IPRange{}
+			s.applyBlocklist()
+		}
+		return
+	}
+
+	refresh := time.Duration(s.config.BlocklistRefresh) * time.Hour
+	if force || s.blocklist.Sources != s.config.BlocklistSources || (refresh > 0 && time.Since(s.blocklist.Updated) > refresh) {
+		ranges := []IPRange{}
+		failed := false
+		for _, source := range blocklistSources(s.config.BlocklistSources) {
+			if source = strings.TrimSpace(source); source == "" {
+				continue
+			}
+
+			r, err := openBlocklistSource(source)
+			if err != nil {
+				log.Warningf("Could not open blocklist %s: %s", source, err)
+				failed = true
+				continue
+			}
+
+			parsed, err := ParseBlocklist(r)
+			r.Close()
+			if err != nil {
+				log.Warningf("Could not read blocklist %s: %s", source, err)
+				failed = true
+				continue
+			}
+
+			log.Infof("Loaded %d ranges from blocklist %s", len(parsed), source)
+			ranges = append(ranges, parsed...)
+		}
+
+		// Partial list should not replace the working one, sources are retried on next check
+		if failed {
+			log.Warningf("Keeping previous blocklist with %d ranges", len(s.blocklist.Ranges))
+		} else {
+			s.blocklist.Ranges = ranges
+			s.blocklist.Sources = s.config.BlocklistSources
+			s.blocklist.Updated = time.Now()
+		}
+	}
+
+	s.applyBlocklist()
+}
+
+func (s *Service) applyBlocklist() {
+	if s.Session == nil || s.Session.Swigcptr() == 0 {
+		return
+	}
+
+	filter := lt.NewIpFilter()
+	defer lt.DeleteIpFilter(filter)
+
+	for _, r := range s.blocklist.Ranges {
+		start := lt.AddressFromString(r.Start.String())
+		end := lt.AddressFromString(r.End.String())
+		filter.AddRule(start, end, uint(lt.IpFilterBlocked))
+		lt.DeleteAddress(start)
+		lt.DeleteAddress(end)
+	}
+
+	s.Session.SetIpFilter(filter)
+	log.Infof("Applied IP filter with %d blocked ranges", len(s.blocklist.Ranges))
+}
+
+func (s *Service) blocklistLoop() {
+	closing := s.Closer.C()
+	ticker := time.NewTicker(blocklistCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-closing:
+			return
+		case <-ticker.C:
+			if s.config.BlocklistEnabled {
+				s.LoadBlocklist(false)
+			}
+		}
+	}
+}
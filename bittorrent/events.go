@@ -0,0 +1,93 @@
+package bittorrent
+
+import (
+	"encoding/hex"
+
+	lt "github.com/da-inc/libtorrent-go"
+
+	"github.com/mrjdainc/da-inc/events"
+)
+
+// eventsConsumer translates torrent related alerts into events
+func (s *Service) eventsConsumer() {
+	alerts, alertsDone := s.Alerts()
+	closing := s.Closer.C()
+	defer close(alertsDone)
+
+	for {
+		select {
+		case <-closing:
+			return
+		case alert, ok := <-alerts:
+			if !ok {
+				return
+			}
+
+			switch alert.Type {
+			case lt.AddTorrentAlertAlertType:
+				addAlert := lt.SwigcptrAddTorrentAlert(alert.Pointer)
+				if addAlert.GetError().Value() != 0 {
+					continue
+				}
+
+				events.Publish(events.TorrentAdded, torrentEvent(addAlert.GetHandle()))
+
+			case lt.TorrentRemovedAlertAlertType:
+				removedAlert := lt.SwigcptrTorrentRemovedAlert(alert.Pointer)
+				infoHash := hex.EncodeToString([]byte(removedAlert.GetInfoHash().ToString()))
+
+				events.Publish(events.TorrentRemoved, &events.Torrent{
+					InfoHash: infoHash,
+					Name:     removedAlert.GetTorrentName(),
+				})
+
+			case lt.StateChangedAlertAlertType:
+				stateAlert := lt.SwigcptrStateChangedAlert(alert.Pointer)
+
+				e := torrentEvent(stateAlert.GetHandle())
+				e.State = StatusStrings[int(stateAlert.GetState())]
+				e.PrevState = StatusStrings[int(stateAlert.GetPrevState())]
+				events.Publish(events.TorrentStateChanged, e)
+
+			case lt.TorrentFinishedAlertAlertType:
+				finishedAlert := lt.SwigcptrTorrentFinishedAlert(alert.Pointer)
+				events.Publish(events.TorrentFinished, torrentEvent(finishedAlert.GetHandle()))
+			}
+		}
+	}
+}
+
+func torrentEvent(th lt.TorrentHandle) *events.Torrent {
+	ts := th.Status(uint(lt.WrappedTorrentHandleQueryName))
+	defer lt.DeleteTorrentStatus(ts)
+
+	return &events.Torrent{
+		InfoHash: hex.EncodeToString([]byte(ts.GetInfoHash().ToString())),
+		Name:     ts.GetName(),
+		State:    StatusStrings[int(ts.GetState())],
+	}
+}
+
+func (btp *Player) publishEvent(eventType string) {
+	if btp.t == nil {
+		return
+	}
+
+	e := &events.Player{
+		InfoHash:    btp.t.InfoHash(),
+		Name:        btp.t.Name(),
+		ContentType: btp.p.ContentType,
+		TMDBId:      btp.p.TMDBId,
+		Position:    btp.p.WatchedTime,
+		Duration:    btp.p.VideoDuration,
+		Progress:    btp.t.BufferProgress,
+	}
+	if btp.chosenFile != nil {
+		e.File = btp.chosenFile.Path
+	}
+	if e.Progress < 0 {
+		e.Progress = 0
+	}
+
+	events.Publish(eventType, e)
+}
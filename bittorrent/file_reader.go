@@ -0,0 +1,141 @@
+package bittorrent
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	lt "github.com/da-inc/libtorrent-go"
+)
+
+const fileReadTimeout = 2 * time.Minute
+
+// fileReader reads file contents directly from torrent pieces, waiting for them to be downloaded.
+// It is used to look into files headers before the file is opened by the player.
+type fileReader struct {
+	t       *Torrent
+	f       *File
+	archive *ArchiveEntry
+}
+
+func (t *Torrent) newFileReader(f *File) *fileReader {
+	return &fileReader{
+		t:       t,
+		f:       f,
+		archive: t.GetArchive(f),
+	}
+}
+
+// ReadAt implements io.ReaderAt
+func (r *fileReader) ReadAt(b []byte, off int64) (n int, err error) {
+	if off >= r.f.Size {
+		return 0, io.EOF
+	}
+	if left := r.f.Size - off; int64(len(b)) > left {
+		b = b[:left]
+		defer func() {
+			if err == nil {
+				err = io.EOF
+			}
+		}()
+	}
+
+	for n < len(b) {
+		vol, volOffset, size := r.f, off+int64(n), int64(len(b)-n)
+		if r.archive != nil {
+			pos := off + int64(n)
+			s := r.archive.segment(pos)
+			vol, volOffset = s.Volume, s.Offset+pos-s.Start
+			if left := r.archive.ContiguousLeft(pos); size > left {
+				size = left
+			}
+		}
+
+		if err = r.t.readFileAt(vol, b[n:n+int(size)], volOffset); err != nil {
+			return
+		}
+		n += int(size)
+	}
+	return
+}
+
+// requestFileRegion sets deadlines for pieces of the file region
+func (t *Torrent) requestFileRegion(f *File, off, size int64) (begin, end int) {
+	if off+size > f.Size {
+		size = f.Size - off
+	}
+	if size <= 0 {
+		return 0, -1
+	}
+
+	begin, end = t.byteRegionPieces(f.Offset+off, size)
+	for piece := begin; piece <= end; piece++ {
+		if t.hasPiece(piece) {
+			continue
+		}
+
+		t.th.PiecePriority(piece, 7)
+		t.th.SetPieceDeadline(piece, 0, 0)
+	}
+	return
+}
+
+// readFileAt waits for the pieces and reads the file region
+func (t *Torrent) readFileAt(f *File, b []byte, off int64) error {
+	if off < 0 || off+int64(len(b)) > f.Size {
+		return io.ErrUnexpectedEOF
+	}
+
+	begin, end := t.requestFileRegion(f, off, int64(len(b)))
+
+	timeout := time.After(fileReadTimeout)
+	ticker := time.NewTicker(piecesRefreshDuration)
+	defer ticker.Stop()
+	closing := t.Closer.C()
+
+	for piece := begin; piece <= end; {
+		if t.hasPiece(piece) {
+			piece++
+			continue
+		}
+
+		select {
+		case <-closing:
+			return errors.New("Torrent was closed")
+		case <-timeout:
+			return fmt.Errorf("Timed out waiting for piece %d of %s", piece, f.Path)
+		case <-ticker.C:
+		}
+	}
+
+	if !t.Service.IsMemoryStorage() {
+		fh, err := os.Open(filepath.Join(t.Service.config.DownloadPath, f.Path))
+		if err != nil {
+			return err
+		}
+		defer fh.Close()
+
+		_, err = fh.ReadAt(b, off)
+		return err
+	}
+
+	ms := t.th.GetMemoryStorage().(lt.MemoryStorage)
+	pos := 0
+	for pos < len(b) {
+		offset := f.Offset + off + int64(pos)
+		piece, pieceOffset := int(offset/t.pieceLength), int(offset%t.pieceLength)
+		size := len(b) - pos
+		if pieceOffset+size > int(t.pieceLength) {
+			size = int(t.pieceLength) - pieceOffset
+		}
+
+		if n := ms.Read(b[pos:pos+size], size, piece, pieceOffset); n != size {
+			return fmt.Errorf("Unable to read piece %d from memory", piece)
+		}
+		pos += size
+	}
+	return nil
+}
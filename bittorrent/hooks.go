@@ -0,0 +1,79 @@
+package bittorrent
+
+import (
+	"encoding/hex"
+
+	lt "github.com/da-inc/libtorrent-go"
+
+	"github.com/mrjdainc/da-inc/database"
+	"github.com/mrjdainc/da-inc/hooks"
+)
+
+func (t *Torrent) hookPayload() *hooks.Payload {
+	p := &hooks.Payload{
+		InfoHash: t.InfoHash(),
+		Name:     t.Name(),
+		SavePath: t.Service.config.DownloadPath,
+	}
+
+	item := t.DBItem
+	if item == nil {
+		item = database.GetStorm().GetBTItem(p.InfoHash)
+	}
+	return p.FromBTItem(item)
+}
+
+func (t *Torrent) triggerHook(event string) {
+	hooks.Trigger(event, t.hookPayload())
+}
+
+func (btp *Player) triggerHook(event string) {
+	if btp.t == nil {
+		return
+	}
+
+	p := btp.t.hookPayload()
+	if btp.chosenFile != nil {
+		p.Path = btp.chosenFile.Path
+	}
+	if btp.p.TMDBId != 0 {
+		p.MediaType = btp.p.ContentType
+		p.TMDBId = btp.p.TMDBId
+		p.ShowID = btp.p.ShowID
+		p.Season = btp.p.Season
+		p.Episode = btp.p.Episode
+	}
+
+	hooks.Trigger(event, p)
+}
+
+// hooksConsumer runs hooks for finished downloads
+func (s *Service) hooksConsumer() {
+	alerts, alertsDone := s.Alerts()
+	closing := s.Closer.C()
+	defer close(alertsDone)
+
+	for {
+		select {
+		case <-closing:
+			return
+		case alert, ok := <-alerts:
+			if !ok {
+				return
+			}
+
+			if alert.Type != lt.TorrentFinishedAlertAlertType {
+				continue
+			}
+
+			th := lt.SwigcptrTorrentFinishedAlert(alert.Pointer).GetHandle()
+			ts := th.Status(uint(lt.WrappedTorrentHandleQueryName))
+			infoHash := hex.EncodeToString([]byte(ts.GetInfoHash().ToString()))
+			lt.DeleteTorrentStatus(ts)
+
+			if t := s.GetTorrentByHash(infoHash); t != nil {
+				t.triggerHook(hooks.DownloadFinished)
+			}
+		}
+	}
+}
@@ -0,0 +1,270 @@
+package bittorrent
+
+import (
+	"encoding/base64"
+	"sort"
+
+	lt "github.com/da-inc/libtorrent-go"
+)
+
+// PeerInfo describes connected peer
+type PeerInfo struct {
+	IP         string   `json:"ip"`
+	Port       int      `json:"port"`
+	Client     string   `json:"client"`
+	Flags      []string `json:"flags"`
+	Encrypted  bool     `json:"encrypted"`
+	UTP        bool     `json:"utp"`
+	Seed       bool     `json:"seed"`
+	Progress   float64  `json:"progress"`
+	DownRate   int      `json:"down_rate"`
+	UpRate     int      `json:"up_rate"`
+	Downloaded int64    `json:"downloaded"`
+	Uploaded   int64    `json:"uploaded"`
+}
+
+// TrackerInfo describes tracker with its announce status
+type TrackerInfo struct {
+	URL          string `json:"url"`
+	Tier         int    `json:"tier"`
+	Working      bool   `json:"working"`
+	Updating     bool   `json:"updating"`
+	Fails        int    `json:"fails"`
+	Seeds        int    `json:"seeds"`
+	Peers        int    `json:"peers"`
+	NextAnnounce int    `json:"next_announce"`
+	MinAnnounce  int    `json:"min_announce"`
+	Message      string `json:"message"`
+	LastError    string `json:"last_error"`
+}
+
+// PiecesRun is a range of pieces with the same value, both ends inclusive
+type PiecesRun struct {
+	Begin int `json:"begin"`
+	End   int `json:"end"`
+	Value int `json:"value,omitempty"`
+}
+
+// PiecesMap is a compressed view of pieces state.
+// Have is a base64 encoded bitfield, other fields are run-length encoded.
+type PiecesMap struct {
+	Count        int         `json:"count"`
+	Length       int64       `json:"length"`
+	Completed    int         `json:"completed"`
+	Have         string      `json:"have"`
+	Availability []PiecesRun `json:"availability"`
+	Reserved     []PiecesRun `json:"reserved"`
+	Deadline     []PiecesRun `json:"deadline"`
+	Demanded     []PiecesRun `json:"demanded"`
+	Readers      []PiecesRun `json:"readers"`
+}
+
+var peerFlags = []struct {
+	flag int
+	name string
+}{
+	{int(lt.PeerInfoInteresting), "interesting"},
+	{int(lt.PeerInfoChoked), "choked"},
+	{int(lt.PeerInfoRemoteInterested), "remote_interested"},
+	{int(lt.PeerInfoRemoteChoked), "remote_choked"},
+	{int(lt.PeerInfoSupportsExtensions), "extensions"},
+	{int(lt.PeerInfoLocalConnection), "outgoing"},
+	{int(lt.PeerInfoHandshake), "handshake"},
+	{int(lt.PeerInfoConnecting), "connecting"},
+	{int(lt.PeerInfoOnParole), "on_parole"},
+	{int(lt.PeerInfoSeed), "seed"},
+	{int(lt.PeerInfoOptimisticUnchoke), "optimistic_unchoke"},
+	{int(lt.PeerInfoSnubbed), "snubbed"},
+	{int(lt.PeerInfoUploadOnly), "upload_only"},
+	{int(lt.PeerInfoEndgameMode), "endgame"},
+	{int(lt.PeerInfoHolepunched), "holepunched"},
+	{int(lt.PeerInfoUtpSocket), "utp"},
+	{int(lt.PeerInfoRc4Encrypted), "rc4_encrypted"},
+	{int(lt.PeerInfoPlaintextEncrypted), "plaintext_encrypted"},
+}
+
+// GetPeers returns list of connected peers
+func (t *Torrent) GetPeers() []PeerInfo {
+	ret := []PeerInfo{}
+	if t.th == nil || t.th.Swigcptr() == 0 || t.Closer.IsSet() {
+		return ret
+	}
+
+	peers := lt.NewStdVectorPeerInfo()
+	defer lt.DeleteStdVectorPeerInfo(peers)
+
+	t.th.GetPeerInfo(peers)
+	for i := 0; i < int(peers.Size()); i++ {
+		pi := peers.Get(i)
+		flags := pi.GetFlags()
+
+		peer := PeerInfo{
+			IP:         pi.GetIp().Address().ToString(),
+			Port:       int(pi.GetIp().Port()),
+			Client:     pi.GetClient(),
+			Flags:      []string{},
+			Encrypted:  flags&(int(lt.PeerInfoRc4Encrypted)|int(lt.PeerInfoPlaintextEncrypted)) != 0,
+			UTP:        flags&int(lt.PeerInfoUtpSocket) != 0,
+			Seed:       flags&int(lt.PeerInfoSeed) != 0,
+			Progress:   float64(pi.GetProgress()) * 100,
+			DownRate:   pi.GetDownSpeed(),
+			UpRate:     pi.GetUpSpeed(),
+			Downloaded: pi.GetTotalDownload(),
+			Uploaded:   pi.GetTotalUpload(),
+		}
+		for _, f := range peerFlags {
+			if flags&f.flag != 0 {
+				peer.Flags = append(peer.Flags, f.name)
+			}
+		}
+
+		ret = append(ret, peer)
+	}
+
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].DownRate > ret[j].DownRate
+	})
+
+	return ret
+}
+
+// GetTrackers returns list of trackers with their last announce results
+func (t *Torrent) GetTrackers() []TrackerInfo {
+	ret := []TrackerInfo{}
+	if t.th == nil || t.th.Swigcptr() == 0 || t.Closer.IsSet() {
+		return ret
+	}
+
+	trackers := t.th.Trackers()
+	defer lt.DeleteStdVectorAnnounceEntry(trackers)
+
+	for i := 0; i < int(trackers.Size()); i++ {
+		ae := trackers.Get(i)
+
+		tracker := TrackerInfo{
+			URL:          ae.GetUrl(),
+			Tier:         int(ae.GetTier()),
+			Working:      ae.IsWorking(),
+			Updating:     ae.GetUpdating(),
+			Fails:        int(ae.GetFails()),
+			Seeds:        ae.GetScrapeComplete(),
+			Peers:        ae.GetScrapeIncomplete(),
+			NextAnnounce: ae.NextAnnounceIn(),
+			MinAnnounce:  ae.MinAnnounceIn(),
+			Message:      ae.GetMessage(),
+		}
+		if err := ae.GetLastError(); err.Value() != 0 {
+			tracker.LastError = err.Message()
+		}
+
+		ret = append(ret, tracker)
+	}
+
+	return ret
+}
+
+// GetPiecesMap returns have-map, peers availability, and pieces,
+// that are reserved, demanded or have deadlines set for streaming.
+func (t *Torrent) GetPiecesMap() *PiecesMap {
+	ret := &PiecesMap{
+		Count:        t.pieceCount,
+		Length:       t.pieceLength,
+		Availability: []PiecesRun{},
+		Reserved:     []PiecesRun{},
+		Deadline:     []PiecesRun{},
+		Demanded:     []PiecesRun{},
+		Readers:      []PiecesRun{},
+	}
+	if t.th == nil || t.th.Swigcptr() == 0 || t.Closer.IsSet() {
+		return ret
+	}
+
+	if err := t.updatePieces(); err == nil {
+		t.piecesMx.RLock()
+		// Copy is needed, since bitfield is backed by libtorrent's memory
+		have := make(Bitfield, len(t.pieces))
+		copy(have, t.pieces)
+		t.piecesMx.RUnlock()
+
+		for i := 0; i < t.pieceCount; i++ {
+			if have.GetBit(i) {
+				ret.Completed++
+			}
+		}
+		ret.Have = base64.StdEncoding.EncodeToString(have)
+	}
+
+	availability := lt.NewStdVectorInt()
+	defer lt.DeleteStdVectorInt(availability)
+
+	t.th.PieceAvailability(availability)
+	values := make([]int, availability.Size())
+	for i := range values {
+		values[i] = availability.Get(i)
+	}
+	ret.Availability = valuesToRuns(values)
+
+	ret.Reserved = piecesToRuns(append([]int{}, t.reservedPieces...))
+	ret.Deadline = piecesToRuns(bitmapToPieces(t.awaitingPieces.ToArray()))
+	ret.Demanded = piecesToRuns(bitmapToPieces(t.demandPieces.ToArray()))
+
+	t.muReaders.Lock()
+	for _, r := range t.readers {
+		pr := r.ReaderPiecesRange()
+		ret.Readers = append(ret.Readers, PiecesRun{Begin: pr.Begin, End: pr.End})
+	}
+	t.muReaders.Unlock()
+
+	sort.Slice(ret.Readers, func(i, j int) bool {
+		return ret.Readers[i].Begin < ret.Readers[j].Begin
+	})
+
+	return ret
+}
+
+func bitmapToPieces(values []uint32) []int {
+	ret := make([]int, len(values))
+	for i, v := range values {
+		ret[i] = int(v)
+	}
+	return ret
+}
+
+// piecesToRuns collapses list of piece indexes into continuous ranges
+func piecesToRuns(pieces []int) []PiecesRun {
+	ret := []PiecesRun{}
+	if len(pieces) == 0 {
+		return ret
+	}
+
+	sort.Ints(pieces)
+	run := PiecesRun{Begin: pieces[0], End: pieces[0]}
+	for _, p := range pieces[1:] {
+		if p <= run.End+1 {
+			if p > run.End {
+				run.End = p
+			}
+			continue
+		}
+
+		ret = append(ret, run)
+		run = PiecesRun{Begin: p, End: p}
+	}
+
+	return append(ret, run)
+}
+
+// valuesToRuns collapses per-piece values into ranges with the same value
+func valuesToRuns(values []int) []PiecesRun {
+	ret := []PiecesRun{}
+	for i, v := range values {
+		if len(ret) > 0 && ret[len(ret)-1].Value == v {
+			ret[len(ret)-1].End = i
+			continue
+		}
+
+		ret = append(ret, PiecesRun{Begin: i, End: i, Value: v})
+	}
+
+	return ret
+}
@@ -0,0 +1,83 @@
+package bittorrent
+
+import (
+	"github.com/mrjdainc/da-inc/metrics"
+)
+
+var (
+	alertsMetric = metrics.NewCounter("alerts_total", "Libtorrent alerts by type.", "type")
+
+	downloadRateMetric = metrics.NewGauge("session_download_rate_bytes", "Payload download rate of all torrents, in bytes per second.")
+	uploadRateMetric   = metrics.NewGauge("session_upload_rate_bytes", "Payload upload rate of all torrents, in bytes per second.")
+	torrentsMetric     = metrics.NewGauge("torrents", "Number of torrents by state.", "state")
+
+	memoryTotalMetric   = metrics.NewGauge("memory_total_bytes", "Total memory of the system.")
+	memoryFreeMetric    = metrics.NewGauge("memory_free_bytes", "Free memory of the system.")
+	memoryStorageMetric = metrics.NewGauge("memory_storage_bytes", "Memory reserved by memory storage of active torrents.")
+
+	bufferProgressMetric = metrics.NewGauge("player_buffer_progress", "Buffer progress of active players, in percents.", "infohash")
+	bufferingMetric      = metrics.NewGauge("player_buffering_seconds", "Time spent on buffering by active players.", "infohash")
+)
+
+func (s *Service) collectMetrics() {
+	if s.Closer.IsSet() {
+		return
+	}
+
+	torrentsMetric.Reset()
+	for _, state := range StatusStrings {
+		torrentsMetric.Set(0, state)
+	}
+
+	down, up := 0, 0
+	memoryStorage := int64(0)
+	for _, t := range s.q.All() {
+		if t == nil || t.th == nil || t.th.Swigcptr() == 0 || t.Closer.IsSet() {
+			continue
+		}
+
+		torrentDown, torrentUp := t.GetSpeeds()
+		down += torrentDown
+		up += torrentUp
+
+		torrentsMetric.Add(1, t.GetStateString())
+
+		if s.IsMemoryStorage() {
+			memoryStorage += t.MemorySize
+		}
+	}
+
+	downloadRateMetric.Set(float64(down))
+	uploadRateMetric.Set(float64(up))
+	memoryStorageMetric.Set(float64(memoryStorage))
+
+	total, free := s.GetMemoryStats()
+	memoryTotalMetric.Set(float64(total))
+	memoryFreeMetric.Set(float64(free))
+
+	bufferProgressMetric.Reset()
+	bufferingMetric.Reset()
+
+	s.mu.Lock()
+	players := make([]*Player, 0, len(s.Players))
+	for _, p := range s.Players {
+		if p != nil && p.t != nil {
+			players = append(players, p)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, p := range players {
+		infoHash := p.t.InfoHash()
+
+		progress := p.t.BufferProgress
+		if progress < 0 {
+			progress = 0
+		} else if !p.t.IsBuffering && p.t.IsBufferingFinished {
+			progress = 100
+		}
+
+		bufferProgressMetric.Set(progress, infoHash)
+		bufferingMetric.Set(p.t.GetBufferingDuration().Seconds(), infoHash)
+	}
+}
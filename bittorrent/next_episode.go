@@ -0,0 +1,144 @@
+package bittorrent
+
+import (
+	"time"
+
+	lt "github.com/da-inc/libtorrent-go"
+
+	"github.com/mrjdainc/da-inc/config"
+	"github.com/mrjdainc/da-inc/database"
+	"github.com/mrjdainc/da-inc/tmdb"
+)
+
+// nextEpisodeMetadataTimeout limits waiting for metadata of the next episode torrent
+const nextEpisodeMetadataTimeout = 2 * time.Minute
+
+// EpisodeSearchFunc searches providers for an episode and returns the link,
+// that would be chosen automatically, or nil if nothing was found
+type EpisodeSearchFunc func(showID, season, episode int) *TorrentFile
+
+// SetEpisodeSearch sets the function, used for searching next episode in other torrents
+func (s *Service) SetEpisodeSearch(fn EpisodeSearchFunc) {
+	s.episodeSearch = fn
+}
+
+func (btp *Player) isReadyForNextSearch() bool {
+	return btp.next.done && btp.next.f == nil && !btp.next.searched && btp.p.ContentType == episodeType && btp.p.WatchedProgress > config.Get().PlaybackPercent
+}
+
+// searchNextEpisode looks for the next episode when current torrent does not have it,
+// adds the top result and starts buffering, so it is ready for "next up" playback
+func (btp *Player) searchNextEpisode() {
+	if btp.s.episodeSearch == nil || btp.p.ShowID == 0 || !config.Get().SmartEpisodeSearch {
+		return
+	}
+
+	episode := nextEpisode(btp.p.ShowID, btp.p.Season, btp.p.Episode)
+	if episode == nil {
+		log.Debugf("No next episode available after S%02dE%02d", btp.p.Season, btp.p.Episode)
+		return
+	} else if btp.s.HasTorrentByEpisode(btp.p.ShowID, episode.SeasonNumber, episode.EpisodeNumber) != nil {
+		log.Debugf("Next episode S%02dE%02d is already in active torrents", episode.SeasonNumber, episode.EpisodeNumber)
+		return
+	}
+
+	log.Infof("Searching next episode S%02dE%02d in providers", episode.SeasonNumber, episode.EpisodeNumber)
+	torrent := btp.s.episodeSearch(btp.p.ShowID, episode.SeasonNumber, episode.EpisodeNumber)
+	if torrent == nil || btp.closer.IsSet() {
+		return
+	} else if torrent.InfoHash != "" && btp.s.GetTorrentByHash(torrent.InfoHash) != nil {
+		log.Debugf("Next episode torrent %s is already added", torrent.InfoHash)
+		return
+	}
+
+	t, err := btp.s.AddTorrent(torrent.URI, false)
+	if err != nil {
+		log.Warningf("Unable to add next episode torrent: %s", err)
+		return
+	}
+
+	select {
+	case <-t.GotInfo():
+	case <-t.Closer.C():
+		return
+	case <-time.After(nextEpisodeMetadataTimeout):
+		log.Warningf("Expired timeout for next episode torrent metadata: %s", t.InfoHash())
+		btp.s.RemoveTorrent(t, false, false, false)
+		return
+	}
+
+	f := t.GetNextEpisodeFile(episode.SeasonNumber, episode.EpisodeNumber)
+	if f == nil {
+		f = t.biggestVideoFile()
+	}
+	if f == nil {
+		log.Warningf("No video file found in next episode torrent %s", t.Name())
+		btp.s.RemoveTorrent(t, false, false, false)
+		return
+	}
+
+	database.GetStorm().UpdateBTItem(t.InfoHash(), episode.ID, episodeType, []string{f.Path}, "", btp.p.ShowID, episode.SeasonNumber, episode.EpisodeNumber)
+	t.DBItem = database.GetStorm().GetBTItem(t.InfoHash())
+
+	t.startNextTimer()
+	t.downloadOnly(f)
+	btp.next.t = t
+
+	log.Infof("Next episode prepared in torrent %s: %#v", t.Name(), f.Path)
+	t.Buffer(f, true)
+}
+
+// nextEpisode returns the episode after given one, which is already aired
+func nextEpisode(showID, season, episode int) *tmdb.Episode {
+	show := tmdb.GetShow(showID, config.Get().Language)
+	if show == nil {
+		return nil
+	}
+
+	var next *tmdb.Episode
+	if s := tmdb.GetSeason(showID, season, config.Get().Language, len(show.Seasons)); s != nil && episode < len(s.Episodes) {
+		next = s.Episodes[episode]
+	} else if s := tmdb.GetSeason(showID, season+1, config.Get().Language, len(show.Seasons)); s != nil && len(s.Episodes) > 0 {
+		next = s.Episodes[0]
+	}
+
+	if next == nil || next.AirDate == "" {
+		return nil
+	} else if aired, err := time.Parse("2006-01-02", next.AirDate); err != nil || aired.After(time.Now()) {
+		return nil
+	}
+
+	return next
+}
+
+// downloadOnly disables all files except the chosen one
+func (t *Torrent) downloadOnly(file *File) {
+	t.DownloadFile(file)
+	if t.Service.IsMemoryStorage() {
+		return
+	}
+
+	filesPriorities := lt.NewStdVectorInt()
+	defer lt.DeleteStdVectorInt(filesPriorities)
+
+	for _, f := range t.files {
+		if f == file {
+			filesPriorities.Add(4)
+		} else {
+			filesPriorities.Add(0)
+		}
+	}
+	t.th.PrioritizeFiles(filesPriorities)
+}
+
+func (t *Torrent) biggestVideoFile() (ret *File) {
+	for _, f := range t.files {
+		if !videoFileRegex.MatchString(f.Name) {
+			continue
+		}
+		if ret == nil || f.Size > ret.Size {
+			ret = f
+		}
+	}
+	return
+}
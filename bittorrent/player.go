@@ -25,8 +25,11 @@ import (
 	"github.com/mrjdainc/da-inc/config"
 	"github.com/mrjdainc/da-inc/database"
 	"github.com/mrjdainc/da-inc/diskusage"
+	"github.com/mrjdainc/da-inc/events"
+	"github.com/mrjdainc/da-inc/hooks"
 	"github.com/mrjdainc/da-inc/library"
 	"github.com/mrjdainc/da-inc/osdb"
+	"github.com/mrjdainc/da-inc/release"
 	"github.com/mrjdainc/da-inc/tmdb"
 	"github.com/mrjdainc/da-inc/trakt"
 	"github.com/mrjdainc/da-inc/tvdb"
@@ -63,6 +66,10 @@ type Player struct {
 	bufferPiecesProgress     map[int]float64
 	bufferPiecesProgressLock sync.RWMutex
 
+	stalledSince    time.Time
+	fallbackStarted bool
+	alternatives    chan *TorrentFile
+
 	diskStatus *diskusage.DiskStatus
 	closer     util.Event
 	closed     bool
@@ -99,6 +106,7 @@ type PlayerParams struct {
 	Episode           int
 	AbsoluteNumber    int
 	Query             string
+	StartPosition     float64
 	UIDs              *library.UniqueIDs
 	Resume            *library.Resume
 	StoredResume      *library.Resume
@@ -107,9 +115,12 @@ type PlayerParams struct {
 // NextEpisode ...
 type NextEpisode struct {
 	f *File
+	// t is a torrent with next episode, found by provider search
+	t *Torrent
 
 	started    bool
 	done       bool
+	searched   bool
 	bufferSize int64
 }
 
@@ -138,6 +149,7 @@ func NewPlayer(bts *Service, params PlayerParams) *Player {
 		isDownloading:        false,
 		notEnoughSpace:       false,
 		bufferEvents:         broadcast.NewBroadcaster(),
+		alternatives:         make(chan *TorrentFile, 1),
 		subtitlesLoaded:      []string{},
 	}
 	return btp
@@ -294,6 +306,22 @@ func (btp *Player) processMetadata() {
 		return
 	}
 
+	var archive *ArchiveEntry
+	if btp.t.IsRarArchive || IsArchiveVolume(btp.chosenFile) {
+		if archive, err = btp.t.OpenArchive(btp.chosenFile); err == nil {
+			btp.chosenFile = archive.File
+			btp.t.IsRarArchive = false
+		} else {
+			log.Warningf("Unable to stream from archive: %s", err)
+
+			if btp.t.IsRarArchive && !xbmc.DialogConfirm("dainc", "LOCALIZE[30303]") {
+				btp.notEnoughSpace = true
+				btp.bufferEvents.Broadcast(errors.New("RAR archive detected and download was cancelled"))
+				return
+			}
+		}
+	}
+
 	btp.p.ResumeToken = strconv.FormatUint(xxhash.Sum64String(btp.t.InfoHash()+btp.chosenFile.Path), 10)
 	btp.hasChosenFile = true
 	btp.fileSize = btp.chosenFile.Size
@@ -313,7 +341,13 @@ func (btp *Player) processMetadata() {
 	}
 
 	files := []string{}
-	if btp.chosenFile != nil {
+	if archive != nil {
+		// Chosen file is virtual, so volumes are saved to be restored and moved
+		for _, v := range archive.Volumes {
+			btp.t.DownloadFile(v)
+			files = append(files, v.Path)
+		}
+	} else if btp.chosenFile != nil {
 		btp.t.DownloadFile(btp.chosenFile)
 		files = append(files, btp.chosenFile.Path)
 	}
@@ -344,7 +378,7 @@ func (btp *Player) processMetadata() {
 	for _, f := range btp.t.files {
 		if btp.s.IsMemoryStorage() {
 			filesPriorities.Add(0)
-		} else if f == btp.chosenFile {
+		} else if f == btp.chosenFile || (archive != nil && archive.HasVolume(f)) {
 			filesPriorities.Add(4)
 		} else if f == btp.subtitlesFile {
 			filesPriorities.Add(4)
@@ -465,6 +499,11 @@ func (btp *Player) Close() {
 		go btp.s.PlayerStop()
 	}()
 
+	if btp.next.t != nil && btp.next.t.IsNextFile {
+		log.Infof("Leaving torrent '%s' awaiting for next episode playback", btp.next.t.Name())
+		btp.next.t.startNextTimer()
+	}
+
 	if btp.t.HasNextFile {
 		log.Infof("Leaving torrent '%s' awaiting for next file playback", btp.t.Name())
 		btp.t.startNextTimer()
@@ -503,7 +542,16 @@ func (btp *Player) bufferDialog() {
 				btp.bufferEvents.Broadcast(errors.New(errMsg))
 				return
 			}
+		case torrent := <-btp.alternatives:
+			btp.bufferEvents.Broadcast(errStalled)
+			go btp.switchTo(torrent)
+			return
 		case <-oneSecond.C:
+			if btp.isStalled() {
+				btp.fallbackStarted = true
+				go btp.bufferFallback()
+			}
+
 			if finished, err := btp.updateBufferDialog(); finished {
 				return
 			} else if err != nil {
@@ -600,6 +648,7 @@ func (btp *Player) updateBufferDialog() (bool, error) {
 		if btp.dialogProgress != nil {
 			btp.dialogProgress.Update(int(btp.t.BufferProgress), line1, line2, line3)
 		}
+		btp.publishEvent(events.PlayerBuffering)
 		if !btp.t.IsBuffering && btp.t.HasMetadata() && btp.t.GetState() != StatusChecking {
 			btp.bufferEvents.Signal()
 			btp.setRateLimiting(true)
@@ -641,6 +690,13 @@ func (btp *Player) updateWatchTimes() {
 	}
 	btp.p.WatchedTime, _ = strconv.ParseFloat(ret["watchedTime"], 64)
 	btp.p.VideoDuration, _ = strconv.ParseFloat(ret["videoDuration"], 64)
+
+	// Kodi does not know duration while the stream is opening
+	if btp.p.VideoDuration == 0 {
+		if info := btp.t.GetMediaInfo(); info != nil {
+			btp.p.VideoDuration = info.Duration
+		}
+	}
 }
 
 func (btp *Player) playerLoop() {
@@ -691,6 +747,8 @@ playbackWaitLoop:
 	}
 
 	btp.t.IsPlaying = true
+	btp.publishEvent(events.PlayerStarted)
+	btp.triggerHook(hooks.PlaybackStarted)
 
 playbackLoop:
 	for {
@@ -704,6 +762,7 @@ playbackLoop:
 
 			if btp.p.Seeked {
 				btp.p.Seeked = false
+				btp.publishEvent(events.PlayerSeeked)
 				if btp.scrobble {
 					trakt.Scrobble("start", btp.p.ContentType, btp.p.TMDBId, btp.p.WatchedTime, btp.p.VideoDuration)
 				}
@@ -743,11 +802,21 @@ playbackLoop:
 
 			if btp.next.f != nil && !btp.next.started && btp.isReadyForNextFile() {
 				btp.startNextFile()
+			} else if btp.isReadyForNextSearch() {
+				btp.next.searched = true
+				go btp.searchNextEpisode()
+			}
+
+			if btp.isStalled() {
+				btp.fallbackStarted = true
+				go btp.fallback()
 			}
 		}
 	}
 
 	log.Info("Stopped playback")
+	btp.publishEvent(events.PlayerStopped)
+	btp.triggerHook(hooks.PlaybackStopped)
 	btp.SaveStoredResume()
 	btp.setRateLimiting(false)
 	go func() {
@@ -802,6 +871,8 @@ func (btp *Player) UpdateWatched() {
 	SetWatchedFile(btp.chosenFile.Path, btp.chosenFile.Size, progress > float64(config.Get().PlaybackPercent))
 
 	if progress > float64(config.Get().PlaybackPercent) {
+		btp.triggerHook(hooks.PlaybackWatched)
+
 		var watched *trakt.WatchedItem
 
 		// TODO: Make use of Playcount, possibly increment when Watched, use old value if in progress
@@ -1150,19 +1221,41 @@ func (btp *Player) SetSubtitles() {
 	}
 }
 
+// resumeKey returns the ID of stored resume item, movies and episodes are
+// identified by TMDB, other items fall back to torrent and file
+func (btp *Player) resumeKey() string {
+	if btp.p.ContentType == movieType && btp.p.TMDBId != 0 {
+		return database.ResumeMovieID(btp.p.TMDBId)
+	} else if btp.p.ContentType == episodeType && btp.p.ShowID != 0 {
+		return database.ResumeEpisodeID(btp.p.ShowID, btp.p.Season, btp.p.Episode)
+	}
+
+	return "torrent." + btp.p.ResumeToken
+}
+
 // FetchStoredResume ...
 func (btp *Player) FetchStoredResume() {
-	key := "stored.resume." + btp.p.ResumeToken
 	if btp.p.StoredResume == nil {
 		btp.p.StoredResume = &library.Resume{}
 	}
 
-	database.GetCache().GetCachedObject(database.CommonBucket, key, btp.p.StoredResume)
+	if item := database.GetStorm().GetResumeItem(btp.resumeKey()); item != nil {
+		btp.p.StoredResume.Position = item.Position
+		btp.p.StoredResume.Total = item.Total
+		btp.p.StoredResume.LastPlayed = item.UpdatedAt
+	} else {
+		database.GetCache().GetCachedObject(database.CommonBucket, "stored.resume."+btp.p.ResumeToken, btp.p.StoredResume)
+	}
+
+	// Kodi library position wins if it was played there later
+	if btp.p.Resume != nil && btp.p.Resume.Position > 0 && btp.p.Resume.LastPlayed.After(btp.p.StoredResume.LastPlayed) {
+		*btp.p.StoredResume = *btp.p.Resume
+	}
 }
 
 // SaveStoredResume ...
 func (btp *Player) SaveStoredResume() {
-	key := "stored.resume." + btp.p.ResumeToken
+	key := btp.resumeKey()
 
 	if btp.p.StoredResume == nil {
 		btp.p.StoredResume = &library.Resume{}
@@ -1170,13 +1263,36 @@ func (btp *Player) SaveStoredResume() {
 
 	btp.p.StoredResume.Total = btp.p.VideoDuration
 	btp.p.StoredResume.Position = btp.p.WatchedTime
+	btp.p.StoredResume.LastPlayed = time.Now()
 
 	if btp.p.StoredResume.Total == 0 || btp.p.StoredResume.Position == 0 {
 		return
 	} else if btp.IsWatched() || btp.p.StoredResume.Position < 180 {
-		database.GetCache().Delete(database.CommonBucket, key)
+		database.GetStorm().DeleteResumeItem(key)
+		database.GetCache().Delete(database.CommonBucket, "stored.resume."+btp.p.ResumeToken)
 	} else {
-		database.GetCache().SetCachedObject(database.CommonBucket, storedResumeExpiration, key, btp.p.StoredResume)
+		item := &database.ResumeItem{
+			ID:        key,
+			Type:      btp.p.ContentType,
+			TMDBId:    btp.p.TMDBId,
+			ShowID:    btp.p.ShowID,
+			Season:    btp.p.Season,
+			Episode:   btp.p.Episode,
+			Query:     btp.p.Query,
+			Position:  btp.p.StoredResume.Position,
+			Total:     btp.p.StoredResume.Total,
+			UpdatedAt: btp.p.StoredResume.LastPlayed,
+		}
+		if btp.t != nil {
+			item.InfoHash = btp.t.InfoHash()
+		}
+		if btp.chosenFile != nil {
+			item.File = btp.chosenFile.Path
+		}
+
+		if err := database.GetStorm().SaveResumeItem(item); err != nil {
+			log.Warningf("Could not save resume position: %s", err)
+		}
 	}
 }
 
@@ -1252,6 +1368,16 @@ func MatchEpisodeFilename(s, e int, isSingleSeason bool, show *tmdb.Show, episod
 		}
 	}
 
+	// Multi-episode files, like "S01E01-E03", are not matched by the pattern
+	if found == 0 {
+		for i, choice := range choices {
+			if release.Parse(filepath.Base(choice.Path)).HasEpisode(s, e) {
+				index = i
+				found++
+			}
+		}
+	}
+
 	if show != nil && episode != nil && show.IsAnime() {
 		if an, _ := show.AnimeInfoWithShow(episode, tvdbShow); an != 0 {
 			re := regexp.MustCompile(fmt.Sprintf(singleEpisodeMatchRegex, an))
@@ -0,0 +1,78 @@
+package bittorrent
+
+import (
+	"errors"
+	"time"
+
+	lt "github.com/da-inc/libtorrent-go"
+
+	"github.com/mrjdainc/da-inc/config"
+)
+
+// FetchMetadata gets metadata of a magnet over DHT, using a throwaway handle in the session,
+// no files are downloaded. Metadata is returned as bencoded torrent file.
+func (s *Service) FetchMetadata(uri string, timeout time.Duration) ([]byte, error) {
+	torrent := NewTorrentFile(uri)
+	if !torrent.IsMagnet() || torrent.InfoHash == "" {
+		return nil, errors.New("Not a magnet link")
+	}
+
+	// Torrent is already in the session, so it should not be removed afterwards
+	if t := s.GetTorrentByHash(torrent.InfoHash); t != nil {
+		if !t.gotMetainfo.IsSet() {
+			return nil, errors.New("Torrent is already added, but has no metadata yet")
+		}
+		return t.GetMetadata(), nil
+	}
+
+	torrentParams := lt.NewAddTorrentParams()
+	defer lt.DeleteAddTorrentParams(torrentParams)
+
+	torrentParams.SetUrl(torrent.URI)
+	torrentParams.SetSavePath(config.Get().Info.TempPath)
+
+	filesPriorities := lt.NewStdVectorInt()
+	defer lt.DeleteStdVectorInt(filesPriorities)
+	for i := 0; i <= 500; i++ {
+		filesPriorities.Add(0)
+	}
+	torrentParams.SetFilePriorities(filesPriorities)
+
+	th, err := s.Session.AddTorrent(torrentParams)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := s.Session.RemoveTorrent(th, 0); err != nil {
+			log.Warningf("Could not remove prefetched torrent %s: %s", torrent.InfoHash, err)
+		}
+	}()
+	th.Resume()
+
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+	expired := time.After(timeout)
+
+	for {
+		select {
+		case <-expired:
+			return nil, errors.New("Expired timeout for fetching magnet metadata")
+
+		case <-s.Closer.C():
+			return nil, errors.New("Service is closing")
+
+		case <-ticker.C:
+			ts := th.Status(uint(lt.WrappedTorrentHandleQueryName))
+			hasMetadata := ts.GetHasMetadata()
+			lt.DeleteTorrentStatus(ts)
+			if !hasMetadata {
+				continue
+			}
+
+			torrentFile := lt.NewCreateTorrent(th.TorrentFile())
+			defer lt.DeleteCreateTorrent(torrentFile)
+
+			return []byte(lt.Bencode(torrentFile.Generate())), nil
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package bittorrent
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/dustin/go-humanize"
+
+	"github.com/mrjdainc/da-inc/config"
+	"github.com/mrjdainc/da-inc/probe"
+	"github.com/mrjdainc/da-inc/xbmc"
+)
+
+// probedMedia keeps probe results by info hash, for torrents in the session
+var probedMedia sync.Map
+
+// GetMediaInfo returns container information of the buffered file, if it was probed
+func (t *Torrent) GetMediaInfo() *probe.Info {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.mediaInfo
+}
+
+// probeFile parses container headers from the first pieces of the file,
+// then extends the buffer with the tail MP4 index and with enough data for BufferDuration.
+func (t *Torrent) probeFile(file *File) {
+	if !config.Get().ProbeMedia || file == nil || t.Closer.IsSet() {
+		return
+	}
+
+	r := t.newFileReader(file)
+	info, err := probe.Probe(r, file.Size)
+	if err != nil {
+		log.Infof("Unable to probe %s: %s", file.Path, err)
+		return
+	}
+
+	if info.MoovAtEnd {
+		log.Infof("MP4 index of %s is at the end, adding %s to buffer", file.Path, humanize.Bytes(uint64(info.MoovSize)))
+		t.extendBuffer(file, info.MoovOffset, info.MoovSize)
+
+		if err := probe.ProbeMoov(r, info); err != nil {
+			log.Infof("Unable to probe MP4 index of %s: %s", file.Path, err)
+			return
+		}
+	}
+
+	log.Infof("Probed %s: %s %dx%d %s, %.0fs, %s/s, audio: %s, subtitles: %s",
+		file.Path, info.Container, info.Width, info.Height, info.VideoCodec, info.Duration,
+		humanize.Bytes(uint64(info.Bitrate/8)), strings.Join(info.AudioLanguages(), ","), strings.Join(info.SubtitleLanguages(), ","))
+
+	t.mu.Lock()
+	t.mediaInfo = info
+	t.mu.Unlock()
+	probedMedia.Store(t.InfoHash(), info)
+
+	if duration := config.Get().BufferDuration; duration > 0 && info.Bitrate > 0 {
+		t.extendBuffer(file, 0, info.Bitrate/8*int64(duration))
+	}
+}
+
+// extendBuffer adds pieces of the file region to the buffer, while buffering is in progress
+func (t *Torrent) extendBuffer(file *File, off, size int64) {
+	if off+size > file.Size {
+		size = file.Size - off
+	}
+	if size <= 0 {
+		return
+	}
+
+	ranges := []PieceRange{}
+	if a := t.GetArchive(file); a != nil {
+		for size > 0 {
+			n := a.ContiguousLeft(off)
+			if n <= 0 {
+				break
+			} else if n > size {
+				n = size
+			}
+
+			begin, end := t.byteRegionPieces(a.TorrentOffset(off), n)
+			ranges = append(ranges, PieceRange{Begin: begin, End: end})
+			off += n
+			size -= n
+		}
+	} else {
+		begin, end := t.byteRegionPieces(file.Offset+off, size)
+		ranges = append(ranges, PieceRange{Begin: begin, End: end})
+	}
+
+	t.muBuffer.Lock()
+	defer t.muBuffer.Unlock()
+
+	if !t.IsBuffering || t.Closer.IsSet() {
+		return
+	}
+
+	added := 0
+	for _, pr := range ranges {
+		for piece := pr.Begin; piece <= pr.End; piece++ {
+			if _, ok := t.BufferPiecesProgress[piece]; ok {
+				continue
+			}
+
+			t.BufferPiecesProgress[piece] = 0
+			t.demandPieces.AddInt(piece)
+			t.th.PiecePriority(piece, 7)
+			t.th.SetPieceDeadline(piece, 0, 0)
+			added++
+		}
+	}
+	if added == 0 {
+		return
+	}
+
+	t.BufferLength += int64(added) * t.pieceLength
+	t.BufferPiecesLength += int64(added) * t.pieceLength
+	log.Infof("Extended buffer with %d pieces, now it is %s", added, humanize.Bytes(uint64(t.BufferPiecesLength)))
+
+	if t.Service.IsMemoryStorage() && t.ms != nil && t.BufferPiecesLength > t.MemorySize {
+		t.MemorySize = t.BufferPiecesLength + t.pieceLength
+		log.Infof("Adjusting memory size to %s, to fit all buffer!", humanize.Bytes(uint64(t.MemorySize)))
+		t.ms.SetMemorySize(t.MemorySize)
+	}
+}
+
+// MediaStreamInfo converts probed container information to Kodi stream details
+func MediaStreamInfo(info *probe.Info) *xbmc.StreamInfo {
+	if info == nil {
+		return nil
+	}
+
+	sie := &xbmc.StreamInfo{
+		Video: &xbmc.StreamInfoEntry{
+			Codec:    info.VideoCodec,
+			Width:    info.Width,
+			Height:   info.Height,
+			Duration: int(info.Duration),
+		},
+	}
+	if info.Height > 0 {
+		sie.Video.Aspect = float32(info.Width) / float32(info.Height)
+	}
+	if a := info.DefaultAudio(); a != nil {
+		sie.Audio = &xbmc.StreamInfoEntry{
+			Codec:    a.Codec,
+			Language: a.Language,
+			Channels: a.Channels,
+		}
+	}
+	if len(info.Subtitles) > 0 {
+		sie.Subtitle = &xbmc.StreamInfoEntry{
+			Language: info.Subtitles[0].Language,
+		}
+	}
+
+	return sie
+}
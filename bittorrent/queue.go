@@ -1,16 +1,51 @@
 package bittorrent
 
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mrjdainc/da-inc/database"
+)
+
+// Priority classes of torrents in the queue, higher class gets active slots first
+const (
+	// PriorityDefault means torrent has no explicit class and is treated as manual
+	PriorityDefault = iota
+	// PriorityAutoScrape ...
+	PriorityAutoScrape
+	// PriorityManual ...
+	PriorityManual
+	// PriorityStreaming ...
+	PriorityStreaming
+)
+
+// PriorityStrings ...
+var PriorityStrings = map[int]string{
+	PriorityDefault:    "manual",
+	PriorityAutoScrape: "autoscrape",
+	PriorityManual:     "manual",
+	PriorityStreaming:  "streaming",
+}
+
+const queueProcessInterval = 5 * time.Second
+
 // Queue represents list of torrents inside of a session
 type Queue struct {
 	s        *Service
 	torrents []*Torrent
+
+	mu      sync.RWMutex
+	trigger chan struct{}
 }
 
 // NewQueue contructor for empty Queue
 func NewQueue(s *Service) *Queue {
 	return &Queue{
-		s,
-		[]*Torrent{},
+		s:        s,
+		torrents: []*Torrent{},
+		trigger:  make(chan struct{}, 1),
 	}
 }
 
@@ -20,20 +55,21 @@ func (q *Queue) Add(t *Torrent) bool {
 		return false
 	}
 
+	q.mu.Lock()
 	q.torrents = append(q.torrents, t)
+	q.mu.Unlock()
+
+	q.Notify()
 	return true
 }
 
 // Delete removes torrent from the queue
 func (q *Queue) Delete(t *Torrent) bool {
-	idx := -1
-	for i, ti := range q.torrents {
-		if ti.InfoHash() == t.InfoHash() {
-			idx = i
-			break
-		}
-	}
+	q.mu.Lock()
+	defer q.Notify()
+	defer q.mu.Unlock()
 
+	idx := q.indexOf(t)
 	if idx < 0 {
 		return false
 	}
@@ -44,11 +80,19 @@ func (q *Queue) Delete(t *Torrent) bool {
 
 // All returns all queue
 func (q *Queue) All() []*Torrent {
-	return q.torrents
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	ret := make([]*Torrent, len(q.torrents))
+	copy(ret, q.torrents)
+	return ret
 }
 
 // FindByHash checks if torrent with infohash is in the queue
 func (q *Queue) FindByHash(hash string) *Torrent {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
 	for _, t := range q.torrents {
 		if t.InfoHash() == hash {
 			return t
@@ -61,5 +105,190 @@ func (q *Queue) FindByHash(hash string) *Torrent {
 // Clean would cleanup torrents list,
 // should be used in case of a service reload
 func (q *Queue) Clean() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
 	q.torrents = []*Torrent{}
 }
+
+// Position returns 1-based position of a torrent in the queue, or 0 if it is not queued
+func (q *Queue) Position(t *Torrent) int {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	return q.indexOf(t) + 1
+}
+
+// Move changes position of a torrent by delta, negative delta moves torrent up
+func (q *Queue) Move(t *Torrent, delta int) bool {
+	q.mu.Lock()
+
+	idx := q.indexOf(t)
+	if idx < 0 {
+		q.mu.Unlock()
+		return false
+	}
+
+	to := idx + delta
+	if to < 0 {
+		to = 0
+	} else if to >= len(q.torrents) {
+		to = len(q.torrents) - 1
+	}
+	if to == idx {
+		q.mu.Unlock()
+		return false
+	}
+
+	q.torrents = append(q.torrents[:idx], q.torrents[idx+1:]...)
+	q.torrents = append(q.torrents[:to], append([]*Torrent{t}, q.torrents[to:]...)...)
+	q.mu.Unlock()
+
+	q.savePositions()
+	q.Notify()
+	return true
+}
+
+// MoveUp ...
+func (q *Queue) MoveUp(t *Torrent) bool {
+	return q.Move(t, -1)
+}
+
+// MoveDown ...
+func (q *Queue) MoveDown(t *Torrent) bool {
+	return q.Move(t, 1)
+}
+
+// MoveTop ...
+func (q *Queue) MoveTop(t *Torrent) bool {
+	return q.Move(t, math.MinInt32)
+}
+
+// MoveBottom ...
+func (q *Queue) MoveBottom(t *Torrent) bool {
+	return q.Move(t, math.MaxInt32)
+}
+
+// Restore orders torrents according to positions saved in the database,
+// torrents without saved position are kept at the end
+func (q *Queue) Restore() {
+	q.mu.Lock()
+	positions := map[string]int{}
+	for _, t := range q.torrents {
+		positions[t.InfoHash()] = math.MaxInt32
+		if item := database.GetStorm().GetTorrentQueueItem(t.InfoHash()); item != nil && item.Position > 0 {
+			positions[t.InfoHash()] = item.Position
+		}
+	}
+	sort.SliceStable(q.torrents, func(i, j int) bool {
+		return positions[q.torrents[i].InfoHash()] < positions[q.torrents[j].InfoHash()]
+	})
+	q.mu.Unlock()
+
+	q.savePositions()
+	q.Notify()
+}
+
+// Notify asks queue to re-check active slots
+func (q *Queue) Notify() {
+	select {
+	case q.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// Watch processes the queue periodically and on notifications
+func (q *Queue) Watch() {
+	closing := q.s.Closer.C()
+	ticker := time.NewTicker(queueProcessInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-closing:
+			return
+		case <-ticker.C:
+		case <-q.trigger:
+		}
+
+		q.Process()
+	}
+}
+
+// Process pauses torrents, that exceed active downloads and seeds limits,
+// and starts queued ones when there are free slots.
+// Torrents are checked by priority class first and by queue position after that.
+func (q *Queue) Process() {
+	if q.s.Closer.IsSet() || q.s.IsMemoryStorage() || q.s.Session == nil || q.s.Session.Swigcptr() == 0 || q.s.Session.IsPaused() {
+		return
+	}
+
+	maxDownloads := q.s.config.MaxActiveDownloads
+	maxSeeds := q.s.config.MaxActiveSeeds
+
+	downloads := 0
+	seeds := 0
+	for _, t := range q.Ordered() {
+		if t.Closer.IsSet() || t.th == nil || t.th.Swigcptr() == 0 || !t.HasMetadata() {
+			continue
+		}
+
+		// Paused by user or by seeding limits, so not our business
+		if t.GetPaused() && !t.IsQueued {
+			continue
+		}
+
+		if t.GetPriority() == PriorityStreaming {
+			downloads++
+			if t.IsQueued {
+				t.unqueue()
+			}
+			continue
+		}
+
+		limit, counter := maxDownloads, &downloads
+		if t.GetRealProgress() >= 100 {
+			limit, counter = maxSeeds, &seeds
+		}
+
+		if limit <= 0 || *counter < limit {
+			*counter++
+			if t.IsQueued {
+				t.unqueue()
+			}
+		} else if !t.IsQueued {
+			t.queue()
+		}
+	}
+}
+
+// Ordered returns torrents sorted by priority class and queue position
+func (q *Queue) Ordered() []*Torrent {
+	ret := q.All()
+	sort.SliceStable(ret, func(i, j int) bool {
+		return ret[i].GetPriority() > ret[j].GetPriority()
+	})
+	return ret
+}
+
+func (q *Queue) indexOf(t *Torrent) int {
+	for i, ti := range q.torrents {
+		if ti.InfoHash() == t.InfoHash() {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func (q *Queue) savePositions() {
+	for i, t := range q.All() {
+		if item := database.GetStorm().GetTorrentQueueItem(t.InfoHash()); item != nil && item.Position == i+1 && item.Priority == t.Priority {
+			continue
+		}
+
+		if err := database.GetStorm().SaveTorrentQueueItem(t.InfoHash(), i+1, t.Priority); err != nil {
+			log.Warningf("Could not save queue position of %s: %s", t.Name(), err)
+		}
+	}
+}
@@ -0,0 +1,334 @@
+package bittorrent
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+)
+
+// Names of limit profiles, that could be used in schedule rules
+const (
+	ProfileNormal      = "normal"
+	ProfileAlternative = "alt"
+	ProfileCustom      = "custom"
+)
+
+const schedulerInterval = 30 * time.Second
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// LimitsProfile represents download and upload rate limits in bytes per second, 0 means unlimited
+type LimitsProfile struct {
+	Name     string `json:"name"`
+	Download int    `json:"download"`
+	Upload   int    `json:"upload"`
+}
+
+// ScheduleRule switches limits profile for a time range on selected weekdays
+type ScheduleRule struct {
+	Days    [7]bool
+	Start   int
+	End     int
+	Profile *LimitsProfile
+}
+
+// ParseScheduleRules parses list of rules, separated with new lines or semicolons.
+// Each rule looks like "<days> <from>-<to> <profile>", for example "mon-fri 09:00-18:00 alt",
+// "sat,sun 00:00-08:00 normal" or "* 23:00-07:00 0/50",
+// where profile is "normal", "alt" or download/upload limits in KB/s.
+// Time ranges can wrap around midnight.
+func ParseScheduleRules(rules string, normal, alternative *LimitsProfile) ([]*ScheduleRule, error) {
+	ret := []*ScheduleRule{}
+
+	for _, line := range strings.FieldsFunc(rules, func(r rune) bool {
+		return r == '\n' || r == ';'
+	}) {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		} else if len(fields) != 3 {
+			return nil, fmt.Errorf("Rule should have days, time range and profile: %s", line)
+		}
+
+		rule := &ScheduleRule{}
+
+		var err error
+		if rule.Days, err = parseScheduleDays(fields[0]); err != nil {
+			return nil, err
+		}
+		if rule.Start, rule.End, err = parseScheduleTime(fields[1]); err != nil {
+			return nil, err
+		}
+		if rule.Profile, err = parseScheduleProfile(fields[2], normal, alternative); err != nil {
+			return nil, err
+		}
+
+		ret = append(ret, rule)
+	}
+
+	return ret, nil
+}
+
+// Matches checks whether rule is active at specific time
+func (r *ScheduleRule) Matches(now time.Time) bool {
+	minute := now.Hour()*60 + now.Minute()
+	day := now.Weekday()
+
+	if r.Start <= r.End {
+		return r.Days[day] && minute >= r.Start && minute < r.End
+	}
+
+	// Range wraps around midnight, so the part after midnight belongs to previous day
+	if minute >= r.Start {
+		return r.Days[day]
+	}
+	return minute < r.End && r.Days[(day+6)%7]
+}
+
+func parseScheduleDays(days string) (ret [7]bool, err error) {
+	if days == "*" {
+		for i := range ret {
+			ret[i] = true
+		}
+		return
+	}
+
+	for _, part := range strings.Split(strings.ToLower(days), ",") {
+		tokens := strings.SplitN(part, "-", 2)
+		from, ok := weekdays[tokens[0]]
+		if !ok {
+			return ret, fmt.Errorf("Unknown weekday: %s", tokens[0])
+		}
+
+		to := from
+		if len(tokens) == 2 {
+			if to, ok = weekdays[tokens[1]]; !ok {
+				return ret, fmt.Errorf("Unknown weekday: %s", tokens[1])
+			}
+		}
+
+		for d := from; ; d = (d + 1) % 7 {
+			ret[d] = true
+			if d == to {
+				break
+			}
+		}
+	}
+
+	return
+}
+
+func parseScheduleTime(period string) (start, end int, err error) {
+	tokens := strings.SplitN(period, "-", 2)
+	if len(tokens) != 2 {
+		return 0, 0, fmt.Errorf("Time range should look like 09:00-18:00: %s", period)
+	}
+
+	if start, err = parseScheduleMinute(tokens[0]); err != nil {
+		return
+	}
+	end, err = parseScheduleMinute(tokens[1])
+	return
+}
+
+func parseScheduleMinute(value string) (int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		if value == "24:00" {
+			return 24 * 60, nil
+		}
+		return 0, fmt.Errorf("Wrong time: %s", value)
+	}
+
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+func parseScheduleProfile(profile string, normal, alternative *LimitsProfile) (*LimitsProfile, error) {
+	switch profile {
+	case ProfileNormal:
+		return normal, nil
+	case ProfileAlternative:
+		return alternative, nil
+	}
+
+	tokens := strings.SplitN(profile, "/", 2)
+	if len(tokens) != 2 {
+		return nil, fmt.Errorf("Unknown profile: %s", profile)
+	}
+
+	download, err := strconv.Atoi(tokens[0])
+	if err != nil {
+		return nil, fmt.Errorf("Wrong download limit: %s", tokens[0])
+	}
+	upload, err := strconv.Atoi(tokens[1])
+	if err != nil {
+		return nil, fmt.Errorf("Wrong upload limit: %s", tokens[1])
+	}
+
+	return &LimitsProfile{
+		Name:     ProfileCustom,
+		Download: download * 1024,
+		Upload:   upload * 1024,
+	}, nil
+}
+
+// String ...
+func (p *LimitsProfile) String() string {
+	return fmt.Sprintf("%s (download: %s, upload: %s)", p.Name, humanizeLimit(p.Download), humanizeLimit(p.Upload))
+}
+
+func humanizeLimit(limit int) string {
+	if limit <= 0 {
+		return "unlimited"
+	}
+	return humanize.Bytes(uint64(limit)) + "/s"
+}
+
+// limitsSchedule keeps limits profiles and rules, parsed from settings
+type limitsSchedule struct {
+	normal      *LimitsProfile
+	alternative *LimitsProfile
+	rules       []*ScheduleRule
+}
+
+// loadLimitsSchedule parses limits and schedule rules, should be called on every settings change
+func (s *Service) loadLimitsSchedule() {
+	schedule := &limitsSchedule{
+		normal: &LimitsProfile{
+			Name:     ProfileNormal,
+			Download: s.config.DownloadRateLimit,
+			Upload:   s.config.UploadRateLimit,
+		},
+		alternative: &LimitsProfile{
+			Name:     ProfileAlternative,
+			Download: s.config.AltDownloadRateLimit,
+			Upload:   s.config.AltUploadRateLimit,
+		},
+	}
+
+	if s.config.SchedulerEnabled {
+		rules, err := ParseScheduleRules(s.config.SchedulerRules, schedule.normal, schedule.alternative)
+		if err != nil {
+			log.Warningf("Could not parse bandwidth schedule: %s", err)
+		} else {
+			schedule.rules = rules
+		}
+	}
+
+	s.muLimits.Lock()
+	defer s.muLimits.Unlock()
+
+	s.schedule = schedule
+}
+
+// GetLimitsProfile returns limits, that should be active right now,
+// alternative speed toggle wins over the schedule.
+func (s *Service) GetLimitsProfile() *LimitsProfile {
+	s.muLimits.Lock()
+	defer s.muLimits.Unlock()
+
+	if s.altSpeed {
+		return s.schedule.alternative
+	}
+
+	now := time.Now()
+	for _, r := range s.schedule.rules {
+		if r.Matches(now) {
+			return r.Profile
+		}
+	}
+
+	return s.schedule.normal
+}
+
+// IsAltSpeed ...
+func (s *Service) IsAltSpeed() bool {
+	s.muLimits.Lock()
+	defer s.muLimits.Unlock()
+
+	return s.altSpeed
+}
+
+// SetAltSpeed enables or disables alternative speed limits
+func (s *Service) SetAltSpeed(enabled bool) {
+	s.muLimits.Lock()
+	s.altSpeed = enabled
+	s.muLimits.Unlock()
+
+	s.onAltSpeedChanged(enabled)
+}
+
+// ToggleAltSpeed ...
+func (s *Service) ToggleAltSpeed() bool {
+	s.muLimits.Lock()
+	s.altSpeed = !s.altSpeed
+	enabled := s.altSpeed
+	s.muLimits.Unlock()
+
+	s.onAltSpeedChanged(enabled)
+	return enabled
+}
+
+func (s *Service) onAltSpeedChanged(enabled bool) {
+	log.Infof("Alternative speed limits are %s", map[bool]string{true: "enabled", false: "disabled"}[enabled])
+
+	s.applyScheduledLimits(true)
+}
+
+func (s *Service) isBuffering() bool {
+	for _, t := range s.q.All() {
+		if t.IsBuffering {
+			return true
+		}
+	}
+
+	return false
+}
+
+// applyScheduledLimits sets limits of the active profile,
+// unless there is a buffering player, that has own limits
+func (s *Service) applyScheduledLimits(force bool) {
+	profile := s.GetLimitsProfile()
+
+	s.muLimits.Lock()
+	unchanged := s.activeProfile != nil && *s.activeProfile == *profile
+	s.muLimits.Unlock()
+
+	if !force && unchanged {
+		return
+	}
+
+	if s.config.LimitAfterBuffering && s.isBuffering() {
+		return
+	}
+
+	s.RestoreLimits()
+}
+
+func (s *Service) limitsScheduler() {
+	closing := s.Closer.C()
+	ticker := time.NewTicker(schedulerInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-closing:
+			return
+		case <-ticker.C:
+			if s.config.SchedulerEnabled || s.IsAltSpeed() {
+				s.applyScheduledLimits(false)
+			}
+		}
+	}
+}
@@ -32,6 +32,8 @@ import (
 	"github.com/mrjdainc/da-inc/config"
 	"github.com/mrjdainc/da-inc/database"
 	"github.com/mrjdainc/da-inc/diskusage"
+	"github.com/mrjdainc/da-inc/hooks"
+	"github.com/mrjdainc/da-inc/metrics"
 	"github.com/mrjdainc/da-inc/proxy"
 	"github.com/mrjdainc/da-inc/tmdb"
 	"github.com/mrjdainc/da-inc/util"
@@ -66,6 +68,19 @@ type Service struct {
 
 	MarkedToMove string
 
+	altSpeed      bool
+	activeProfile *LimitsProfile
+	schedule      *limitsSchedule
+	muLimits      sync.Mutex
+
+	blocklist *Blocklist
+
+	episodeSearch     EpisodeSearchFunc
+	alternativeSearch AlternativeSearchFunc
+
+	stalledTorrents map[string]time.Time
+	muStalled       sync.Mutex
+
 	alertsBroadcaster *broadcast.Broadcaster
 	Closer            util.Event
 	isShutdown        bool
@@ -91,7 +106,10 @@ func NewService() *Service {
 		SpaceChecked: map[string]bool{},
 		Players:      map[string]*Player{},
 
+		stalledTorrents: map[string]time.Time{},
+
 		alertsBroadcaster: broadcast.NewBroadcaster(),
+		blocklist:         &Blocklist{},
 	}
 
 	s.q = NewQueue(s)
@@ -102,21 +120,26 @@ func NewService() *Service {
 		return s
 	}
 
+	metrics.OnCollect(s.collectMetrics)
+
 	go s.alertsConsumer()
+	go s.eventsConsumer()
+	go s.hooksConsumer()
 	go s.logAlerts()
 
 	go s.startServices()
 
 	go s.watchConfig()
 	go s.saveResumeDataConsumer()
-	if !s.IsMemoryStorage() {
-		go s.saveResumeDataLoop()
-	}
+	go s.saveResumeDataLoop()
 
 	go tmdb.CheckAPIKey()
 
 	go s.loadTorrentFiles()
 	go s.downloadProgress()
+	go s.q.Watch()
+	go s.limitsScheduler()
+	go s.blocklistLoop()
 
 	return s
 }
@@ -139,6 +162,8 @@ func (s *Service) Close(isShutdown bool) {
 // CloseSession tries to close libtorrent session with a timeout,
 // because it takes too much to close and Kodi hangs.
 func (s *Service) CloseSession() {
+	s.saveSessionState()
+
 	log.Info("Closing Session")
 	if err := lt.DeleteSession(s.SessionGlobal); err != nil {
 		log.Errorf("Could not delete libtorrent session: %s", err)
@@ -169,6 +194,8 @@ func (s *Service) Reconfigure() {
 func (s *Service) configure() {
 	log.Info("Configuring client...")
 
+	s.loadLimitsSchedule()
+
 	if s.config.InternalProxyEnabled {
 		log.Infof("Starting internal proxy")
 		s.InternalProxy = proxy.StartProxy()
@@ -482,6 +509,12 @@ func (s *Service) configure() {
 
 	// s.Session.GetHandle().ApplySettings(s.PackSettings)
 
+	// Saved settings only fill the gaps, current config always wins over them
+	s.loadSessionState()
+	if s.config.SessionStateSettings {
+		s.Session.ApplySettings(s.PackSettings)
+	}
+
 	if !s.config.LimitAfterBuffering {
 		s.RestoreLimits()
 	}
@@ -514,6 +547,8 @@ func (s *Service) startServices() {
 		s.mappedPorts[p] = s.Session.AddPortMapping(lt.WrappedSessionHandleTcp, port, port)
 		log.Infof("Adding port mapping %v: %v", port, s.mappedPorts[p])
 	}
+
+	go s.LoadBlocklist(false)
 }
 
 func (s *Service) stopServices() {
@@ -642,6 +677,7 @@ func (s *Service) AddTorrent(uri string, paused bool) (*Torrent, error) {
 	var err error
 	var th lt.TorrentHandle
 	var infoHash string
+	isNew := true
 
 	// Dummy check if torrent file is a file containing a magnet link
 	if _, err := os.Stat(uri); err == nil {
@@ -693,6 +729,11 @@ func (s *Service) AddTorrent(uri string, paused bool) (*Torrent, error) {
 		infoHash = hex.EncodeToString([]byte(shaHash))
 	}
 
+	// Torrents, loaded on startup, already have saved torrent file
+	if _, err := os.Stat(filepath.Join(s.config.TorrentsPath, fmt.Sprintf("%s.torrent", infoHash))); err == nil {
+		isNew = false
+	}
+
 	log.Infof("Setting save path to %s", s.config.DownloadPath)
 	torrentParams.SetSavePath(s.config.DownloadPath)
 
@@ -745,6 +786,10 @@ func (s *Service) AddTorrent(uri string, paused bool) (*Torrent, error) {
 	t.addedTime = time.Now()
 	s.q.Add(t)
 
+	if isNew {
+		t.triggerHook(hooks.TorrentAdded)
+	}
+
 	if !t.HasMetadata() {
 		if err := t.WaitForMetadata(infoHash); err != nil {
 			return nil, err
@@ -754,6 +799,10 @@ func (s *Service) AddTorrent(uri string, paused bool) (*Torrent, error) {
 	// Saving torrent file
 	t.onMetadataReceived()
 
+	if isNew {
+		t.triggerHook(hooks.MetadataReceived)
+	}
+
 	go t.Watch()
 
 	return t, nil
@@ -795,22 +844,7 @@ func (s *Service) RemoveTorrent(t *Torrent, forceDrop, forceDelete, isWatched bo
 	}
 
 	if keepDownloading == false || s.IsMemoryStorage() {
-		// Delete torrent file
-		if len(t.torrentFile) > 0 {
-			if _, err := os.Stat(t.torrentFile); err == nil {
-				log.Infof("Deleting torrent file at %s", t.torrentFile)
-				defer os.Remove(t.torrentFile)
-			}
-		}
-
-		infoHash := t.InfoHash()
-		savedFilePath := filepath.Join(s.config.TorrentsPath, fmt.Sprintf("%s.torrent", infoHash))
-		if _, err := os.Stat(savedFilePath); err == nil {
-			log.Infof("Deleting saved torrent file at %s", savedFilePath)
-			defer os.Remove(savedFilePath)
-		}
-
-		log.Infof("Removed %s from database", t.Name())
+		s.removeTorrentFiles(t)
 
 		if deleteAnswer == true {
 			log.Info("Removing the torrent and deleting files after playing ...")
@@ -820,18 +854,56 @@ func (s *Service) RemoveTorrent(t *Torrent, forceDrop, forceDelete, isWatched bo
 	}
 
 	if !keepDownloading {
-		defer func() {
-			database.GetStorm().DeleteBTItem(t.InfoHash())
-		}()
+		s.dropTorrent(t, deleteAnswer)
+	}
 
-		s.q.Delete(t)
+	return true
+}
 
-		t.Drop(deleteAnswer)
+// DeleteTorrent removes torrent without asking the user, which is used by API clients,
+// downloaded files are deleted only if deleteFiles is set
+func (s *Service) DeleteTorrent(t *Torrent, deleteFiles bool) bool {
+	if t = s.q.FindByHash(t.InfoHash()); t == nil {
+		return false
 	}
 
+	log.Infof("Removing torrent: %s, deleting files: %v", t.Name(), deleteFiles)
+	s.removeTorrentFiles(t)
+	s.dropTorrent(t, deleteFiles)
 	return true
 }
 
+// removeTorrentFiles deletes .torrent files, that are kept to restore torrent on startup
+func (s *Service) removeTorrentFiles(t *Torrent) {
+	if len(t.torrentFile) > 0 {
+		if _, err := os.Stat(t.torrentFile); err == nil {
+			log.Infof("Deleting torrent file at %s", t.torrentFile)
+			os.Remove(t.torrentFile)
+		}
+	}
+
+	savedFilePath := filepath.Join(s.config.TorrentsPath, fmt.Sprintf("%s.torrent", t.InfoHash()))
+	if _, err := os.Stat(savedFilePath); err == nil {
+		log.Infof("Deleting saved torrent file at %s", savedFilePath)
+		os.Remove(savedFilePath)
+	}
+}
+
+// dropTorrent removes torrent from the session and the database
+func (s *Service) dropTorrent(t *Torrent, deleteFiles bool) {
+	t.triggerHook(hooks.TorrentRemoved)
+
+	defer func() {
+		database.GetStorm().DeleteBTItem(t.InfoHash())
+		database.GetStorm().DeleteTorrentQueueItem(t.InfoHash())
+	}()
+
+	s.q.Delete(t)
+
+	t.Drop(deleteFiles)
+	log.Infof("Removed %s from database", t.Name())
+}
+
 func (s *Service) onStateChanged(stateAlert lt.StateChangedAlert) {
 	switch stateAlert.GetState() {
 	case lt.TorrentStatusDownloading:
@@ -850,6 +922,11 @@ func (s *Service) onStateChanged(stateAlert lt.StateChangedAlert) {
 	}
 }
 
+// GetQueue ...
+func (s *Service) GetQueue() *Queue {
+	return s.q
+}
+
 // GetTorrentByHash ...
 func (s *Service) GetTorrentByHash(hash string) *Torrent {
 	return s.q.FindByHash(hash)
@@ -860,11 +937,23 @@ func (s *Service) saveResumeDataLoop() {
 	closing := s.Closer.C()
 	defer saveResumeWait.Stop()
 
+	sessionStateSaved := time.Now()
+
 	for {
 		select {
 		case <-closing:
 			return
 		case <-saveResumeWait.C:
+			if time.Since(sessionStateSaved) >= sessionStateSaveInterval {
+				s.saveSessionState()
+				sessionStateSaved = time.Now()
+			}
+
+			// Resume data is useless for memory storage
+			if s.IsMemoryStorage() {
+				continue
+			}
+
 			torrentsVector := s.Session.GetTorrents()
 			torrentsVectorSize := int(torrentsVector.Size())
 
@@ -984,6 +1073,7 @@ func (s *Service) alertsConsumer() {
 					Entry:    entry,
 					InfoHash: infoHash,
 				}
+				alertsMetric.Inc(alert.What)
 				s.alertsBroadcaster.Broadcast(alert)
 			}
 		}
@@ -1058,6 +1148,10 @@ func (s *Service) loadTorrentFiles() {
 
 		t, _ := s.AddTorrent(filePath, s.config.AutoloadTorrentsPaused)
 		if t != nil {
+			if qi := database.GetStorm().GetTorrentQueueItem(t.InfoHash()); qi != nil {
+				t.Priority = qi.Priority
+			}
+
 			i := database.GetStorm().GetBTItem(t.InfoHash())
 			if i != nil {
 				t.DBItem = i
@@ -1065,12 +1159,16 @@ func (s *Service) loadTorrentFiles() {
 				for _, p := range i.Files {
 					if f := t.GetFileByPath(p); f != nil {
 						t.DownloadFile(f)
+					} else {
+						log.Warningf("File %s is not found in torrent %s", p, t.InfoHash())
 					}
 				}
 			}
 		}
 	}
 
+	s.q.Restore()
+
 	s.cleanStaleFiles(s.config.DownloadPath, ".parts")
 	s.cleanStaleFiles(s.config.TorrentsPath, ".fastresume")
 }
@@ -1311,6 +1409,14 @@ func (s *Service) downloadProgress() {
 					torrentInfo := torrentHandle.TorrentFile()
 					for _, fp := range item.Files {
 						f := t.GetFileByPath(fp)
+						if f == nil {
+							log.Warningf("File %s is not found in torrent %s", fp, t.InfoHash())
+							continue
+						}
+						// Extracted file is moved once, for the first volume of the archive
+						if kind, volumes := t.archiveVolumes(f); kind == ArchiveRar && len(volumes) > 0 && volumes[0] != f {
+							continue
+						}
 
 						filePath := torrentInfo.Files().FilePath(f.Index)
 						fileName := filepath.Base(filePath)
@@ -1379,6 +1485,14 @@ func (s *Service) downloadProgress() {
 								}
 								log.Warning(fileName, "moved to", dst)
 
+								p := (&hooks.Payload{
+									InfoHash: infoHash,
+									Name:     torrentName,
+									SavePath: dstPath,
+									Path:     dst,
+								}).FromBTItem(item)
+								hooks.Trigger(hooks.TorrentMoved, p)
+
 								log.Infof("Marking %s for removal from library and database...", torrentName)
 								database.GetStorm().UpdateBTItemStatus(infoHash, Remove)
 							}
@@ -1435,11 +1549,16 @@ func (s *Service) SetUploadLimit(i int) {
 	s.Session.ApplySettings(settings)
 }
 
-// RestoreLimits ...
+// RestoreLimits applies limits of the active profile, from settings or from the schedule
 func (s *Service) RestoreLimits() {
-	if s.config.DownloadRateLimit > 0 {
-		s.SetDownloadLimit(s.config.DownloadRateLimit)
-		log.Infof("Rate limiting download to %s", humanize.Bytes(uint64(s.config.DownloadRateLimit)))
+	profile := s.GetLimitsProfile()
+	s.muLimits.Lock()
+	s.activeProfile = profile
+	s.muLimits.Unlock()
+
+	if profile.Download > 0 {
+		s.SetDownloadLimit(profile.Download)
+		log.Infof("Rate limiting download to %s", humanize.Bytes(uint64(profile.Download)))
 	} else {
 		s.SetDownloadLimit(0)
 	}
@@ -1447,13 +1566,17 @@ func (s *Service) RestoreLimits() {
 	// if s.config.DisableUpload {
 	// 	s.SetUploadLimit(1)
 	// 	log.Infof("Rate limiting upload to %d byte, due to disabled upload", 1)
-	// } else if s.config.UploadRateLimit > 0 {
-	if s.config.UploadRateLimit > 0 {
-		s.SetUploadLimit(s.config.UploadRateLimit)
-		log.Infof("Rate limiting upload to %s", humanize.Bytes(uint64(s.config.UploadRateLimit)))
+	// } else if profile.Upload > 0 {
+	if profile.Upload > 0 {
+		s.SetUploadLimit(profile.Upload)
+		log.Infof("Rate limiting upload to %s", humanize.Bytes(uint64(profile.Upload)))
 	} else {
 		s.SetUploadLimit(0)
 	}
+
+	if profile.Name != ProfileNormal {
+		log.Infof("Using limits profile %s", profile)
+	}
 }
 
 // SetBufferingLimits ...
@@ -1503,9 +1626,16 @@ func (s *Service) PlayerSeek() {
 
 // ClientInfo ...
 func (s *Service) ClientInfo(_w io.Writer) {
-	// TODO: Print any client info here
-	// w := bufio.NewWriter(_w)
+	w := bufio.NewWriter(_w)
+	defer w.Flush()
 
+	if s.config.BlocklistEnabled {
+		fmt.Fprintf(w, "Blocklist: %d ranges, updated at %s\n", s.blocklist.Count(), s.blocklist.LastUpdate().Format(time.RFC1123))
+	} else {
+		fmt.Fprintf(w, "Blocklist: disabled\n")
+	}
+
+	// TODO: Print any client info here
 	// for _, t := range s.q.All() {
 	// 	if t == nil || t.th == nil {
 	// 		continue
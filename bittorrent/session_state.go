@@ -0,0 +1,148 @@
+package bittorrent
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	lt "github.com/da-inc/libtorrent-go"
+	"github.com/zeebo/bencode"
+
+	"github.com/mrjdainc/da-inc/database"
+)
+
+const (
+	sessionStateFileName       = "session.state"
+	sessionStateBackupFileName = "session-backup.state"
+	sessionStateSaveInterval   = 5 * time.Minute
+)
+
+var sessionStateMu sync.Mutex
+
+func (s *Service) sessionStatePath() string {
+	return filepath.Join(s.config.ProfilePath, sessionStateFileName)
+}
+
+func (s *Service) sessionStateBackupPath() string {
+	return filepath.Join(s.config.ProfilePath, sessionStateBackupFileName)
+}
+
+func (s *Service) sessionStateFlags() uint {
+	flags := uint(lt.WrappedSessionHandleSaveDhtState)
+	if s.config.SessionStateSettings {
+		flags |= uint(lt.WrappedSessionHandleSaveSettings)
+	}
+
+	return flags
+}
+
+// saveSessionState saves DHT nodes, and session settings if enabled, to the profile folder.
+// Previous state is kept as a backup, in case new file gets corrupted.
+func (s *Service) saveSessionState() {
+	if s.Session == nil || s.Session.Swigcptr() == 0 {
+		return
+	}
+
+	sessionStateMu.Lock()
+	defer sessionStateMu.Unlock()
+
+	entry := lt.NewEntry()
+	defer lt.DeleteEntry(entry)
+
+	s.Session.SaveState(entry, s.sessionStateFlags())
+	data := []byte(lt.Bencode(entry))
+	if err := validateSessionState(data); err != nil {
+		log.Warningf("Not saving session state: %s", err)
+		return
+	}
+
+	path := s.sessionStatePath()
+	if current, err := ioutil.ReadFile(path); err == nil && validateSessionState(current) == nil {
+		if err := ioutil.WriteFile(s.sessionStateBackupPath(), current, 0644); err != nil {
+			log.Warningf("Could not save session state backup: %s", err)
+		}
+	}
+
+	tmpPath := path + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, data, 0644); err != nil {
+		log.Warningf("Could not save session state: %s", err)
+		return
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		log.Warningf("Could not save session state: %s", err)
+		return
+	}
+
+	log.Debugf("Session state saved to %s (%d bytes)", path, len(data))
+}
+
+// loadSessionState restores session state, saved with previous run,
+// falling back to a backup if state file is corrupted.
+// Restored settings should be overridden with current config afterwards.
+func (s *Service) loadSessionState() {
+	if s.Session == nil || s.Session.Swigcptr() == 0 {
+		return
+	}
+
+	sessionStateMu.Lock()
+	defer sessionStateMu.Unlock()
+
+	path := s.sessionStatePath()
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err == nil {
+		err = validateSessionState(data)
+	}
+	if err != nil {
+		log.Warningf("Session state at %s is corrupted: %s", path, err)
+
+		database.RestoreBackup(path, s.sessionStateBackupPath())
+		if data, err = ioutil.ReadFile(path); err == nil {
+			err = validateSessionState(data)
+		}
+		if err != nil {
+			log.Warningf("Could not restore session state from backup: %s", err)
+			os.Remove(path)
+			return
+		}
+	}
+
+	vector := lt.NewStdVectorChar()
+	defer lt.DeleteStdVectorChar(vector)
+	for _, c := range data {
+		vector.Add(c)
+	}
+
+	node := lt.NewBdecodeNode()
+	defer lt.DeleteBdecodeNode(node)
+
+	if err := lt.Bdecode(vector, node); err != nil {
+		log.Warningf("Could not decode session state: %s", err)
+		return
+	}
+
+	s.Session.LoadState(node, s.sessionStateFlags())
+	log.Infof("Session state loaded from %s", path)
+}
+
+func validateSessionState(data []byte) error {
+	if len(data) == 0 {
+		return fmt.Errorf("Session state is empty")
+	}
+
+	var state map[string]interface{}
+	if err := bencode.NewDecoder(bytes.NewReader(data)).Decode(&state); err != nil {
+		return err
+	} else if len(state) == 0 {
+		return fmt.Errorf("Session state has no entries")
+	}
+
+	return nil
+}
@@ -0,0 +1,146 @@
+package bittorrent
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/mrjdainc/da-inc/config"
+	"github.com/mrjdainc/da-inc/database"
+	"github.com/mrjdainc/da-inc/xbmc"
+)
+
+// stalledExpiration is how long a stalled torrent is not chosen as an alternative
+const stalledExpiration = 6 * time.Hour
+
+var errStalled = errors.New("Stream has stalled")
+
+// AlternativeSearchFunc returns the best link for the media of the player,
+// skipping torrents, which are reported by skip function
+type AlternativeSearchFunc func(params *PlayerParams, skip func(infoHash string) bool) *TorrentFile
+
+// SetAlternativeSearch sets the function, used for finding a replacement of stalled torrent
+func (s *Service) SetAlternativeSearch(fn AlternativeSearchFunc) {
+	s.alternativeSearch = fn
+}
+
+func (s *Service) markStalled(infoHash string) {
+	s.muStalled.Lock()
+	defer s.muStalled.Unlock()
+
+	s.stalledTorrents[infoHash] = time.Now()
+}
+
+// IsStalled checks whether torrent has stalled recently
+func (s *Service) IsStalled(infoHash string) bool {
+	s.muStalled.Lock()
+	defer s.muStalled.Unlock()
+
+	at, ok := s.stalledTorrents[infoHash]
+	if ok && time.Since(at) > stalledExpiration {
+		delete(s.stalledTorrents, infoHash)
+		return false
+	}
+	return ok
+}
+
+// GetStallTimeout returns stall timeout for the torrent, zero means fallback is disabled
+func (t *Torrent) GetStallTimeout() time.Duration {
+	if !config.Get().StallFallbackEnabled {
+		return 0
+	}
+
+	timeout := config.Get().StallTimeout
+	if t.DBItem != nil && t.DBItem.StallTimeout != 0 {
+		timeout = t.DBItem.StallTimeout
+	}
+	if timeout <= 0 {
+		return 0
+	}
+
+	return time.Duration(timeout) * time.Second
+}
+
+// SetStallTimeout overrides global stall timeout, -1 disables fallback for the torrent, 0 restores the default
+func (t *Torrent) SetStallTimeout(timeout int) error {
+	if err := database.GetStorm().UpdateBTItemStallTimeout(t.infoHash, timeout); err != nil {
+		return err
+	}
+
+	t.FetchDBItem()
+	return nil
+}
+
+// isStalled is called every second while buffering or playing,
+// torrent is stalled if it does not download pieces, that are waited for, or has too few seeders
+func (btp *Player) isStalled() bool {
+	timeout := btp.t.GetStallTimeout()
+	if timeout == 0 || btp.p.Background || btp.fallbackStarted {
+		return false
+	}
+
+	waiting := btp.t.IsBuffering || !btp.t.awaitingPieces.IsEmpty()
+	down, _ := btp.t.GetSpeeds()
+	_, seedsTotal, _, _ := btp.t.GetConnections()
+	fewSeeders := config.Get().StallMinSeeders > 0 && seedsTotal < config.Get().StallMinSeeders
+
+	if !waiting || (down > 0 && !fewSeeders) {
+		btp.stalledSince = time.Time{}
+		return false
+	} else if btp.stalledSince.IsZero() {
+		btp.stalledSince = time.Now()
+		return false
+	}
+
+	return time.Since(btp.stalledSince) >= timeout
+}
+
+// findAlternative marks torrent as stalled and looks for the next best link,
+// user is notified if nothing is found
+func (btp *Player) findAlternative() *TorrentFile {
+	btp.s.markStalled(btp.t.InfoHash())
+	log.Warningf("Torrent %s has stalled at %.0fs, looking for alternatives", btp.t.Name(), btp.p.WatchedTime)
+
+	if btp.s.alternativeSearch == nil {
+		return nil
+	}
+
+	torrent := btp.s.alternativeSearch(btp.p, btp.s.IsStalled)
+	if torrent == nil {
+		log.Info("No alternative torrents found")
+		xbmc.Notify("dainc", "LOCALIZE[30613]", config.AddonIcon())
+	}
+	return torrent
+}
+
+// fallback switches playback to the next best link, if there is one
+func (btp *Player) fallback() {
+	if torrent := btp.findAlternative(); torrent != nil {
+		btp.switchTo(torrent)
+	}
+}
+
+// bufferFallback looks for the next best link while buffering goes on,
+// buffering is aborted only when an alternative is found
+func (btp *Player) bufferFallback() {
+	if torrent := btp.findAlternative(); torrent != nil {
+		btp.alternatives <- torrent
+	}
+}
+
+// switchTo starts playback of the torrent, keeping the position
+func (btp *Player) switchTo(torrent *TorrentFile) {
+	log.Infof("Switching playback to %s", torrent.Name)
+	xbmc.Notify("dainc", "LOCALIZE[30612]", config.AddonIcon())
+
+	xbmc.PlayURLWithTimeout(URLQuery(URLForXBMC("/play"),
+		"uri", torrent.URI,
+		"tmdb", strconv.Itoa(btp.p.TMDBId),
+		"show", strconv.Itoa(btp.p.ShowID),
+		"season", strconv.Itoa(btp.p.Season),
+		"episode", strconv.Itoa(btp.p.Episode),
+		"query", btp.p.Query,
+		"type", btp.p.ContentType,
+		"position", fmt.Sprintf("%.0f", btp.p.WatchedTime)))
+}
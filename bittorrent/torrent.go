@@ -27,6 +27,7 @@ import (
 
 	"github.com/mrjdainc/da-inc/config"
 	"github.com/mrjdainc/da-inc/database"
+	"github.com/mrjdainc/da-inc/probe"
 	"github.com/mrjdainc/da-inc/tmdb"
 	"github.com/mrjdainc/da-inc/util"
 	"github.com/mrjdainc/da-inc/xbmc"
@@ -66,6 +67,9 @@ type Torrent struct {
 	BufferPiecesProgress   map[int]float64
 	MemorySize             int64
 
+	bufferStartedAt  time.Time
+	bufferFinishedAt time.Time
+
 	IsPlaying           bool
 	IsPaused            bool
 	IsBuffering         bool
@@ -74,10 +78,16 @@ type Torrent struct {
 	IsRarArchive        bool
 	IsNextFile          bool
 	HasNextFile         bool
+	IsQueued            bool
 	PlayerAttached      int
 
+	Priority int
+
 	DBItem *database.BTItem
 
+	archive   *ArchiveEntry
+	mediaInfo *probe.Info
+
 	mu        *sync.Mutex
 	muBuffer  *sync.RWMutex
 	muReaders *sync.Mutex
@@ -302,6 +312,20 @@ func (t *Torrent) GetSpeeds() (down, up int) {
 	return ts.GetDownloadPayloadRate(), ts.GetUploadPayloadRate()
 }
 
+// GetBufferingDuration returns time spent on buffering, or zero if buffering was not started
+func (t *Torrent) GetBufferingDuration() time.Duration {
+	t.muBuffer.RLock()
+	defer t.muBuffer.RUnlock()
+
+	if t.bufferStartedAt.IsZero() {
+		return 0
+	} else if t.IsBuffering || t.bufferFinishedAt.Before(t.bufferStartedAt) {
+		return time.Since(t.bufferStartedAt)
+	}
+
+	return t.bufferFinishedAt.Sub(t.bufferStartedAt)
+}
+
 // GetHumanizedSpeeds returns humanize download and upload speeds
 func (t *Torrent) GetHumanizedSpeeds() (down, up string) {
 	downInt, upInt := t.GetSpeeds()
@@ -315,6 +339,7 @@ func (t *Torrent) bufferFinishedEvent() {
 	t.BufferPiecesProgress = map[int]float64{}
 	t.IsBuffering = false
 	t.IsBufferingFinished = true
+	t.bufferFinishedAt = time.Now()
 
 	t.muBuffer.Unlock()
 
@@ -342,7 +367,16 @@ func (t *Torrent) Buffer(file *File, isStartup bool) {
 
 	startBufferSize := t.Service.GetBufferSize()
 	preBufferStart, preBufferEnd, preBufferOffset, preBufferSize := t.getBufferSize(file.Offset, 0, startBufferSize)
-	postBufferStart, postBufferEnd, postBufferOffset, postBufferSize := t.getBufferSize(file.Offset, file.Size-int64(config.Get().EndBufferSize), int64(config.Get().EndBufferSize))
+	postBufferFileOffset := file.Offset
+	if a := t.GetArchive(file); a != nil {
+		// End of archived file is in the last volume, so the offset is mapped separately
+		postBufferPos := file.Size - int64(config.Get().EndBufferSize)
+		if postBufferPos < 0 {
+			postBufferPos = 0
+		}
+		postBufferFileOffset = a.TorrentOffset(postBufferPos) - postBufferPos
+	}
+	postBufferStart, postBufferEnd, postBufferOffset, postBufferSize := t.getBufferSize(postBufferFileOffset, file.Size-int64(config.Get().EndBufferSize), int64(config.Get().EndBufferSize))
 
 	// TODO: Remove this piece of buffer adjustment?
 	// if config.Get().AutoAdjustBufferSize && preBufferEnd-preBufferStart < 10 {
@@ -415,6 +449,7 @@ func (t *Torrent) Buffer(file *File, isStartup bool) {
 	t.muBuffer.Lock()
 	t.IsBuffering = true
 	t.IsBufferingFinished = false
+	t.bufferStartedAt = time.Now()
 	t.BufferProgress = 0
 	t.BufferProgressPrevious = 0
 	t.BufferLength = preBufferSize + postBufferSize
@@ -511,6 +546,8 @@ func (t *Torrent) Buffer(file *File, isStartup bool) {
 			t.th.SetPieceDeadline(curPiece, 0, 0)
 		}
 	}
+
+	go t.probeFile(file)
 }
 
 // AdjustMemorySize ...
@@ -767,6 +804,16 @@ func (t *Torrent) GetAddedTime() time.Time {
 	return t.addedTime
 }
 
+// GetPieceLength ...
+func (t *Torrent) GetPieceLength() int64 {
+	return t.pieceLength
+}
+
+// GetPieceCount ...
+func (t *Torrent) GetPieceCount() int {
+	return t.pieceCount
+}
+
 // GetStatus ...
 func (t *Torrent) GetStatus() lt.TorrentStatus {
 	return t.th.Status(uint(lt.WrappedTorrentHandleQueryName))
@@ -792,7 +839,7 @@ func (t *Torrent) GetStateString() string {
 		}
 	}
 
-	if t.th == nil || t.th.Swigcptr() == 0 {
+	if t.th == nil || t.th.Swigcptr() == 0 || t.IsQueued {
 		return StatusStrings[StatusQueued]
 	}
 
@@ -1058,6 +1105,7 @@ func (t *Torrent) Drop(removeFiles bool) {
 
 	log.Infof("Dropping torrent: %s", t.Name())
 	t.Closer.Set()
+	probedMedia.Delete(t.InfoHash())
 
 	for _, r := range t.readers {
 		if r != nil {
@@ -1110,6 +1158,7 @@ func (t *Torrent) Pause() {
 	t.th.Pause()
 
 	t.IsPaused = true
+	t.IsQueued = false
 }
 
 // Resume ...
@@ -1124,6 +1173,66 @@ func (t *Torrent) Resume() {
 	t.th.Resume()
 
 	t.IsPaused = false
+	t.IsQueued = false
+
+	t.Service.q.Notify()
+}
+
+// GetPriority returns priority class of a torrent,
+// active playback always has streaming priority
+func (t *Torrent) GetPriority() int {
+	if t.IsPlaying || t.IsBuffering || t.IsNextFile {
+		return PriorityStreaming
+	} else if t.Priority == PriorityDefault {
+		return PriorityManual
+	}
+
+	return t.Priority
+}
+
+// SetPriority changes priority class of a torrent and saves it
+func (t *Torrent) SetPriority(priority int) {
+	if _, ok := PriorityStrings[priority]; !ok {
+		return
+	}
+
+	t.Priority = priority
+	if err := database.GetStorm().SaveTorrentQueueItem(t.infoHash, t.Service.q.Position(t), priority); err != nil {
+		log.Warningf("Could not save priority of %s: %s", t.Name(), err)
+	}
+
+	t.Service.q.Notify()
+}
+
+// GetQueuePosition ...
+func (t *Torrent) GetQueuePosition() int {
+	return t.Service.q.Position(t)
+}
+
+func (t *Torrent) queue() {
+	if t.Closer.IsSet() {
+		return
+	}
+
+	log.Infof("Queueing torrent: %s", t.InfoHash())
+
+	t.th.AutoManaged(false)
+	t.th.Pause()
+
+	t.IsQueued = true
+}
+
+func (t *Torrent) unqueue() {
+	if t.Closer.IsSet() {
+		return
+	}
+
+	log.Infof("Starting queued torrent: %s", t.InfoHash())
+
+	t.th.AutoManaged(true)
+	t.th.Resume()
+
+	t.IsQueued = false
 }
 
 // GetDBItem ...
@@ -1303,6 +1412,22 @@ func (t *Torrent) MakeFiles() {
 	}
 }
 
+// GetFileProgress returns percentage of downloaded pieces of a file
+func (t *Torrent) GetFileProgress(f *File) float64 {
+	if f == nil || f.PieceEnd < f.PieceStart {
+		return 0
+	}
+
+	have := 0
+	for i := f.PieceStart; i <= f.PieceEnd; i++ {
+		if t.hasPiece(i) {
+			have++
+		}
+	}
+
+	return float64(have) / float64(f.PieceEnd-f.PieceStart+1) * 100
+}
+
 // GetFileByPath ...
 func (t *Torrent) GetFileByPath(q string) *File {
 	for _, f := range t.files {
@@ -1551,12 +1676,6 @@ func (t *Torrent) GetCandidateFiles(btp *Player) ([]*CandidateFile, int, error)
 		re := regexp.MustCompile(`(?i).*\.rar$`)
 		if re.MatchString(fileName) && size > 10*1024*1024 {
 			t.IsRarArchive = true
-			if !xbmc.DialogConfirm("dainc", "LOCALIZE[30303]") {
-				if btp != nil {
-					btp.notEnoughSpace = true
-				}
-				return nil, -1, errors.New("RAR archive detected and download was cancelled")
-			}
 		}
 	}
 
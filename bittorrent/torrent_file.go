@@ -24,7 +24,9 @@ import (
 	"github.com/zeebo/bencode"
 
 	"github.com/mrjdainc/da-inc/config"
+	"github.com/mrjdainc/da-inc/probe"
 	"github.com/mrjdainc/da-inc/proxy"
+	"github.com/mrjdainc/da-inc/release"
 	"github.com/mrjdainc/da-inc/util"
 	"github.com/mrjdainc/da-inc/xbmc"
 )
@@ -55,9 +57,28 @@ type TorrentFile struct {
 	RipType     int    `json:"rip_type"`
 	SceneRating int    `json:"scene_rating"`
 
+	// Extra fields, reported by providers
+	Files      []ProviderFile `json:"files,omitempty"`
+	Freeleech  bool           `json:"freeleech,omitempty"`
+	UploadDate int64          `json:"upload_date,omitempty"`
+
+	Score   int           `json:"score"`
+	Flagged string        `json:"flagged,omitempty"`
+	Dropped string        `json:"dropped,omitempty"`
+	Release *release.Info `json:"-"`
+
+	// Searcher, which returned the torrent, used for provider stats
+	Searcher string `json:"-"`
+
 	hasResolved bool
 }
 
+// ProviderFile is a file inside the torrent, as reported by a provider
+type ProviderFile struct {
+	Name string `json:"name"`
+	Size int64  `json:"size"`
+}
+
 // Used to avoid infinite recursion in UnmarshalJSON
 type torrent TorrentFile
 
@@ -71,81 +92,56 @@ type TorrentFileRaw struct {
 
 const (
 	// ResolutionUnknown ...
-	ResolutionUnknown = iota
+	ResolutionUnknown = release.ResolutionUnknown
 	// Resolution240p ...
-	Resolution240p
+	Resolution240p = release.Resolution240p
 	// Resolution480p ...
-	Resolution480p
+	Resolution480p = release.Resolution480p
 	// Resolution720p ...
-	Resolution720p
+	Resolution720p = release.Resolution720p
 	// Resolution1080p ...
-	Resolution1080p
+	Resolution1080p = release.Resolution1080p
 	// Resolution2K ...
-	Resolution2K
+	Resolution2K = release.Resolution2K
 	// Resolution4k ...
-	Resolution4k
+	Resolution4k = release.Resolution4k
 )
 
 var (
-	// [pр] actually contains "p" from latin and "р" from cyrillic, which looks the same, but it's not.
-	resolutionTags = []map[*regexp.Regexp]int{
-		{regexp.MustCompile(`(?i)\W+240[pр]\W*`): Resolution240p},
-		{regexp.MustCompile(`(?i)\W+480[pр]\W*`): Resolution480p},
-		{regexp.MustCompile(`(?i)\W+(720[pр]|1280x720)\W*`): Resolution720p},
-		{regexp.MustCompile(`(?i)\W+(1080[piр]|1920x1080)\W*`): Resolution1080p},
-		{regexp.MustCompile(`(?i)\W+1440[pр]\W*`): Resolution2K},
-		{regexp.MustCompile(`(?i)\W+(4k|2160[pр]|UHD)\W*`): Resolution4k},
-
-		{regexp.MustCompile(`(?i)\W+(vhs\-?rip)\W*`): Resolution240p},
-		{regexp.MustCompile(`(?i)\W+(tv\-?rip|sat\-?rip|iptv\-?rip|xvid|dvd|hdtv|web\-(dl)?rip)\W*`): Resolution480p},
-		{regexp.MustCompile(`(?i)\W+(hd720p?|hd\-?rip|b[rd]rip)\W*`): Resolution720p},
-		{regexp.MustCompile(`(?i)\W+(hd1080p?|fullhd|fhd|blu\W*ray|bd\W*remux)\W*`): Resolution1080p},
-		{regexp.MustCompile(`(?i)\W+(2k)\W*`): Resolution2K},
-		{regexp.MustCompile(`(?i)\W+(4k|hd4k)\W*`): Resolution4k},
-	}
 	// Resolutions ...
 	Resolutions = []string{"", "240p", "480p", "720p", "1080p", "2K", "4K"}
 	// Colors ...
 	Colors = []string{"", "FFFC3401", "FFA56F01", "FF539A02", "FF0166FC", "FFF15052", "FF6BB9EC"}
 	// Size regexp
 	sizeMatcher = regexp.MustCompile(`^\s*([\d\.\,]+)\s*`)
+	// Executables are the usual content of fake releases
+	executableFileRegex = regexp.MustCompile(`(?i)\.(exe|msi|bat|cmd|scr|com|lnk|vbs)$`)
 )
 
 const (
 	// RipUnknown ...
-	RipUnknown = iota
+	RipUnknown = release.SourceUnknown
 	// RipCam ...
-	RipCam
+	RipCam = release.SourceCam
 	// RipTS ...
-	RipTS
+	RipTS = release.SourceTS
 	// RipTC ...
-	RipTC
+	RipTC = release.SourceTC
 	// RipScr ...
-	RipScr
+	RipScr = release.SourceScr
 	// RipDVDScr ...
-	RipDVDScr
+	RipDVDScr = release.SourceDVDScr
 	// RipDVD ...
-	RipDVD
+	RipDVD = release.SourceDVD
 	// RipHDTV ...
-	RipHDTV
+	RipHDTV = release.SourceHDTV
 	// RipWeb ...
-	RipWeb
+	RipWeb = release.SourceWeb
 	// RipBluRay ...
-	RipBluRay
+	RipBluRay = release.SourceBluRay
 )
 
 var (
-	ripTags = map[*regexp.Regexp]int{
-		regexp.MustCompile(`(?i)\W+(cam|camrip|hdcam)\W*`):   RipCam,
-		regexp.MustCompile(`(?i)\W+(ts|telesync)\W*`):        RipTS,
-		regexp.MustCompile(`(?i)\W+(tc|telecine)\W*`):        RipTC,
-		regexp.MustCompile(`(?i)\W+(scr|screener)\W*`):       RipScr,
-		regexp.MustCompile(`(?i)\W+dvd\W*scr\W*`):            RipDVDScr,
-		regexp.MustCompile(`(?i)\W+dvd\W*rip\W*`):            RipDVD,
-		regexp.MustCompile(`(?i)\W+hd(tv|rip)\W*`):           RipHDTV,
-		regexp.MustCompile(`(?i)\W+(web\W*dl|web\W*rip)\W*`): RipWeb,
-		regexp.MustCompile(`(?i)\W+(bluray|b[rd]rip)\W*`):    RipBluRay,
-	}
 	// Rips ...
 	Rips = []string{"", "Cam", "TeleSync", "TeleCine", "Screener", "DVD Screener", "DVDRip", "HDTV", "WebDL", "Blu-Ray"}
 )
@@ -159,54 +155,40 @@ const (
 	RatingNuked
 )
 
-var (
-	sceneTags = map[*regexp.Regexp]int{
-		regexp.MustCompile(`(?i)\W+nuked\W*`):  RatingNuked,
-		regexp.MustCompile(`(?i)\W+proper\W*`): RatingProper,
-	}
-)
-
 const (
 	// CodecUnknown ...
-	CodecUnknown = iota
+	CodecUnknown = release.CodecUnknown
 
 	// CodecXVid ...
-	CodecXVid
+	CodecXVid = release.CodecXVid
 	// CodecH264 ...
-	CodecH264
+	CodecH264 = release.CodecH264
 	// CodecH265 ...
-	CodecH265
+	CodecH265 = release.CodecH265
+	// CodecAV1 ...
+	CodecAV1 = release.CodecAV1
 
 	// CodecMp3 ...
-	CodecMp3
+	CodecMp3 = release.CodecMp3
 	// CodecAAC ...
-	CodecAAC
+	CodecAAC = release.CodecAAC
 	// CodecAC3 ...
-	CodecAC3
+	CodecAC3 = release.CodecAC3
+	// CodecEAC3 ...
+	CodecEAC3 = release.CodecEAC3
 	// CodecDTS ...
-	CodecDTS
+	CodecDTS = release.CodecDTS
 	// CodecDTSHD ...
-	CodecDTSHD
+	CodecDTSHD = release.CodecDTSHD
 	// CodecDTSHDMA ...
-	CodecDTSHDMA
+	CodecDTSHDMA = release.CodecDTSHDMA
+	// CodecTrueHD ...
+	CodecTrueHD = release.CodecTrueHD
 )
 
 var (
-	videoTags = map[*regexp.Regexp]int{
-		regexp.MustCompile(`(?i)\W+xvid\W*`):           CodecXVid,
-		regexp.MustCompile(`(?i)\W+([hx]264)\W*`):      CodecH264,
-		regexp.MustCompile(`(?i)\W+([hx]265|hevc)\W*`): CodecH265,
-	}
-	audioTags = map[*regexp.Regexp]int{
-		regexp.MustCompile(`(?i)\W+mp3\W*`):              CodecMp3,
-		regexp.MustCompile(`(?i)\W+aac\W*`):              CodecAAC,
-		regexp.MustCompile(`(?i)\W+(ac3|[Dd]*5\W+1)\W*`): CodecAC3,
-		regexp.MustCompile(`(?i)\W+dts\W*`):              CodecDTS,
-		regexp.MustCompile(`(?i)\W+dts\W+hd\W*`):         CodecDTSHD,
-		regexp.MustCompile(`(?i)\W+dts\W+hd\W+ma\W*`):    CodecDTSHDMA,
-	}
 	// Codecs ...
-	Codecs = []string{"", "Xvid", "H.264", "H.265", "MP3", "AAC", "AC3", "DTS", "DTS HD", "DTS HD MA"}
+	Codecs = []string{"", "Xvid", "H.264", "H.265", "MP3", "AAC", "AC3", "DTS", "DTS HD", "DTS HD MA", "AV1", "E-AC3", "TrueHD"}
 )
 
 const (
@@ -293,28 +275,41 @@ func NewTorrentFile(uri string) *TorrentFile {
 	return t
 }
 
+// Initialize fills fields, that are not set by the provider, from magnet uri and release name
+func (t *TorrentFile) Initialize() {
+	t.initialize()
+}
+
 func (t *TorrentFile) initialize() {
 	if t.IsMagnet() {
 		t.initializeFromMagnet()
 	}
 
+	t.Release = release.Parse(t.Name)
 	if t.Resolution == ResolutionUnknown {
-		t.Resolution = matchLowerTags(t, resolutionTags)
+		t.Resolution = t.Release.Resolution
 		if t.Resolution == ResolutionUnknown {
 			t.Resolution = Resolution480p
 		}
 	}
 	if t.VideoCodec == CodecUnknown {
-		t.VideoCodec = matchTags(t, videoTags)
+		t.VideoCodec = t.Release.VideoCodec
 	}
 	if t.AudioCodec == CodecUnknown {
-		t.AudioCodec = matchTags(t, audioTags)
+		t.AudioCodec = t.Release.AudioCodec
 	}
 	if t.RipType == RipUnknown {
-		t.RipType = matchTags(t, ripTags)
+		t.RipType = t.Release.Source
 	}
 	if t.SceneRating == RatingUnkown {
-		t.SceneRating = matchTags(t, sceneTags)
+		if t.Release.Nuked {
+			t.SceneRating = RatingNuked
+		} else if t.Release.Proper || t.Release.Repack {
+			t.SceneRating = RatingProper
+		}
+	}
+	if t.Language == "" && len(t.Release.Languages) > 0 {
+		t.Language = t.Release.Languages[0]
 	}
 	t.beautifySize()
 	t.parseSize()
@@ -445,6 +440,101 @@ func (t *TorrentFile) LoadFromBytes(in []byte) error {
 	return nil
 }
 
+// LoadMetadata fills files and real size of the torrent from its metadata,
+// quality is detected again from the name of the largest video file
+func (t *TorrentFile) LoadMetadata(in []byte) error {
+	var torrentFile *TorrentFileRaw
+	if err := bencode.DecodeBytes(in, &torrentFile); err != nil {
+		return err
+	}
+
+	name, _ := torrentFile.Info["name"].(string)
+	files := make([]ProviderFile, 0)
+	if list, ok := torrentFile.Info["files"].([]interface{}); ok {
+		for _, item := range list {
+			entry, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			size, _ := entry["length"].(int64)
+			path := []string{name}
+			if parts, ok := entry["path"].([]interface{}); ok {
+				for _, p := range parts {
+					if s, ok := p.(string); ok {
+						path = append(path, s)
+					}
+				}
+			}
+			files = append(files, ProviderFile{Name: strings.Join(path, "/"), Size: size})
+		}
+	} else {
+		size, _ := torrentFile.Info["length"].(int64)
+		files = append(files, ProviderFile{Name: name, Size: size})
+	}
+
+	var total int64
+	for _, f := range files {
+		total += f.Size
+	}
+	if total == 0 {
+		return fmt.Errorf("No files in metadata of %s", t.InfoHash)
+	}
+
+	t.Files = files
+	t.SizeParsed = uint64(total)
+	t.Size = humanize.Bytes(t.SizeParsed)
+
+	video := t.LargestVideoFile()
+	if video == nil {
+		return nil
+	}
+
+	info := release.Parse(filepath.Base(video.Name))
+	if info.Resolution != ResolutionUnknown {
+		t.Resolution = info.Resolution
+	}
+	if info.VideoCodec != CodecUnknown {
+		t.VideoCodec = info.VideoCodec
+	}
+	if info.AudioCodec != CodecUnknown {
+		t.AudioCodec = info.AudioCodec
+	}
+	if info.Source != RipUnknown {
+		t.RipType = info.Source
+	}
+	if t.Release != nil && t.Release.HDR == "" {
+		t.Release.HDR = info.HDR
+	}
+
+	return nil
+}
+
+// LargestVideoFile returns the biggest video file of the torrent, if its files are known
+func (t *TorrentFile) LargestVideoFile() *ProviderFile {
+	var ret *ProviderFile
+	for i := range t.Files {
+		if videoFileRegex.MatchString(t.Files[i].Name) && (ret == nil || t.Files[i].Size > ret.Size) {
+			ret = &t.Files[i]
+		}
+	}
+	return ret
+}
+
+// IsFake checks whether known files of the torrent contain executables and no video
+func (t *TorrentFile) IsFake() bool {
+	if len(t.Files) == 0 || t.LargestVideoFile() != nil {
+		return false
+	}
+
+	for _, f := range t.Files {
+		if executableFileRegex.MatchString(f.Name) {
+			return true
+		}
+	}
+	return false
+}
+
 // Download takes care about torrent's URI and downloads or reads cached file
 func (t *TorrentFile) Download() ([]byte, error) {
 
@@ -580,33 +670,13 @@ func (t *TorrentFile) Resolve() error {
 	return nil
 }
 
-func matchTags(t *TorrentFile, tokens map[*regexp.Regexp]int) int {
-	lowName := strings.ToLower(t.Name)
-	codec := 0
-	for re, value := range tokens {
-		if re.MatchString(lowName) {
-			if value > codec {
-				codec = value
-			}
-		}
-	}
-	return codec
-}
-
-func matchLowerTags(t *TorrentFile, tokens []map[*regexp.Regexp]int) int {
-	lowName := strings.ToLower(t.Name)
-	for _, res := range tokens {
-		for re, value := range res {
-			if re.MatchString(lowName) {
-				return value
-			}
-		}
+// StreamInfo returns probed container details, if the torrent was played,
+// otherwise details are guessed from the name
+func (t *TorrentFile) StreamInfo() *xbmc.StreamInfo {
+	if info, ok := probedMedia.Load(t.InfoHash); ok {
+		return MediaStreamInfo(info.(*probe.Info))
 	}
-	return 0
-}
 
-// StreamInfo ...
-func (t *TorrentFile) StreamInfo() *xbmc.StreamInfo {
 	sie := &xbmc.StreamInfo{
 		Video: &xbmc.StreamInfoEntry{
 			Codec: Codecs[t.VideoCodec],
@@ -33,6 +33,8 @@ type TorrentFSEntry struct {
 	t   *Torrent
 	f   *File
 
+	archive *ArchiveEntry
+
 	totalLength int64
 	pieceLength int
 	numPieces   int
@@ -50,6 +52,10 @@ type TorrentFSEntry struct {
 	isActive bool
 }
 
+type pieceReader interface {
+	ReadPiece(b []byte, piece int, pieceOffset int) (int, error)
+}
+
 // PieceRange ...
 type PieceRange struct {
 	Begin, End int
@@ -70,6 +76,13 @@ func (tfs *TorrentFS) Open(uname string) (http.File, error) {
 	var file http.File
 	var err error
 
+	for _, t := range tfs.s.q.All() {
+		if a := t.getArchiveByPath(name[1:]); a != nil {
+			log.Noticef("%s belongs to archive in torrent %s", name, t.Name())
+			return NewTorrentFSEntry(NewArchiveFile(tfs, t, a), tfs, t, a.File, name)
+		}
+	}
+
 	if tfs.s.config.DownloadStorage == StorageFile {
 		file, err = os.Open(filepath.Join(string(tfs.Dir), name))
 		if err != nil {
@@ -109,6 +122,8 @@ func NewTorrentFSEntry(file http.File, tfs *TorrentFS, t *Torrent, f *File, name
 		t:    t,
 		f:    f,
 
+		archive: t.GetArchive(f),
+
 		totalLength: t.ti.TotalSize(),
 		pieceLength: t.ti.PieceLength(),
 		numPieces:   t.ti.NumPieces(),
@@ -189,12 +204,18 @@ func (tf *TorrentFSEntry) Read(data []byte) (n int, err error) {
 		if pieceOffset+size > tf.pieceLength {
 			size = tf.pieceLength - pieceOffset
 		}
+		// Archived file continues in the next volume, which can be anywhere in torrent
+		if tf.archive != nil {
+			if left := tf.archive.ContiguousLeft(currentOffset); left > 0 && int64(size) > left {
+				size = int(left)
+			}
+		}
 
 		b := data[pos : pos+size]
 		n1 := 0
 
 		if tf.storageType == StorageMemory {
-			n1, err = tf.File.(*MemoryFile).ReadPiece(b, piece, pieceOffset)
+			n1, err = tf.File.(pieceReader).ReadPiece(b, piece, pieceOffset)
 		} else {
 			n1, err = tf.File.Read(b)
 		}
@@ -303,8 +324,8 @@ func (tf *TorrentFSEntry) pieceFromOffset(offset int64) (int, int) {
 		return 0, 0
 	}
 
-	piece := (tf.f.Offset + offset) / int64(tf.pieceLength)
-	pieceOffset := (tf.f.Offset + offset) % int64(tf.pieceLength)
+	piece := tf.torrentOffset(offset) / int64(tf.pieceLength)
+	pieceOffset := tf.torrentOffset(offset) % int64(tf.pieceLength)
 	return int(piece), int(pieceOffset)
 }
 
@@ -313,6 +334,12 @@ func (tf *TorrentFSEntry) ReaderPiecesRange() (ret PieceRange) {
 	pos, _ := tf.Pos()
 	ra := tf.Readahead()
 
+	if tf.archive != nil {
+		if left := tf.archive.ContiguousLeft(pos); left > 0 && ra > left {
+			ra = left
+		}
+	}
+
 	return tf.byteRegionPieces(tf.torrentOffset(pos), ra)
 }
 
@@ -337,6 +364,9 @@ func (tf *TorrentFSEntry) Pos() (int64, error) {
 }
 
 func (tf *TorrentFSEntry) torrentOffset(readerPos int64) int64 {
+	if tf.archive != nil {
+		return tf.archive.TorrentOffset(readerPos)
+	}
 	return tf.f.Offset + readerPos
 }
 
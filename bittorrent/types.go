@@ -138,6 +138,5 @@ const (
 )
 
 const (
-	storedResumeExpiration      = 60 * 60 * 60 * 24
 	storedWatchedFileExpiration = 60 * 60 * 60 * 24
 )
@@ -11,6 +11,7 @@ import (
 	"github.com/vmihailenco/msgpack"
 
 	"github.com/mrjdainc/da-inc/database"
+	"github.com/mrjdainc/da-inc/metrics"
 	"github.com/mrjdainc/da-inc/util"
 )
 
@@ -46,6 +47,8 @@ var (
 
 var dbStore *DBStore
 
+var requestsMetric = metrics.NewCounter("cache_requests_total", "Cache lookups by result.", "store", "result")
+
 // NewDBStore Returns instance of BoltDB backed cache store
 func NewDBStore() *DBStore {
 	if dbStore == nil {
@@ -89,6 +92,14 @@ func (c *DBStore) Replace(key string, value interface{}, expires time.Duration)
 
 // Get ...
 func (c *DBStore) Get(key string, value interface{}) (err error) {
+	defer func() {
+		if err == nil {
+			requestsMetric.Inc("db", "hit")
+		} else {
+			requestsMetric.Inc("db", "miss")
+		}
+	}()
+
 	data, errGet := c.db.GetBytes(database.CommonBucket, key)
 	if errGet != nil {
 		return errGet
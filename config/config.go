@@ -74,6 +74,10 @@ type Configuration struct {
 	SmartEpisodeStart          bool
 	SmartEpisodeMatch          bool
 	SmartEpisodeChoose         bool
+	SmartEpisodeSearch         bool
+	StallFallbackEnabled       bool
+	StallTimeout               int
+	StallMinSeeders            int
 	LibraryEnabled             bool
 	LibrarySyncEnabled         bool
 	LibrarySyncPlaybackEnabled bool
@@ -95,9 +99,15 @@ type Configuration struct {
 	BufferTimeout              int
 	BufferSize                 int
 	EndBufferSize              int
+	BufferDuration             int
+	ProbeMedia                 bool
 	KodiBufferSize             int
 	UploadRateLimit            int
 	DownloadRateLimit          int
+	AltUploadRateLimit         int
+	AltDownloadRateLimit       int
+	SchedulerEnabled           bool
+	SchedulerRules             string
 	AutoloadTorrents           bool
 	AutoloadTorrentsPaused     bool
 	LimitAfterBuffering        bool
@@ -105,12 +115,20 @@ type Configuration struct {
 	ConnTrackerLimit           int
 	ConnTrackerLimitAuto       bool
 	SessionSave                int
+	SessionStateSettings       bool
 
 	SeedForever        bool
 	ShareRatioLimit    int
 	SeedTimeRatioLimit int
 	SeedTimeLimit      int
 
+	MaxActiveDownloads int
+	MaxActiveSeeds     int
+
+	BlocklistEnabled bool
+	BlocklistSources string
+	BlocklistRefresh int
+
 	DisableUpload            bool
 	DisableDHT               bool
 	DisableTCP               bool
@@ -132,6 +150,9 @@ type Configuration struct {
 	LibtorrentProfile        int
 	MagnetTrackers           int
 	MagnetResolveTimeout     int
+	MagnetPrefetchEnabled    bool
+	MagnetPrefetchCount      int
+	MagnetPrefetchParallel   int
 	Scrobble                 bool
 
 	AutoScrapeEnabled        bool
@@ -141,6 +162,7 @@ type Configuration struct {
 	AutoScrapePerHours       int
 	AutoScrapeLimitMovies    int
 	AutoScrapeInterval       int
+	AutoScrapeProfile        int
 
 	TraktClientID                  string
 	TraktClientSecret              string
@@ -209,9 +231,31 @@ type Configuration struct {
 	ResolutionPreferenceMovies  int
 	ResolutionPreferenceShows   int
 	PercentageAdditionalSeeders int
+	QualityProfile              int
+	QualityProfileMovies        int
+	QualityProfileShows         int
 
 	CustomProviderTimeoutEnabled bool
 	CustomProviderTimeout        int
+	ProviderTimeoutsLimit        int
+	ProviderCooloff              int
+
+	TorznabEnabled   bool
+	TorznabEndpoints string
+
+	ExecutableProvidersEnabled     bool
+	ExecutableProviders            string
+	ExecutableProvidersConcurrency int
+
+	QBittorrentUsername string
+	QBittorrentPassword string
+
+	RSSEnabled  bool
+	RSSInterval int
+
+	HooksEnabled bool
+	HooksTimeout int
+	HooksRetries int
 
 	InternalDNSEnabled  bool
 	InternalDNSSkipIPv6 bool
@@ -270,6 +314,9 @@ var (
 
 		LocalHost string `help:"local host, default is '0.0.0.0'"`
 		LocalPort int    `help:"local port, default is '65220'"`
+
+		Headless   bool   `help:"run without Kodi, settings are taken from config file and DAINC_* environment variables"`
+		ConfigFile string `help:"path to YAML or TOML config file for headless mode"`
 	}{
 		RemoteHost: "127.0.0.1",
 		RemotePort: 65221,
@@ -295,8 +342,16 @@ func Reload() *Configuration {
 	xbmc.XBMCJSONRPCHosts = []string{net.JoinHostPort(Args.RemoteHost, "9090")}
 	xbmc.XBMCExJSONRPCHosts = []string{net.JoinHostPort(Args.RemoteHost, strconv.Itoa(Args.RemotePort))}
 
+	xbmc.Headless = Args.Headless
+	xbmc.HeadlessSetSetting = SetHeadlessSetting
+
 	defer func() {
 		if r := recover(); r != nil {
+			if Args.Headless {
+				log.Criticalf("Configuration is not properly set: %#v", r)
+				os.Exit(5)
+			}
+
 			log.Warningf("Addon settings not properly set, opening settings window: %#v", r)
 
 			message := "LOCALIZE[30314]"
@@ -314,41 +369,56 @@ func Reload() *Configuration {
 		}
 	}()
 
-	info := xbmc.GetAddonInfo()
-	if info == nil || info.ID == "" {
-		log.Warningf("Can't continue because addon info is empty")
-		settingsWarning = "LOCALIZE[30113]"
-		panic(settingsWarning)
-	}
-
-	info.Path = xbmc.TranslatePath(info.Path)
-	info.Profile = xbmc.TranslatePath(info.Profile)
-	info.Home = xbmc.TranslatePath(info.Home)
-	info.Xbmc = xbmc.TranslatePath(info.Xbmc)
-	info.TempPath = filepath.Join(xbmc.TranslatePath("special://temp"), "dainc")
-
-	platform := xbmc.GetPlatform()
+	var info *xbmc.AddonInfo
+	var platform *xbmc.Platform
 
-	// If it's Windows and it's installed from Store - we should try to find real path
-	// and change addon settings accordingly
-	if platform != nil && strings.ToLower(platform.OS) == "windows" && strings.Contains(info.Xbmc, "XBMCFoundation") {
-		path := findExistingPath([]string{
-			filepath.Join(os.Getenv("LOCALAPPDATA"), "/Packages/XBMCFoundation.Kodi_4n2hpmxwrvr6p/LocalCache/Roaming/Kodi/"),
-			filepath.Join(os.Getenv("APPDATA"), "/kodi/"),
-		}, "/userdata/addon_data/"+info.ID)
-
-		if path != "" {
-			info.Path = strings.Replace(info.Path, info.Home, "", 1)
-			info.Profile = strings.Replace(info.Profile, info.Home, "", 1)
-			info.TempPath = strings.Replace(info.TempPath, info.Home, "", 1)
-			info.Icon = strings.Replace(info.Icon, info.Home, "", 1)
+	headless = nil
+	if Args.Headless {
+		var err error
+		if headless, err = LoadHeadless(Args.ConfigFile); err != nil {
+			settingsWarning = err.Error()
+			panic(settingsWarning)
+		}
 
-			info.Path = filepath.Join(path, info.Path)
-			info.Profile = filepath.Join(path, info.Profile)
-			info.TempPath = filepath.Join(path, info.TempPath)
-			info.Icon = filepath.Join(path, info.Icon)
+		info = headless.AddonInfo()
+		platform = headless.Platform()
+	} else {
+		info = xbmc.GetAddonInfo()
+		if info == nil || info.ID == "" {
+			log.Warningf("Can't continue because addon info is empty")
+			settingsWarning = "LOCALIZE[30113]"
+			panic(settingsWarning)
+		}
 
-			info.Home = path
+		info.Path = xbmc.TranslatePath(info.Path)
+		info.Profile = xbmc.TranslatePath(info.Profile)
+		info.Home = xbmc.TranslatePath(info.Home)
+		info.Xbmc = xbmc.TranslatePath(info.Xbmc)
+		info.TempPath = filepath.Join(xbmc.TranslatePath("special://temp"), "dainc")
+
+		platform = xbmc.GetPlatform()
+
+		// If it's Windows and it's installed from Store - we should try to find real path
+		// and change addon settings accordingly
+		if platform != nil && strings.ToLower(platform.OS) == "windows" && strings.Contains(info.Xbmc, "XBMCFoundation") {
+			path := findExistingPath([]string{
+				filepath.Join(os.Getenv("LOCALAPPDATA"), "/Packages/XBMCFoundation.Kodi_4n2hpmxwrvr6p/LocalCache/Roaming/Kodi/"),
+				filepath.Join(os.Getenv("APPDATA"), "/kodi/"),
+			}, "/userdata/addon_data/"+info.ID)
+
+			if path != "" {
+				info.Path = strings.Replace(info.Path, info.Home, "", 1)
+				info.Profile = strings.Replace(info.Profile, info.Home, "", 1)
+				info.TempPath = strings.Replace(info.TempPath, info.Home, "", 1)
+				info.Icon = strings.Replace(info.Icon, info.Home, "", 1)
+
+				info.Path = filepath.Join(path, info.Path)
+				info.Profile = filepath.Join(path, info.Profile)
+				info.TempPath = filepath.Join(path, info.TempPath)
+				info.Icon = filepath.Join(path, info.Icon)
+
+				info.Home = path
+			}
 		}
 	}
 
@@ -379,16 +449,25 @@ func Reload() *Configuration {
 		}
 	}
 
-	downloadPath := TranslatePath(xbmc.GetSettingString("download_path"))
-	libraryPath := TranslatePath(xbmc.GetSettingString("library_path"))
-	torrentsPath := TranslatePath(xbmc.GetSettingString("torrents_path"))
-	downloadStorage := xbmc.GetSettingInt("download_storage")
+	downloadPath := TranslatePath(getSettingString("download_path"))
+	libraryPath := TranslatePath(getSettingString("library_path"))
+	torrentsPath := TranslatePath(getSettingString("torrents_path"))
+	downloadStorage := getSettingInt("download_storage")
 	if downloadStorage > 1 {
 		downloadStorage = 1
 	}
 
 	log.Noticef("Paths translated by Kodi: Download = %s , Library = %s , Torrents = %s , Storage = %d", downloadPath, libraryPath, torrentsPath, downloadStorage)
 
+	// Without Kodi nobody has created these folders for us
+	if headless != nil {
+		for _, p := range []string{downloadPath, libraryPath, torrentsPath} {
+			if p != "." {
+				os.MkdirAll(p, 0777)
+			}
+		}
+	}
+
 	if downloadStorage != 1 {
 		if downloadPath == "." {
 			log.Warningf("Can't continue because download path is empty")
@@ -436,7 +515,7 @@ func Reload() *Configuration {
 	}
 	log.Infof("Using torrents path: %s", torrentsPath)
 
-	xbmcSettings := xbmc.GetAllSettings()
+	xbmcSettings := getAllSettings()
 	settings := make(map[string]interface{})
 	for _, setting := range xbmcSettings {
 		switch setting.Type {
@@ -476,7 +555,7 @@ func Reload() *Configuration {
 		TorrentsPath:               torrentsPath,
 		Info:                       info,
 		Platform:                   platform,
-		Language:                   xbmc.GetLanguageISO639_1(),
+		Language:                   getLanguage(),
 		TemporaryPath:              info.TempPath,
 		ProfilePath:                info.Profile,
 		HomePath:                   info.Home,
@@ -494,8 +573,14 @@ func Reload() *Configuration {
 		BufferTimeout:              settings["buffer_timeout"].(int),
 		BufferSize:                 settings["buffer_size"].(int) * 1024 * 1024,
 		EndBufferSize:              settings["end_buffer_size"].(int) * 1024 * 1024,
+		BufferDuration:             settings["buffer_duration"].(int),
+		ProbeMedia:                 settings["probe_media"].(bool),
 		UploadRateLimit:            settings["max_upload_rate"].(int) * 1024,
 		DownloadRateLimit:          settings["max_download_rate"].(int) * 1024,
+		AltUploadRateLimit:         settings["alt_max_upload_rate"].(int) * 1024,
+		AltDownloadRateLimit:       settings["alt_max_download_rate"].(int) * 1024,
+		SchedulerEnabled:           settings["scheduler_enabled"].(bool),
+		SchedulerRules:             settings["scheduler_rules"].(string),
 		AutoloadTorrents:           settings["autoload_torrents"].(bool),
 		AutoloadTorrentsPaused:     settings["autoload_torrents_paused"].(bool),
 		SpoofUserAgent:             settings["spoof_user_agent"].(int),
@@ -535,6 +620,10 @@ func Reload() *Configuration {
 		SmartEpisodeStart:          settings["smart_episode_start"].(bool),
 		SmartEpisodeMatch:          settings["smart_episode_match"].(bool),
 		SmartEpisodeChoose:         settings["smart_episode_choose"].(bool),
+		SmartEpisodeSearch:         settings["smart_episode_search"].(bool),
+		StallFallbackEnabled:       settings["stall_fallback_enabled"].(bool),
+		StallTimeout:               settings["stall_timeout"].(int),
+		StallMinSeeders:            settings["stall_min_seeders"].(int),
 		LibraryEnabled:             settings["library_enabled"].(bool),
 		LibrarySyncEnabled:         settings["library_sync_enabled"].(bool),
 		LibrarySyncPlaybackEnabled: settings["library_sync_playback_enabled"].(bool),
@@ -546,6 +635,11 @@ func Reload() *Configuration {
 		ShareRatioLimit:            settings["share_ratio_limit"].(int),
 		SeedTimeRatioLimit:         settings["seed_time_ratio_limit"].(int),
 		SeedTimeLimit:              settings["seed_time_limit"].(int) * 3600,
+		MaxActiveDownloads:         settings["max_active_downloads"].(int),
+		MaxActiveSeeds:             settings["max_active_seeds"].(int),
+		BlocklistEnabled:           settings["blocklist_enabled"].(bool),
+		BlocklistSources:           settings["blocklist_sources"].(string),
+		BlocklistRefresh:           settings["blocklist_refresh"].(int),
 		DisableUpload:              settings["disable_upload"].(bool),
 		DisableDHT:                 settings["disable_dht"].(bool),
 		DisableTCP:                 settings["disable_tcp"].(bool),
@@ -567,10 +661,14 @@ func Reload() *Configuration {
 		LibtorrentProfile:          settings["libtorrent_profile"].(int),
 		MagnetTrackers:             settings["magnet_trackers"].(int),
 		MagnetResolveTimeout:       settings["magnet_resolve_timeout"].(int),
+		MagnetPrefetchEnabled:      settings["magnet_prefetch_enabled"].(bool),
+		MagnetPrefetchCount:        settings["magnet_prefetch_count"].(int),
+		MagnetPrefetchParallel:     settings["magnet_prefetch_parallel"].(int),
 		ConnectionsLimit:           settings["connections_limit"].(int),
 		ConnTrackerLimit:           settings["conntracker_limit"].(int),
 		ConnTrackerLimitAuto:       settings["conntracker_limit_auto"].(bool),
 		SessionSave:                settings["session_save"].(int),
+		SessionStateSettings:       settings["session_state_settings"].(bool),
 		Scrobble:                   settings["trakt_scrobble"].(bool),
 
 		AutoScrapeEnabled:        settings["autoscrape_is_enabled"].(bool),
@@ -580,6 +678,7 @@ func Reload() *Configuration {
 		AutoScrapePerHours:       settings["autoscrape_per_hours"].(int),
 		AutoScrapeLimitMovies:    settings["autoscrape_limit_movies"].(int),
 		AutoScrapeInterval:       settings["autoscrape_interval"].(int),
+		AutoScrapeProfile:        settings["autoscrape_profile"].(int),
 
 		TraktClientID:                  settings["trakt_client_id"].(string),
 		TraktClientSecret:              settings["trakt_client_secret"].(string),
@@ -648,9 +747,31 @@ func Reload() *Configuration {
 		ResolutionPreferenceMovies:  settings["resolution_preference_movies"].(int),
 		ResolutionPreferenceShows:   settings["resolution_preference_shows"].(int),
 		PercentageAdditionalSeeders: settings["percentage_additional_seeders"].(int),
+		QualityProfile:              settings["quality_profile"].(int),
+		QualityProfileMovies:        settings["quality_profile_movies"].(int),
+		QualityProfileShows:         settings["quality_profile_shows"].(int),
 
 		CustomProviderTimeoutEnabled: settings["custom_provider_timeout_enabled"].(bool),
 		CustomProviderTimeout:        settings["custom_provider_timeout"].(int),
+		ProviderTimeoutsLimit:        settings["provider_timeouts_limit"].(int),
+		ProviderCooloff:              settings["provider_cooloff"].(int),
+
+		TorznabEnabled:   settings["torznab_enabled"].(bool),
+		TorznabEndpoints: settings["torznab_endpoints"].(string),
+
+		ExecutableProvidersEnabled:     settings["executable_providers_enabled"].(bool),
+		ExecutableProviders:            settings["executable_providers"].(string),
+		ExecutableProvidersConcurrency: settings["executable_providers_concurrency"].(int),
+
+		QBittorrentUsername: settings["qbittorrent_username"].(string),
+		QBittorrentPassword: settings["qbittorrent_password"].(string),
+
+		RSSEnabled:  settings["rss_enabled"].(bool),
+		RSSInterval: settings["rss_interval"].(int),
+
+		HooksEnabled: settings["hooks_enabled"].(bool),
+		HooksTimeout: settings["hooks_timeout"].(int),
+		HooksRetries: settings["hooks_retries"].(int),
 
 		InternalDNSEnabled:  settings["internal_dns_enabled"].(bool),
 		InternalDNSSkipIPv6: settings["internal_dns_skip_ipv6"].(bool),
@@ -787,7 +908,9 @@ func Reload() *Configuration {
 	lock.Lock()
 	config = &newConfig
 	lock.Unlock()
-	go CheckBurst()
+	if headless == nil {
+		go CheckBurst()
+	}
 
 	// Replacing passwords with asterisks
 	configOutput := litter.Sdump(config)
@@ -810,6 +933,10 @@ func AddonResource(args ...string) string {
 
 // TranslatePath ...
 func TranslatePath(path string) string {
+	if headless != nil {
+		return expandPath(path)
+	}
+
 	// Special case for temporary path in Kodi
 	if strings.HasPrefix(path, "special://temp/") {
 		dir := strings.Replace(path, "special://temp/", "", 1)
@@ -931,6 +1058,10 @@ func findExistingPath(paths []string, addon string) string {
 }
 
 func getKodiBufferSize() int {
+	if headless != nil {
+		return 0
+	}
+
 	xmlFile, err := os.Open(filepath.Join(xbmc.TranslatePath("special://userdata"), "advancedsettings.xml"))
 	if err != nil {
 		return 0
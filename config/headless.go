@@ -0,0 +1,535 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/BurntSushi/toml"
+	"github.com/mrjdainc/da-inc/xbmc"
+
+	"gopkg.in/yaml.v2"
+)
+
+const headlessEnvPrefix = "DAINC_"
+
+// HeadlessConfig describes configuration file, used to run without Kodi.
+// Settings use the same keys as addon settings in Kodi.
+type HeadlessConfig struct {
+	AddonPath   string                 `yaml:"addon_path,omitempty" toml:"addon_path,omitempty"`
+	ProfilePath string                 `yaml:"profile_path,omitempty" toml:"profile_path,omitempty"`
+	Language    string                 `yaml:"language,omitempty" toml:"language,omitempty"`
+	Settings    map[string]interface{} `yaml:"settings" toml:"settings"`
+
+	fileName string
+}
+
+var headless *HeadlessConfig
+
+var (
+	headlessMu sync.Mutex
+	// headlessSaved keeps settings, changed by the daemon itself, like refreshed Trakt tokens,
+	// they win over the file and environment variables until restart
+	headlessSaved = map[string]interface{}{}
+)
+
+// headlessDefaults are taken from addon's resources/settings.xml,
+// type of default value is used to convert values from file and environment.
+var headlessDefaults = map[string]interface{}{
+	"download_path":                      "~/dainc/downloads",
+	"library_path":                       "~/dainc/library",
+	"torrents_path":                      "~/dainc/torrents",
+	"download_storage":                   0,
+	"skip_burst_search":                  true,
+	"auto_memory_size":                   true,
+	"auto_adjust_memory_size":            true,
+	"auto_memory_size_strategy":          1,
+	"memory_size":                        100,
+	"auto_kodi_buffer_size":              false,
+	"auto_adjust_buffer_size":            true,
+	"min_candidate_size":                 100,
+	"min_candidate_show_size":            20,
+	"buffer_timeout":                     60,
+	"buffer_size":                        20,
+	"end_buffer_size":                    4,
+	"buffer_duration":                    0,
+	"probe_media":                        true,
+	"max_upload_rate":                    0,
+	"max_download_rate":                  0,
+	"alt_max_upload_rate":                50,
+	"alt_max_download_rate":              500,
+	"scheduler_enabled":                  false,
+	"scheduler_rules":                    "",
+	"autoload_torrents":                  true,
+	"autoload_torrents_paused":           false,
+	"spoof_user_agent":                   0,
+	"limit_after_buffering":              false,
+	"keep_downloading":                   0,
+	"keep_files_playing":                 0,
+	"keep_files_finished":                0,
+	"use_torrent_history":                true,
+	"torrent_history_size":               50,
+	"use_fanart_tv":                      true,
+	"disable_bg_progress":                true,
+	"disable_bg_progress_playback":       true,
+	"force_use_trakt":                    false,
+	"use_cache_selection":                true,
+	"use_cache_search":                   true,
+	"use_cache_torrents":                 true,
+	"cache_search_duration":              6,
+	"results_per_page":                   20,
+	"show_files_watched":                 true,
+	"greeting_enabled":                   false,
+	"enable_overlay_status":              false,
+	"silent_stream_start":                true,
+	"autoyes_enabled":                    true,
+	"autoyes_timeout":                    0,
+	"choose_stream_auto":                 true,
+	"force_link_type":                    false,
+	"use_original_title":                 false,
+	"use_anime_en_title":                 true,
+	"use_lowest_release_date":            true,
+	"add_specials":                       false,
+	"add_episode_numbers":                true,
+	"unaired_seasons":                    true,
+	"unaired_episodes":                   true,
+	"seasons_all":                        false,
+	"seasons_order":                      0,
+	"playback_percent":                   90,
+	"smart_episode_start":                true,
+	"smart_episode_match":                true,
+	"smart_episode_choose":               true,
+	"smart_episode_search":               true,
+	"stall_fallback_enabled":             true,
+	"stall_timeout":                      60,
+	"stall_min_seeders":                  0,
+	"library_enabled":                    true,
+	"library_sync_enabled":               true,
+	"library_sync_playback_enabled":      false,
+	"library_update":                     0,
+	"strm_language":                      "",
+	"library_nfo_movies":                 true,
+	"library_nfo_shows":                  true,
+	"seed_forever":                       false,
+	"share_ratio_limit":                  200,
+	"seed_time_ratio_limit":              0,
+	"seed_time_limit":                    24,
+	"max_active_downloads":               3,
+	"max_active_seeds":                   5,
+	"blocklist_enabled":                  false,
+	"blocklist_sources":                  "",
+	"blocklist_refresh":                  24,
+	"disable_upload":                     false,
+	"disable_dht":                        false,
+	"disable_tcp":                        false,
+	"disable_utp":                        false,
+	"disable_upnp":                       false,
+	"encryption_policy":                  0,
+	"listen_port_min":                    6891,
+	"listen_port_max":                    6899,
+	"listen_interfaces":                  "",
+	"listen_autodetect_ip":               true,
+	"listen_autodetect_port":             true,
+	"outgoing_interfaces":                "",
+	"tuned_storage":                      false,
+	"disk_cache_size":                    12,
+	"use_libtorrent_config":              false,
+	"use_libtorrent_logging":             false,
+	"use_libtorrent_deadline":            true,
+	"use_libtorrent_pauseresume":         true,
+	"libtorrent_profile":                 0,
+	"magnet_trackers":                    0,
+	"magnet_resolve_timeout":             60,
+	"magnet_prefetch_enabled":            false,
+	"magnet_prefetch_count":              10,
+	"magnet_prefetch_parallel":           5,
+	"connections_limit":                  0,
+	"conntracker_limit":                  0,
+	"conntracker_limit_auto":             true,
+	"session_save":                       10,
+	"session_state_settings":             false,
+	"trakt_scrobble":                     false,
+	"autoscrape_is_enabled":              false,
+	"autoscrape_library_enabled":         false,
+	"autoscrape_strategy":                0,
+	"autoscrape_strategy_expect":         0,
+	"autoscrape_per_hours":               24,
+	"autoscrape_limit_movies":            30,
+	"autoscrape_interval":                10,
+	"autoscrape_profile":                 0,
+	"trakt_client_id":                    "",
+	"trakt_client_secret":                "",
+	"trakt_username":                     "",
+	"trakt_token":                        "",
+	"trakt_refresh_token":                "",
+	"trakt_token_expiry":                 0,
+	"trakt_sync_enabled":                 false,
+	"trakt_sync_playback_enabled":        false,
+	"trakt_sync_frequency_min":           5,
+	"trakt_sync_collections":             true,
+	"trakt_sync_watchlist":               true,
+	"trakt_sync_userlists":               true,
+	"trakt_sync_playback_progress":       true,
+	"trakt_sync_hidden":                  true,
+	"trakt_sync_watched":                 true,
+	"trakt_sync_watched_single":          false,
+	"trakt_sync_watchedback":             false,
+	"trakt_sync_added_movies":            false,
+	"trakt_sync_added_movies_location":   0,
+	"trakt_sync_added_movies_list":       0,
+	"trakt_sync_added_shows":             false,
+	"trakt_sync_added_shows_location":    0,
+	"trakt_sync_added_shows_list":        0,
+	"trakt_sync_removed_movies":          false,
+	"trakt_sync_removed_movies_location": 0,
+	"trakt_sync_removed_movies_list":     0,
+	"trakt_sync_removed_shows":           false,
+	"trakt_sync_removed_shows_location":  0,
+	"trakt_sync_removed_shows_list":      0,
+	"trakt_progress_unaired":             false,
+	"trakt_progress_sort":                0,
+	"trakt_progress_date_format":         "%Y-%m-%d",
+	"trakt_progress_color_date":          "none",
+	"trakt_progress_color_show":          "none",
+	"trakt_progress_color_episode":       "none",
+	"trakt_progress_color_unaired":       "none",
+	"trakt_calendars_date_format":        "%Y-%m-%d",
+	"trakt_calendars_color_date":         "none",
+	"trakt_calendars_color_show":         "none",
+	"trakt_calendars_color_episode":      "none",
+	"trakt_calendars_color_unaired":      "none",
+	"library_update_frequency":           2,
+	"library_update_delay":               0,
+	"library_auto_scan":                  false,
+	"play_resume":                        true,
+	"play_resume_back":                   5,
+	"store_resume":                       true,
+	"store_resume_action":                2,
+	"tmdb_api_key":                       "",
+	"osdb_user":                          "",
+	"osdb_pass":                          "",
+	"osdb_language":                      "",
+	"osdb_auto_language":                 true,
+	"osdb_auto_load":                     false,
+	"osdb_auto_load_count":               3,
+	"osdb_auto_load_delete":              false,
+	"osdb_auto_load_skipexists":          true,
+	"osdb_included_enabled":              false,
+	"osdb_included_skipexists":           false,
+	"sorting_mode_movies":                0,
+	"sorting_mode_shows":                 0,
+	"resolution_preference_movies":       0,
+	"resolution_preference_shows":        0,
+	"percentage_additional_seeders":      10,
+	"quality_profile":                    0,
+	"quality_profile_movies":             0,
+	"quality_profile_shows":              0,
+	"custom_provider_timeout_enabled":    false,
+	"custom_provider_timeout":            30,
+	"provider_timeouts_limit":            3,
+	"provider_cooloff":                   30,
+	"torznab_enabled":                    false,
+	"torznab_endpoints":                  "",
+	"executable_providers_enabled":       false,
+	"executable_providers":               "",
+	"executable_providers_concurrency":   4,
+	"qbittorrent_username":               "",
+	"qbittorrent_password":               "",
+	"rss_enabled":                        false,
+	"rss_interval":                       15,
+	"hooks_enabled":                      false,
+	"hooks_timeout":                      30,
+	"hooks_retries":                      2,
+	"internal_dns_enabled":               false,
+	"internal_dns_skip_ipv6":             true,
+	"internal_proxy_enabled":             false,
+	"internal_proxy_logging":             false,
+	"internal_proxy_logging_body":        false,
+	"antizapret_enabled":                 false,
+	"proxy_type":                         0,
+	"proxy_enabled":                      false,
+	"proxy_host":                         "",
+	"proxy_port":                         0,
+	"proxy_login":                        "",
+	"proxy_password":                     "",
+	"use_proxy_http":                     false,
+	"use_proxy_tracker":                  false,
+	"use_proxy_download":                 false,
+	"completed_move":                     false,
+	"completed_movies_path":              "",
+	"completed_shows_path":               "",
+	"local_only_client":                  false,
+}
+
+// LoadHeadless reads configuration file and environment variables,
+// environment variables are named as DAINC_<SETTING KEY>, like DAINC_DOWNLOAD_PATH.
+// Configuration file is read as TOML if it has .toml extension, and as YAML otherwise.
+func LoadHeadless(fileName string) (*HeadlessConfig, error) {
+	ret := &HeadlessConfig{
+		Settings: map[string]interface{}{},
+	}
+
+	if fileName != "" {
+		ret.fileName = expandPath(fileName)
+		if err := readHeadlessFile(ret.fileName, ret); err != nil {
+			return nil, err
+		}
+	}
+
+	if v, ok := os.LookupEnv(headlessEnvPrefix + "ADDON_PATH"); ok {
+		ret.AddonPath = v
+	}
+	if v, ok := os.LookupEnv(headlessEnvPrefix + "PROFILE_PATH"); ok {
+		ret.ProfilePath = v
+	}
+	if v, ok := os.LookupEnv(headlessEnvPrefix + "LANGUAGE"); ok {
+		ret.Language = v
+	}
+	for _, env := range os.Environ() {
+		tokens := strings.SplitN(env, "=", 2)
+		if len(tokens) != 2 || !strings.HasPrefix(tokens[0], headlessEnvPrefix) {
+			continue
+		}
+
+		key := strings.ToLower(strings.TrimPrefix(tokens[0], headlessEnvPrefix))
+		if _, ok := headlessDefaults[key]; ok {
+			ret.Settings[key] = tokens[1]
+		}
+	}
+
+	headlessMu.Lock()
+	for key, v := range headlessSaved {
+		ret.Settings[key] = v
+	}
+	headlessMu.Unlock()
+
+	if ret.AddonPath == "" {
+		if exe, err := os.Executable(); err == nil {
+			ret.AddonPath = filepath.Dir(exe)
+		}
+	}
+	if ret.ProfilePath == "" {
+		ret.ProfilePath = "~/.dainc"
+	}
+	if ret.Language == "" {
+		ret.Language = "en"
+	}
+
+	ret.AddonPath = expandPath(ret.AddonPath)
+	ret.ProfilePath = expandPath(ret.ProfilePath)
+
+	return ret, nil
+}
+
+func isTOML(fileName string) bool {
+	return strings.EqualFold(filepath.Ext(fileName), ".toml")
+}
+
+func readHeadlessFile(fileName string, h *HeadlessConfig) error {
+	b, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return fmt.Errorf("Could not read configuration file %s: %s", fileName, err)
+	}
+
+	if isTOML(fileName) {
+		err = toml.Unmarshal(b, h)
+	} else {
+		err = yaml.Unmarshal(b, h)
+	}
+	if err != nil {
+		return fmt.Errorf("Could not parse configuration file %s: %s", fileName, err)
+	}
+
+	if h.Settings == nil {
+		h.Settings = map[string]interface{}{}
+	}
+	return nil
+}
+
+func writeHeadlessFile(fileName string, h *HeadlessConfig) error {
+	var b []byte
+	if isTOML(fileName) {
+		buf := &bytes.Buffer{}
+		if err := toml.NewEncoder(buf).Encode(h); err != nil {
+			return err
+		}
+		b = buf.Bytes()
+	} else {
+		var err error
+		if b, err = yaml.Marshal(h); err != nil {
+			return err
+		}
+	}
+
+	tmpPath := fileName + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, b, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, fileName)
+}
+
+// SetHeadlessSetting changes a setting at runtime, as Kodi does for addon settings,
+// and saves it to the configuration file, if daemon was started with one.
+func SetHeadlessSetting(key string, value interface{}) error {
+	headlessMu.Lock()
+	defer headlessMu.Unlock()
+
+	headlessSaved[key] = value
+	if headless == nil {
+		return nil
+	}
+	headless.Settings[key] = value
+
+	if headless.fileName == "" {
+		return nil
+	}
+
+	// File is read again, so values from environment variables are not written into it
+	file := &HeadlessConfig{}
+	if err := readHeadlessFile(headless.fileName, file); err != nil {
+		return err
+	}
+	file.Settings[key] = value
+
+	if err := writeHeadlessFile(headless.fileName, file); err != nil {
+		return fmt.Errorf("Could not save configuration file %s: %s", headless.fileName, err)
+	}
+	return nil
+}
+
+// AddonInfo returns addon information, as Kodi would return for installed addon.
+func (h *HeadlessConfig) AddonInfo() *xbmc.AddonInfo {
+	return &xbmc.AddonInfo{
+		ID:       "plugin.video.dainc",
+		Name:     "dainc",
+		Version:  "headless",
+		Path:     h.AddonPath,
+		Profile:  h.ProfilePath,
+		Home:     h.ProfilePath,
+		Icon:     filepath.Join(h.AddonPath, "icon.png"),
+		TempPath: filepath.Join(os.TempDir(), "dainc"),
+	}
+}
+
+// Platform returns current platform, Kodi version is left empty.
+func (h *HeadlessConfig) Platform() *xbmc.Platform {
+	return &xbmc.Platform{
+		OS:   runtime.GOOS,
+		Arch: runtime.GOARCH,
+	}
+}
+
+// Setting returns string value of a setting
+func (h *HeadlessConfig) Setting(key string) string {
+	if v, ok := h.Settings[key]; ok {
+		return settingToString(v)
+	} else if v, ok := headlessDefaults[key]; ok {
+		return settingToString(v)
+	}
+
+	return ""
+}
+
+// AllSettings returns all settings in the same way Kodi returns them,
+// with types taken from default values.
+func (h *HeadlessConfig) AllSettings() []*xbmc.Setting {
+	ret := make([]*xbmc.Setting, 0, len(headlessDefaults))
+	for key, def := range headlessDefaults {
+		ret = append(ret, &xbmc.Setting{
+			Key:   key,
+			Type:  settingType(def),
+			Value: h.Setting(key),
+		})
+	}
+	for key, v := range h.Settings {
+		if _, ok := headlessDefaults[key]; ok {
+			continue
+		}
+
+		log.Warningf("Unknown setting '%s' in headless configuration", key)
+		ret = append(ret, &xbmc.Setting{
+			Key:   key,
+			Type:  settingType(v),
+			Value: settingToString(v),
+		})
+	}
+
+	return ret
+}
+
+func settingType(v interface{}) string {
+	switch v.(type) {
+	case bool:
+		return "bool"
+	case int, float64:
+		return "number"
+	default:
+		return "text"
+	}
+}
+
+func settingToString(v interface{}) string {
+	switch s := v.(type) {
+	case float64:
+		return strconv.Itoa(int(s))
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(s)
+	}
+}
+
+// expandPath resolves environment variables, home directory
+// and Kodi's temporary directory in a path.
+func expandPath(path string) string {
+	if path == "" || path == "." {
+		return path
+	}
+
+	path = os.ExpandEnv(path)
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, strings.TrimPrefix(path, "~"))
+		}
+	}
+	if strings.HasPrefix(path, "special://temp") {
+		path = filepath.Join(os.TempDir(), strings.TrimPrefix(path, "special://temp"))
+	}
+
+	return filepath.Clean(path)
+}
+
+func getSettingString(key string) string {
+	if headless != nil {
+		return headless.Setting(key)
+	}
+	return xbmc.GetSettingString(key)
+}
+
+func getSettingInt(key string) int {
+	if headless != nil {
+		i, _ := strconv.Atoi(headless.Setting(key))
+		return i
+	}
+	return xbmc.GetSettingInt(key)
+}
+
+func getAllSettings() []*xbmc.Setting {
+	if headless != nil {
+		return headless.AllSettings()
+	}
+	return xbmc.GetAllSettings()
+}
+
+func getLanguage() string {
+	if headless != nil {
+		return headless.Language
+	}
+	return xbmc.GetLanguageISO639_1()
+}
@@ -172,6 +172,28 @@ func (d *StormDatabase) CleanupTorrentLink(infoHash string) {
 	}
 }
 
+// GetTorrentMetadata returns stored metadata of the torrent, or nil
+func (d *StormDatabase) GetTorrentMetadata(infoHash string) []byte {
+	var tm TorrentAssignMetadata
+	if err := d.db.One("InfoHash", infoHash, &tm); err != nil {
+		return nil
+	}
+	return tm.Metadata
+}
+
+// SaveTorrentMetadata stores metadata of the torrent, if it is not stored yet
+func (d *StormDatabase) SaveTorrentMetadata(infoHash string, b []byte) error {
+	var tm TorrentAssignMetadata
+	if err := d.db.One("InfoHash", infoHash, &tm); err == nil {
+		return nil
+	}
+
+	return d.db.Save(&TorrentAssignMetadata{
+		InfoHash: infoHash,
+		Metadata: b,
+	})
+}
+
 // AddTorrentLink saves link between torrent file and tmdbID entry
 func (d *StormDatabase) AddTorrentLink(tmdbID, infoHash string, b []byte) {
 	// Dummy check if infohash is real
@@ -254,6 +276,8 @@ func (d *StormDatabase) UpdateBTItem(infoHash string, mediaID int, mediaType str
 
 	var oldItem BTItem
 	if err := d.db.One("InfoHash", infoHash, &oldItem); err == nil {
+		item.StallTimeout = oldItem.StallTimeout
+
 		d.db.DeleteStruct(&oldItem)
 	}
 	if err := d.db.Save(&item); err != nil {
@@ -274,6 +298,17 @@ func (d *StormDatabase) UpdateBTItemFiles(infoHash string, files []string) error
 	return d.db.Update(&item)
 }
 
+// UpdateBTItemStallTimeout saves stall timeout of a saved torrent
+func (d *StormDatabase) UpdateBTItemStallTimeout(infoHash string, timeout int) error {
+	item := BTItem{}
+	if err := d.db.One("InfoHash", infoHash, &item); err != nil {
+		return err
+	}
+
+	// Update skips zero values, while zero restores the default timeout
+	return d.db.UpdateField(&item, "StallTimeout", timeout)
+}
+
 // DeleteBTItem ...
 func (d *StormDatabase) DeleteBTItem(infoHash string) error {
 	return d.db.Delete(BTItemBucket, infoHash)
@@ -316,3 +351,408 @@ func (d *StormDatabase) AddTorrentHistory(infoHash, name string, b []byte) {
 	}
 	d.db.ReIndex(&TorrentHistory{})
 }
+
+// Torrent categories handlers
+
+// GetTorrentCategories ...
+func (d *StormDatabase) GetTorrentCategories() []TorrentCategory {
+	var categories []TorrentCategory
+	d.db.All(&categories)
+	return categories
+}
+
+// AddTorrentCategory creates or updates category
+func (d *StormDatabase) AddTorrentCategory(name string) error {
+	return d.db.Save(&TorrentCategory{
+		Name: name,
+	})
+}
+
+// DeleteTorrentCategory removes category and unlinks all torrents from it
+func (d *StormDatabase) DeleteTorrentCategory(name string) error {
+	var items []TorrentCategoryItem
+	d.db.Find("Category", name, &items)
+	for _, item := range items {
+		d.db.DeleteStruct(&item)
+	}
+
+	return d.db.DeleteStruct(&TorrentCategory{Name: name})
+}
+
+// GetTorrentCategory returns category name for a torrent
+func (d *StormDatabase) GetTorrentCategory(infoHash string) string {
+	var item TorrentCategoryItem
+	if err := d.db.One("InfoHash", infoHash, &item); err != nil {
+		return ""
+	}
+
+	return item.Category
+}
+
+// SetTorrentCategory links torrent with a category, empty category removes the link
+func (d *StormDatabase) SetTorrentCategory(infoHash, category string) error {
+	if category == "" {
+		return d.db.DeleteStruct(&TorrentCategoryItem{InfoHash: infoHash})
+	}
+
+	return d.db.Save(&TorrentCategoryItem{
+		InfoHash: infoHash,
+		Category: category,
+	})
+}
+
+// Torrent queue handlers
+
+// GetTorrentQueueItem returns saved queue position and priority of a torrent
+func (d *StormDatabase) GetTorrentQueueItem(infoHash string) *TorrentQueueItem {
+	var item TorrentQueueItem
+	if err := d.db.One("InfoHash", infoHash, &item); err != nil {
+		return nil
+	}
+
+	return &item
+}
+
+// SaveTorrentQueueItem saves queue position and priority of a torrent
+func (d *StormDatabase) SaveTorrentQueueItem(infoHash string, position, priority int) error {
+	return d.db.Save(&TorrentQueueItem{
+		InfoHash: infoHash,
+		Position: position,
+		Priority: priority,
+	})
+}
+
+// DeleteTorrentQueueItem ...
+func (d *StormDatabase) DeleteTorrentQueueItem(infoHash string) error {
+	return d.db.DeleteStruct(&TorrentQueueItem{InfoHash: infoHash})
+}
+
+// RSS feeds handlers
+
+// GetRSSFeeds ...
+func (d *StormDatabase) GetRSSFeeds() []RSSFeed {
+	var feeds []RSSFeed
+	d.db.All(&feeds)
+	return feeds
+}
+
+// GetRSSFeed ...
+func (d *StormDatabase) GetRSSFeed(id int) *RSSFeed {
+	feed := &RSSFeed{}
+	if err := d.db.One("ID", id, feed); err != nil {
+		return nil
+	}
+
+	return feed
+}
+
+// SaveRSSFeed creates or updates feed
+func (d *StormDatabase) SaveRSSFeed(feed *RSSFeed) error {
+	return d.db.Save(feed)
+}
+
+// DeleteRSSFeed removes feed with its rules and seen items
+func (d *StormDatabase) DeleteRSSFeed(id int) error {
+	var rules []RSSRule
+	d.db.Find("FeedID", id, &rules)
+	for _, r := range rules {
+		d.db.DeleteStruct(&r)
+	}
+
+	var items []RSSSeenItem
+	d.db.Find("FeedID", id, &items)
+	for _, i := range items {
+		d.db.DeleteStruct(&i)
+	}
+
+	return d.db.DeleteStruct(&RSSFeed{ID: id})
+}
+
+// GetRSSRules returns rules for a feed, including common rules
+func (d *StormDatabase) GetRSSRules(feedID int) []RSSRule {
+	var rules []RSSRule
+	d.db.All(&rules)
+
+	ret := make([]RSSRule, 0, len(rules))
+	for _, r := range rules {
+		if feedID == 0 || r.FeedID == 0 || r.FeedID == feedID {
+			ret = append(ret, r)
+		}
+	}
+	return ret
+}
+
+// GetRSSRule ...
+func (d *StormDatabase) GetRSSRule(id int) *RSSRule {
+	rule := &RSSRule{}
+	if err := d.db.One("ID", id, rule); err != nil {
+		return nil
+	}
+
+	return rule
+}
+
+// SaveRSSRule creates or updates rule
+func (d *StormDatabase) SaveRSSRule(rule *RSSRule) error {
+	return d.db.Save(rule)
+}
+
+// DeleteRSSRule ...
+func (d *StormDatabase) DeleteRSSRule(id int) error {
+	return d.db.DeleteStruct(&RSSRule{ID: id})
+}
+
+// GetRSSSeenItem returns processed feed item, or nil
+func (d *StormDatabase) GetRSSSeenItem(guid string) *RSSSeenItem {
+	item := &RSSSeenItem{}
+	if err := d.db.One("GUID", guid, item); err != nil {
+		return nil
+	}
+
+	return item
+}
+
+// GetRSSSeenItems returns processed items of a feed
+func (d *StormDatabase) GetRSSSeenItems(feedID int) []RSSSeenItem {
+	var items []RSSSeenItem
+	d.db.Find("FeedID", feedID, &items)
+	return items
+}
+
+// MarkRSSItemSeen ...
+func (d *StormDatabase) MarkRSSItemSeen(guid string, feedID int) error {
+	return d.db.Save(&RSSSeenItem{
+		GUID:   guid,
+		FeedID: feedID,
+		Dt:     time.Now(),
+	})
+}
+
+// AddRSSItemFailure saves failed attempt to add the item and returns number of failures
+func (d *StormDatabase) AddRSSItemFailure(guid string, feedID int) int {
+	item := RSSSeenItem{GUID: guid, FeedID: feedID}
+	d.db.One("GUID", guid, &item)
+
+	item.Dt = time.Now()
+	item.Failures++
+	if err := d.db.Save(&item); err != nil {
+		log.Warningf("Could not save failure of RSS item %s: %s", guid, err)
+	}
+
+	return item.Failures
+}
+
+// DeleteRSSSeenItem ...
+func (d *StormDatabase) DeleteRSSSeenItem(guid string) error {
+	return d.db.DeleteStruct(&RSSSeenItem{GUID: guid})
+}
+
+// Hooks handlers
+
+// GetHooks returns all hooks, or hooks for an event if it is not empty
+func (d *StormDatabase) GetHooks(event string) []Hook {
+	var hooks []Hook
+	if event == "" {
+		d.db.All(&hooks)
+	} else {
+		d.db.Find("Event", event, &hooks)
+	}
+
+	return hooks
+}
+
+// GetHook ...
+func (d *StormDatabase) GetHook(id int) *Hook {
+	hook := &Hook{}
+	if err := d.db.One("ID", id, hook); err != nil {
+		return nil
+	}
+
+	return hook
+}
+
+// SaveHook creates or updates hook
+func (d *StormDatabase) SaveHook(hook *Hook) error {
+	return d.db.Save(hook)
+}
+
+// DeleteHook ...
+func (d *StormDatabase) DeleteHook(id int) error {
+	return d.db.DeleteStruct(&Hook{ID: id})
+}
+
+// Filter rules handlers
+
+// GetFilterRules ...
+func (d *StormDatabase) GetFilterRules() []FilterRule {
+	var rules []FilterRule
+	d.db.All(&rules)
+	return rules
+}
+
+// GetFilterRule ...
+func (d *StormDatabase) GetFilterRule(id int) *FilterRule {
+	rule := &FilterRule{}
+	if err := d.db.One("ID", id, rule); err != nil {
+		return nil
+	}
+
+	return rule
+}
+
+// SaveFilterRule creates or updates filter rule
+func (d *StormDatabase) SaveFilterRule(rule *FilterRule) error {
+	return d.db.Save(rule)
+}
+
+// DeleteFilterRule ...
+func (d *StormDatabase) DeleteFilterRule(id int) error {
+	return d.db.DeleteStruct(&FilterRule{ID: id})
+}
+
+// Quality profiles handlers
+
+// GetQualityProfiles ...
+func (d *StormDatabase) GetQualityProfiles() []QualityProfile {
+	var profiles []QualityProfile
+	d.db.All(&profiles)
+	return profiles
+}
+
+// GetQualityProfile ...
+func (d *StormDatabase) GetQualityProfile(id int) *QualityProfile {
+	profile := &QualityProfile{}
+	if err := d.db.One("ID", id, profile); err != nil {
+		return nil
+	}
+
+	return profile
+}
+
+// SaveQualityProfile creates or updates quality profile
+func (d *StormDatabase) SaveQualityProfile(profile *QualityProfile) error {
+	return d.db.Save(profile)
+}
+
+// DeleteQualityProfile removes profile and its show assignments
+func (d *StormDatabase) DeleteQualityProfile(id int) error {
+	d.db.Select(q.Eq("ProfileID", id)).Delete(&ShowQualityProfile{})
+	return d.db.DeleteStruct(&QualityProfile{ID: id})
+}
+
+// GetShowQualityProfile returns profile ID, assigned to the show, or 0
+func (d *StormDatabase) GetShowQualityProfile(showID int) int {
+	item := &ShowQualityProfile{}
+	if err := d.db.One("ShowID", showID, item); err != nil {
+		return 0
+	}
+
+	return item.ProfileID
+}
+
+// SetShowQualityProfile assigns profile to the show, 0 removes the assignment
+func (d *StormDatabase) SetShowQualityProfile(showID, profileID int) error {
+	if profileID == 0 {
+		return d.db.DeleteStruct(&ShowQualityProfile{ShowID: showID})
+	}
+
+	return d.db.Save(&ShowQualityProfile{ShowID: showID, ProfileID: profileID})
+}
+
+// Resume handlers
+
+// ResumeMovieID returns resume item ID for a movie
+func ResumeMovieID(tmdbID int) string {
+	return fmt.Sprintf("movie.%d", tmdbID)
+}
+
+// ResumeEpisodeID returns resume item ID for an episode
+func ResumeEpisodeID(showID, season, episode int) string {
+	return fmt.Sprintf("episode.%d.%d.%d", showID, season, episode)
+}
+
+// GetResumeItems returns in-progress items, last played go first
+func (d *StormDatabase) GetResumeItems() []ResumeItem {
+	var items []ResumeItem
+	d.db.AllByIndex("UpdatedAt", &items, storm.Reverse())
+	return items
+}
+
+// GetResumeItem ...
+func (d *StormDatabase) GetResumeItem(id string) *ResumeItem {
+	item := &ResumeItem{}
+	if err := d.db.One("ID", id, item); err != nil {
+		return nil
+	}
+
+	return item
+}
+
+// SaveResumeItem ...
+func (d *StormDatabase) SaveResumeItem(item *ResumeItem) error {
+	return d.db.Save(item)
+}
+
+// MergeResumeItem saves resume position only if it is newer than stored one,
+// torrent information of the stored item is kept
+func (d *StormDatabase) MergeResumeItem(item *ResumeItem) bool {
+	if stored := d.GetResumeItem(item.ID); stored != nil {
+		if !item.UpdatedAt.After(stored.UpdatedAt) {
+			return false
+		}
+		if item.InfoHash == "" {
+			item.InfoHash = stored.InfoHash
+			item.File = stored.File
+		}
+	}
+
+	return d.SaveResumeItem(item) == nil
+}
+
+// DeleteResumeItem ...
+func (d *StormDatabase) DeleteResumeItem(id string) error {
+	return d.db.DeleteStruct(&ResumeItem{ID: id})
+}
+
+// ClearResumeItems removes all stored resume positions
+func (d *StormDatabase) ClearResumeItems() error {
+	return d.db.Drop(&ResumeItem{})
+}
+
+// Provider stats handlers
+
+// GetProviderStats ...
+func (d *StormDatabase) GetProviderStats() []ProviderStats {
+	var stats []ProviderStats
+	d.db.All(&stats)
+	return stats
+}
+
+// GetProviderStat returns stats of a provider, empty ones if provider was never called
+func (d *StormDatabase) GetProviderStat(name string) *ProviderStats {
+	stats := &ProviderStats{}
+	if err := d.db.One("Name", name, stats); err != nil {
+		return &ProviderStats{Name: name}
+	}
+
+	return stats
+}
+
+// UpdateProviderStats changes provider's stats, updates are serialized,
+// as providers are called concurrently
+func (d *StormDatabase) UpdateProviderStats(name string, update func(*ProviderStats)) error {
+	providerStatsLock.Lock()
+	defer providerStatsLock.Unlock()
+
+	stats := d.GetProviderStat(name)
+	update(stats)
+	return d.db.Save(stats)
+}
+
+// ClearProviderStats removes stats of all providers
+func (d *StormDatabase) ClearProviderStats() error {
+	providerStatsLock.Lock()
+	defer providerStatsLock.Unlock()
+
+	return d.db.Drop(&ProviderStats{})
+}
@@ -57,6 +57,155 @@ type BTItem struct {
 	Season   int      `json:"season"`
 	Episode  int      `json:"episode"`
 	Query    string   `json:"query"`
+
+	StallTimeout int `json:"stallTimeout"`
+}
+
+// TorrentQueueItem keeps queue position and priority class of a torrent,
+// separately from BTItem, as not every torrent in the queue has one
+type TorrentQueueItem struct {
+	InfoHash string `storm:"id"`
+	Position int
+	Priority int
+}
+
+// TorrentCategory ...
+type TorrentCategory struct {
+	Name string `storm:"id"`
+}
+
+// TorrentCategoryItem links torrent with a category
+type TorrentCategoryItem struct {
+	InfoHash string `storm:"id"`
+	Category string `storm:"index"`
+}
+
+// RSSFeed ...
+type RSSFeed struct {
+	ID          int       `json:"id" storm:"id,increment"`
+	Name        string    `json:"name"`
+	URL         string    `json:"url" storm:"unique"`
+	Interval    int       `json:"interval"`
+	Enabled     bool      `json:"enabled"`
+	LastChecked time.Time `json:"last_checked"`
+	LastError   string    `json:"last_error"`
+}
+
+// RSSRule describes which feed items should be downloaded,
+// rules with empty FeedID are applied to all feeds
+type RSSRule struct {
+	ID         int    `json:"id" storm:"id,increment"`
+	FeedID     int    `json:"feed_id" storm:"index"`
+	Name       string `json:"name"`
+	Enabled    bool   `json:"enabled"`
+	Include    string `json:"include"`
+	Exclude    string `json:"exclude"`
+	MinSize    int64  `json:"min_size"`
+	MaxSize    int64  `json:"max_size"`
+	Resolution int    `json:"resolution"`
+	Paused     bool   `json:"paused"`
+	ShowID     int    `json:"show_id"`
+	Season     int    `json:"season"`
+	Episode    int    `json:"episode"`
+}
+
+// RSSSeenItem is a processed feed item, items with Failures are retried
+type RSSSeenItem struct {
+	GUID     string    `storm:"id"`
+	FeedID   int       `storm:"index"`
+	Dt       time.Time `storm:"index"`
+	Failures int
+}
+
+// Hook runs a command, or posts JSON payload to a URL, when event happens
+type Hook struct {
+	ID      int    `json:"id" storm:"id,increment"`
+	Name    string `json:"name"`
+	Event   string `json:"event" storm:"index"`
+	Enabled bool   `json:"enabled"`
+	Command string `json:"command"`
+	URL     string `json:"url"`
+	// Settings defaults are used, when these are not set
+	Timeout *int `json:"timeout,omitempty"`
+	Retries *int `json:"retries,omitempty"`
+}
+
+// QualityProfile describes which provider results are acceptable and how they are ranked,
+// sizes are in megabytes per runtime minute, terms are matched case-insensitively
+type QualityProfile struct {
+	ID                   int      `json:"id" storm:"id,increment"`
+	Name                 string   `json:"name"`
+	AllowedResolutions   []int    `json:"allowed_resolutions"`
+	PreferredResolutions []int    `json:"preferred_resolutions"`
+	MinSizePerMinute     float64  `json:"min_size_per_minute"`
+	MaxSizePerMinute     float64  `json:"max_size_per_minute"`
+	RequiredTerms        []string `json:"required_terms"`
+	PreferredTerms       []string `json:"preferred_terms"`
+	ForbiddenTerms       []string `json:"forbidden_terms"`
+	PreferredVideoCodecs []int    `json:"preferred_video_codecs"`
+	HDR                  int      `json:"hdr"`
+	RequiredLanguages    []string `json:"required_languages"`
+	MinSeeders           int64    `json:"min_seeders"`
+	PreferredSeeders     int64    `json:"preferred_seeders"`
+}
+
+// FilterRule drops or flags provider results, which break any of its conditions,
+// rules with empty MediaType are applied to all searches
+type FilterRule struct {
+	ID                int      `json:"id" storm:"id,increment"`
+	Name              string   `json:"name"`
+	Enabled           bool     `json:"enabled"`
+	Action            int      `json:"action"`
+	MediaType         string   `json:"media_type"`
+	Include           string   `json:"include"`
+	Exclude           string   `json:"exclude"`
+	MinSeeders        int64    `json:"min_seeders"`
+	MinSize           int64    `json:"min_size"`
+	MaxSize           int64    `json:"max_size"`
+	MinSizePerMinute  float64  `json:"min_size_per_minute"`
+	MaxSizePerMinute  float64  `json:"max_size_per_minute"`
+	BlockedProviders  []string `json:"blocked_providers"`
+	RequiredLanguages []string `json:"required_languages"`
+	BlockedCodecs     []int    `json:"blocked_codecs"`
+	BlockedRipTypes   []int    `json:"blocked_rip_types"`
+	PrivateOnly       bool     `json:"private_only"`
+}
+
+// ShowQualityProfile assigns a quality profile to a library show
+type ShowQualityProfile struct {
+	ShowID    int `json:"show_id" storm:"id"`
+	ProfileID int `json:"profile_id" storm:"index"`
+}
+
+// ResumeItem is a playback position of a movie or an episode,
+// torrent and file are kept to show where it was played from
+type ResumeItem struct {
+	ID        string    `json:"id" storm:"id"`
+	Type      string    `json:"type" storm:"index"`
+	TMDBId    int       `json:"tmdb_id"`
+	ShowID    int       `json:"show_id"`
+	Season    int       `json:"season"`
+	Episode   int       `json:"episode"`
+	Query     string    `json:"query,omitempty"`
+	InfoHash  string    `json:"infohash"`
+	File      string    `json:"file"`
+	Position  float64   `json:"position"`
+	Total     float64   `json:"total"`
+	UpdatedAt time.Time `json:"updated_at" storm:"index"`
+}
+
+// ProviderStats keeps health statistics of a provider, latencies are in milliseconds
+type ProviderStats struct {
+	Name          string    `json:"name" storm:"id"`
+	Calls         int       `json:"calls"`
+	Timeouts      int       `json:"timeouts"`
+	Errors        int       `json:"errors"`
+	Empty         int       `json:"empty"`
+	Distinct      int       `json:"distinct"`
+	Latencies     []int64   `json:"latencies"`
+	TimeoutsInRow int       `json:"timeouts_in_row"`
+	SkipUntil     time.Time `json:"skip_until"`
+	LastCall      time.Time `json:"last_call"`
 }
 
 // LibraryItem ...
@@ -114,6 +263,8 @@ var (
 	stormDatabase *StormDatabase
 
 	once sync.Once
+
+	providerStatsLock sync.Mutex
 )
 
 const (
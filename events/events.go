@@ -0,0 +1,122 @@
+package events
+
+import (
+	"sync"
+	"time"
+
+	"github.com/mrjdainc/da-inc/broadcast"
+)
+
+// Event types
+const (
+	TorrentAdded        = "torrent.added"
+	TorrentRemoved      = "torrent.removed"
+	TorrentStateChanged = "torrent.state_changed"
+	TorrentFinished     = "torrent.finished"
+
+	PlayerBuffering = "player.buffering"
+	PlayerStarted   = "player.started"
+	PlayerStopped   = "player.stopped"
+	PlayerSeeked    = "player.seeked"
+
+	LibraryRefreshStarted  = "library.refresh_started"
+	LibraryRefreshFinished = "library.refresh_finished"
+
+	TraktSyncStarted  = "trakt.sync_started"
+	TraktSyncProgress = "trakt.sync_progress"
+	TraktSyncFinished = "trakt.sync_finished"
+)
+
+// historySize is the number of latest events, kept for clients that reconnect
+const historySize = 500
+
+// Event is a message sent to subscribers, Data is one of payload types
+type Event struct {
+	ID   uint64      `json:"id"`
+	Type string      `json:"type"`
+	Time time.Time   `json:"time"`
+	Data interface{} `json:"data"`
+}
+
+// Torrent is a payload of torrent.* events
+type Torrent struct {
+	InfoHash  string `json:"infohash"`
+	Name      string `json:"name,omitempty"`
+	State     string `json:"state,omitempty"`
+	PrevState string `json:"prev_state,omitempty"`
+}
+
+// Player is a payload of player.* events
+type Player struct {
+	InfoHash    string  `json:"infohash"`
+	Name        string  `json:"name,omitempty"`
+	File        string  `json:"file,omitempty"`
+	ContentType string  `json:"content_type,omitempty"`
+	TMDBId      int     `json:"tmdb_id,omitempty"`
+	Progress    float64 `json:"progress"`
+	Position    float64 `json:"position"`
+	Duration    float64 `json:"duration"`
+}
+
+// Library is a payload of library.* events
+type Library struct {
+	Kind     string  `json:"kind"`
+	Duration float64 `json:"duration,omitempty"`
+}
+
+// TraktSync is a payload of trakt.* events
+type TraktSync struct {
+	Stage    string  `json:"stage,omitempty"`
+	Step     int     `json:"step"`
+	Total    int     `json:"total"`
+	Failed   bool    `json:"failed,omitempty"`
+	Duration float64 `json:"duration,omitempty"`
+}
+
+var (
+	mu          sync.Mutex
+	lastID      uint64
+	history     = make([]*Event, 0, historySize)
+	broadcaster = broadcast.NewBroadcaster()
+)
+
+// Publish sends event to all subscribers
+func Publish(eventType string, data interface{}) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	lastID++
+	e := &Event{
+		ID:   lastID,
+		Type: eventType,
+		Time: time.Now(),
+		Data: data,
+	}
+
+	if len(history) >= historySize {
+		history = append(history[:0], history[1:]...)
+	}
+	history = append(history, e)
+
+	broadcaster.Broadcast(e)
+}
+
+// Subscribe returns events, published after lastEventID, and channel with new events.
+// If lastEventID is unknown, for example after a restart, all kept events are returned.
+func Subscribe(lastEventID uint64) ([]*Event, <-chan interface{}, chan<- interface{}) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	missed := []*Event{}
+	if lastEventID > 0 {
+		for _, e := range history {
+			if e.ID > lastEventID || lastEventID > lastID {
+				missed = append(missed, e)
+			}
+		}
+	}
+
+	// Listening under the lock, so that no event is lost between history and channel
+	c, done := broadcaster.Listen()
+	return missed, c, done
+}
@@ -3,6 +3,7 @@ module github.com/mrjdainc/da-inc
 go 1.14
 
 require (
+	github.com/BurntSushi/toml v0.3.0
 	github.com/DataDog/zstd v1.4.5 // indirect
 	github.com/ElementumOrg/cfbypass v0.0.0-20200513191419-1dea11c3c007
 	github.com/ElementumOrg/libtorrent-go v0.0.0-20200519182336-afa516526fc9
@@ -54,4 +55,5 @@ require (
 	golang.org/x/sync v0.0.0-20200317015054-43a5402ce75a // indirect
 	golang.org/x/sys v0.0.0-20200519105757-fe76b779f299 // indirect
 	google.golang.org/appengine v1.6.6 // indirect
+	gopkg.in/yaml.v2 v2.2.8
 )
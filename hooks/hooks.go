@@ -0,0 +1,215 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/op/go-logging"
+
+	"github.com/mrjdainc/da-inc/config"
+	"github.com/mrjdainc/da-inc/database"
+	"github.com/mrjdainc/da-inc/util"
+)
+
+// Hook events
+const (
+	TorrentAdded     = "torrent_added"
+	MetadataReceived = "metadata_received"
+	DownloadFinished = "download_finished"
+	TorrentMoved     = "torrent_moved"
+	TorrentRemoved   = "torrent_removed"
+	PlaybackStarted  = "playback_started"
+	PlaybackStopped  = "playback_stopped"
+	PlaybackWatched  = "playback_watched"
+)
+
+const retryDelay = 5 * time.Second
+
+// Events lists all events hooks can be attached to
+var Events = []string{
+	TorrentAdded,
+	MetadataReceived,
+	DownloadFinished,
+	TorrentMoved,
+	TorrentRemoved,
+	PlaybackStarted,
+	PlaybackStopped,
+	PlaybackWatched,
+}
+
+var log = logging.MustGetLogger("hooks")
+
+// Payload is sent as JSON to webhooks, and as environment variables to commands
+type Payload struct {
+	Event     string    `json:"event"`
+	Time      time.Time `json:"time"`
+	InfoHash  string    `json:"infohash"`
+	Name      string    `json:"name"`
+	SavePath  string    `json:"save_path"`
+	Path      string    `json:"path,omitempty"`
+	MediaType string    `json:"media_type,omitempty"`
+	TMDBId    int       `json:"tmdb_id,omitempty"`
+	ShowID    int       `json:"show_id,omitempty"`
+	Season    int       `json:"season,omitempty"`
+	Episode   int       `json:"episode,omitempty"`
+}
+
+// FromBTItem fills media information from a database item
+func (p *Payload) FromBTItem(item *database.BTItem) *Payload {
+	if item == nil {
+		return p
+	}
+
+	p.MediaType = item.Type
+	p.TMDBId = item.ID
+	p.ShowID = item.ShowID
+	p.Season = item.Season
+	p.Episode = item.Episode
+	return p
+}
+
+// Env returns payload as environment variables
+func (p *Payload) Env() []string {
+	return []string{
+		"DAINC_EVENT=" + p.Event,
+		"DAINC_INFOHASH=" + p.InfoHash,
+		"DAINC_NAME=" + p.Name,
+		"DAINC_SAVE_PATH=" + p.SavePath,
+		"DAINC_PATH=" + p.Path,
+		"DAINC_MEDIA_TYPE=" + p.MediaType,
+		"DAINC_TMDB_ID=" + strconv.Itoa(p.TMDBId),
+		"DAINC_SHOW_ID=" + strconv.Itoa(p.ShowID),
+		"DAINC_SEASON=" + strconv.Itoa(p.Season),
+		"DAINC_EPISODE=" + strconv.Itoa(p.Episode),
+	}
+}
+
+// IsValidEvent ...
+func IsValidEvent(event string) bool {
+	for _, e := range Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Trigger runs all enabled hooks for the event in background
+func Trigger(event string, p *Payload) {
+	if !config.Get().HooksEnabled || p == nil {
+		return
+	}
+
+	p.Event = event
+	if p.Time.IsZero() {
+		p.Time = time.Now()
+	}
+
+	for _, h := range database.GetStorm().GetHooks(event) {
+		if !h.Enabled {
+			continue
+		}
+
+		go func(h database.Hook) {
+			if err := Run(&h, p); err != nil {
+				log.Warningf("Hook %s for %s failed: %s", h.Name, event, err)
+			}
+		}(h)
+	}
+}
+
+// Run executes hook with retries
+func Run(h *database.Hook, p *Payload) (err error) {
+	timeout := config.Get().HooksTimeout
+	if h.Timeout != nil {
+		timeout = *h.Timeout
+	}
+	retries := config.Get().HooksRetries
+	if h.Retries != nil {
+		retries = *h.Retries
+	}
+
+	// Command is not repeated, if only webhook is failing
+	commandDone := h.Command == ""
+	for attempt := 0; attempt <= retries; attempt++ {
+		if attempt > 0 {
+			log.Debugf("Retrying hook %s (%d/%d) after error: %s", h.Name, attempt, retries, err)
+			time.Sleep(time.Duration(attempt) * retryDelay)
+		}
+
+		err = nil
+		if !commandDone {
+			if err = runCommand(h.Command, p, time.Duration(timeout)*time.Second); err == nil {
+				commandDone = true
+			}
+		}
+		if err == nil && h.URL != "" {
+			err = postJSON(h.URL, p, time.Duration(timeout)*time.Second)
+		}
+		if err == nil {
+			log.Infof("Hook %s for %s finished", h.Name, p.Event)
+			return nil
+		}
+	}
+
+	return err
+}
+
+func runCommand(command string, p *Payload, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	cmd.Env = append(os.Environ(), p.Env()...)
+
+	log.Debugf("Running hook command: %s", command)
+	out, err := cmd.CombinedOutput()
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("Command timed out after %s", timeout)
+	} else if err != nil {
+		return fmt.Errorf("%s: %s", err, strings.TrimSpace(string(out)))
+	}
+
+	return nil
+}
+
+func postJSON(url string, p *Payload, timeout time.Duration) error {
+	body, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", util.DefaultUserAgent())
+
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("Bad status: %d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/karrick/godirwalk"
 
 	"github.com/mrjdainc/da-inc/config"
+	"github.com/mrjdainc/da-inc/events"
 	"github.com/mrjdainc/da-inc/playcount"
 	"github.com/mrjdainc/da-inc/tmdb"
 	"github.com/mrjdainc/da-inc/trakt"
@@ -65,6 +66,11 @@ func Refresh() error {
 	now := time.Now()
 	defer util.FreeMemoryGC()
 
+	events.Publish(events.LibraryRefreshStarted, &events.Library{Kind: "kodi"})
+	defer func() {
+		events.Publish(events.LibraryRefreshFinished, &events.Library{Kind: "kodi", Duration: time.Since(now).Seconds()})
+	}()
+
 	if err := RefreshMovies(); err != nil {
 		log.Debugf("RefreshMovies got an error: %v", err)
 	}
@@ -128,6 +134,7 @@ func RefreshMovies() error {
 		if m.Resume != nil {
 			lm.Resume.Position = m.Resume.Position
 			lm.Resume.Total = m.Resume.Total
+			lm.Resume.LastPlayed = m.LastPlayed.Time
 		}
 
 		l.Movies = append(l.Movies, lm)
@@ -378,6 +385,7 @@ func RefreshEpisodes() error {
 		if e.Resume != nil {
 			c.Episodes[len(c.Episodes)-1].Resume.Position = e.Resume.Position
 			c.Episodes[len(c.Episodes)-1].Resume.Total = e.Resume.Total
+			c.Episodes[len(c.Episodes)-1].Resume.LastPlayed = e.LastPlayed.Time
 		}
 	}
 	l.mu.Shows.Unlock()
@@ -816,9 +824,14 @@ func RefreshLocal() error {
 		return nil
 	}
 
+	now := time.Now()
+	events.Publish(events.LibraryRefreshStarted, &events.Library{Kind: "local"})
+
 	refreshLocalMovies()
 	refreshLocalShows()
 
+	events.Publish(events.LibraryRefreshFinished, &events.Library{Kind: "local", Duration: time.Since(now).Seconds()})
+
 	return nil
 }
 
@@ -8,6 +8,8 @@ import (
 	"github.com/cespare/xxhash"
 	"github.com/mrjdainc/da-inc/cache"
 	"github.com/mrjdainc/da-inc/config"
+	"github.com/mrjdainc/da-inc/database"
+	"github.com/mrjdainc/da-inc/events"
 	"github.com/mrjdainc/da-inc/tmdb"
 	"github.com/mrjdainc/da-inc/trakt"
 	"github.com/mrjdainc/da-inc/xbmc"
@@ -18,8 +20,27 @@ var (
 	IsTraktInitialized bool
 	isKodiAdded        bool
 	isKodiUpdated      bool
+
+	traktSyncStages = []string{
+		"movies.watched", "movies.collected", "movies.watchlisted", "movies.paused", "movies.hidden",
+		"episodes.watched", "episodes.collected", "episodes.watchlisted", "episodes.paused",
+		"shows.watchlisted", "shows.hidden",
+		"seasons.watchlisted", "seasons.hidden",
+		"lists",
+	}
 )
 
+func traktSyncProgress(stage string) {
+	step := 0
+	for i, s := range traktSyncStages {
+		if s == stage {
+			step = i + 1
+		}
+	}
+
+	events.Publish(events.TraktSyncProgress, &events.TraktSync{Stage: stage, Step: step, Total: len(traktSyncStages)})
+}
+
 // RefreshTrakt gets user activities from Trakt
 // to see if we need to add movies/set watched status and so on
 func RefreshTrakt() error {
@@ -64,6 +85,16 @@ func RefreshTrakt() error {
 		}
 	}()
 
+	events.Publish(events.TraktSyncStarted, &events.TraktSync{Total: len(traktSyncStages)})
+	defer func() {
+		events.Publish(events.TraktSyncFinished, &events.TraktSync{
+			Step:     len(traktSyncStages),
+			Total:    len(traktSyncStages),
+			Failed:   isErrored,
+			Duration: time.Since(started).Seconds(),
+		})
+	}()
+
 	if isFirstRun {
 		l.mu.Trakt.Lock()
 		l.WatchedTrakt = []uint64{}
@@ -76,26 +107,31 @@ func RefreshTrakt() error {
 
 	// Movies
 	if isFirstRun || isKodiAdded || lastActivities.Movies.WatchedAt.After(previousActivities.Movies.WatchedAt) {
+		traktSyncProgress("movies.watched")
 		if err := RefreshTraktWatched(MovieType, lastActivities.Movies.WatchedAt.After(previousActivities.Movies.WatchedAt)); err != nil {
 			isErrored = true
 		}
 	}
 	if isFirstRun || lastActivities.Movies.CollectedAt.After(previousActivities.Movies.CollectedAt) {
+		traktSyncProgress("movies.collected")
 		if err := RefreshTraktCollected(MovieType, lastActivities.Movies.CollectedAt.After(previousActivities.Movies.CollectedAt)); err != nil {
 			isErrored = true
 		}
 	}
 	if isFirstRun || lastActivities.Movies.WatchlistedAt.After(previousActivities.Movies.WatchlistedAt) {
+		traktSyncProgress("movies.watchlisted")
 		if err := RefreshTraktWatchlisted(MovieType, lastActivities.Movies.WatchlistedAt.After(previousActivities.Movies.WatchlistedAt)); err != nil {
 			isErrored = true
 		}
 	}
 	if isFirstRun || isKodiAdded || lastActivities.Movies.PausedAt.After(previousActivities.Movies.PausedAt) {
+		traktSyncProgress("movies.paused")
 		if err := RefreshTraktPaused(MovieType, lastActivities.Movies.PausedAt.After(previousActivities.Movies.PausedAt)); err != nil {
 			isErrored = true
 		}
 	}
 	if isFirstRun || lastActivities.Movies.HiddenAt.After(previousActivities.Movies.HiddenAt) {
+		traktSyncProgress("movies.hidden")
 		if err := RefreshTraktHidden(MovieType, lastActivities.Movies.HiddenAt.After(previousActivities.Movies.HiddenAt)); err != nil {
 			isErrored = true
 		}
@@ -103,21 +139,25 @@ func RefreshTrakt() error {
 
 	// Episodes
 	if isFirstRun || isKodiAdded || lastActivities.Episodes.WatchedAt.After(previousActivities.Episodes.WatchedAt) {
+		traktSyncProgress("episodes.watched")
 		if err := RefreshTraktWatched(EpisodeType, lastActivities.Episodes.WatchedAt.After(previousActivities.Episodes.WatchedAt)); err != nil {
 			isErrored = true
 		}
 	}
 	if isFirstRun || lastActivities.Episodes.CollectedAt.After(previousActivities.Episodes.CollectedAt) {
+		traktSyncProgress("episodes.collected")
 		if err := RefreshTraktCollected(EpisodeType, lastActivities.Episodes.CollectedAt.After(previousActivities.Episodes.CollectedAt)); err != nil {
 			isErrored = true
 		}
 	}
 	if isFirstRun || lastActivities.Episodes.WatchlistedAt.After(previousActivities.Episodes.WatchlistedAt) {
+		traktSyncProgress("episodes.watchlisted")
 		if err := RefreshTraktWatchlisted(EpisodeType, lastActivities.Episodes.WatchlistedAt.After(previousActivities.Episodes.WatchlistedAt)); err != nil {
 			isErrored = true
 		}
 	}
 	if isFirstRun || isKodiAdded || lastActivities.Episodes.PausedAt.After(previousActivities.Episodes.PausedAt) {
+		traktSyncProgress("episodes.paused")
 		if err := RefreshTraktPaused(EpisodeType, lastActivities.Episodes.PausedAt.After(previousActivities.Episodes.PausedAt)); err != nil {
 			isErrored = true
 		}
@@ -125,11 +165,13 @@ func RefreshTrakt() error {
 
 	// Shows
 	if isFirstRun || lastActivities.Shows.WatchlistedAt.After(previousActivities.Shows.WatchlistedAt) {
+		traktSyncProgress("shows.watchlisted")
 		if err := RefreshTraktWatchlisted(ShowType, lastActivities.Shows.WatchlistedAt.After(previousActivities.Shows.WatchlistedAt)); err != nil {
 			isErrored = true
 		}
 	}
 	if isFirstRun || lastActivities.Shows.HiddenAt.After(previousActivities.Shows.HiddenAt) {
+		traktSyncProgress("shows.hidden")
 		if err := RefreshTraktHidden(ShowType, lastActivities.Shows.HiddenAt.After(previousActivities.Shows.HiddenAt)); err != nil {
 			isErrored = true
 		}
@@ -137,12 +179,14 @@ func RefreshTrakt() error {
 
 	// Seasons
 	if isFirstRun || lastActivities.Seasons.WatchlistedAt.After(previousActivities.Seasons.WatchlistedAt) {
+		traktSyncProgress("seasons.watchlisted")
 		err := RefreshTraktWatchlisted(SeasonType, lastActivities.Seasons.WatchlistedAt.After(previousActivities.Seasons.WatchlistedAt))
 		if err != nil {
 			isErrored = true
 		}
 	}
 	if isFirstRun || lastActivities.Seasons.HiddenAt.After(previousActivities.Seasons.HiddenAt) {
+		traktSyncProgress("seasons.hidden")
 		err := RefreshTraktHidden(SeasonType, lastActivities.Seasons.HiddenAt.After(previousActivities.Seasons.HiddenAt))
 		if err != nil {
 			isErrored = true
@@ -151,6 +195,7 @@ func RefreshTrakt() error {
 
 	// Lists
 	if isFirstRun || lastActivities.Lists.UpdatedAt.After(previousActivities.Lists.UpdatedAt) {
+		traktSyncProgress("lists")
 		err := RefreshTraktLists(lastActivities.Lists.UpdatedAt.After(previousActivities.Lists.UpdatedAt))
 		if err != nil {
 			isErrored = true
@@ -616,13 +661,22 @@ func RefreshTraktPaused(itemType int, isRefreshNeeded bool) error {
 				continue
 			}
 
+			runtime := m.Movie.Runtime * 60
+			database.GetStorm().MergeResumeItem(&database.ResumeItem{
+				ID:        database.ResumeMovieID(m.Movie.IDs.TMDB),
+				Type:      "movie",
+				TMDBId:    m.Movie.IDs.TMDB,
+				Position:  float64(runtime) * float64(m.Progress) / 100,
+				Total:     float64(runtime),
+				UpdatedAt: m.PausedAt,
+			})
+
 			if lm, err := GetMovieByTMDB(m.Movie.IDs.TMDB); err == nil {
 				if t, ok := lastUpdates[m.Movie.IDs.Trakt]; ok && !t.Before(m.PausedAt) {
 					continue
 				}
 
 				lastUpdates[m.Movie.IDs.Trakt] = m.PausedAt
-				runtime := m.Movie.Runtime * 60
 
 				xbmc.SetMovieProgressWithDate(lm.UIDs.Kodi, runtime/100*int(m.Progress), runtime, m.PausedAt)
 			}
@@ -644,6 +698,18 @@ func RefreshTraktPaused(itemType int, isRefreshNeeded bool) error {
 				continue
 			}
 
+			runtime := s.Episode.Runtime * 60
+			database.GetStorm().MergeResumeItem(&database.ResumeItem{
+				ID:        database.ResumeEpisodeID(s.Show.IDs.TMDB, s.Episode.Season, s.Episode.Number),
+				Type:      "episode",
+				ShowID:    s.Show.IDs.TMDB,
+				Season:    s.Episode.Season,
+				Episode:   s.Episode.Number,
+				Position:  float64(runtime) * float64(s.Progress) / 100,
+				Total:     float64(runtime),
+				UpdatedAt: s.PausedAt,
+			})
+
 			if ls, err := GetShowByTMDB(s.Show.IDs.TMDB); err == nil {
 				e := ls.GetEpisode(s.Episode.Season, s.Episode.Number)
 				if e == nil {
@@ -653,7 +719,6 @@ func RefreshTraktPaused(itemType int, isRefreshNeeded bool) error {
 				}
 
 				lastUpdates[s.Episode.IDs.Trakt] = s.PausedAt
-				runtime := s.Episode.Runtime * 60
 
 				xbmc.SetEpisodeProgressWithDate(e.UIDs.Kodi, runtime/100*int(s.Progress), runtime, s.PausedAt)
 			}
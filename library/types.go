@@ -102,8 +102,9 @@ type Episode struct {
 
 // Resume shows watched progress information
 type Resume struct {
-	Position float64 `json:"position"`
-	Total    float64 `json:"total"`
+	Position   float64   `json:"position"`
+	Total      float64   `json:"total"`
+	LastPlayed time.Time `json:"last_played"`
 }
 
 // DBItem ...
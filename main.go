@@ -23,6 +23,9 @@ import (
 	"github.com/mrjdainc/da-inc/database"
 	"github.com/mrjdainc/da-inc/library"
 	"github.com/mrjdainc/da-inc/lockfile"
+	"github.com/mrjdainc/da-inc/metrics"
+	"github.com/mrjdainc/da-inc/providers"
+	"github.com/mrjdainc/da-inc/rss"
 	"github.com/mrjdainc/da-inc/scrape"
 	"github.com/mrjdainc/da-inc/trakt"
 	"github.com/mrjdainc/da-inc/util"
@@ -108,6 +111,7 @@ func main() {
 	}
 
 	s := bittorrent.NewService()
+	providers.SetService(s)
 
 	var shutdown = func(fromSignal bool) {
 		if s == nil || s.Closer.IsSet() {
@@ -118,6 +122,7 @@ func main() {
 
 		log.Info("Shutting down...")
 		library.CloseLibrary()
+		rss.Stop()
 		s.Close(true)
 
 		db.Close()
@@ -145,13 +150,17 @@ func main() {
 			time.Sleep(1 * time.Second)
 		}
 	}
-	go watchParentProcess()
+	// In headless mode we are not started by Kodi, so parent can be init
+	if !config.Args.Headless {
+		go watchParentProcess()
+	}
 
 	http.Handle("/", api.Routes(s))
 
 	http.Handle("/info", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		s.ClientInfo(w)
 	}))
+	http.Handle("/metrics", metrics.Handler())
 	http.Handle("/debug/all", bittorrent.DebugAll(s))
 	http.Handle("/debug/bundle", bittorrent.DebugBundle(s))
 
@@ -211,6 +220,7 @@ func main() {
 	go db.MaintenanceRefreshHandler()
 	go cacheDb.MaintenanceRefreshHandler()
 	go scrape.Start()
+	go rss.Start(s)
 
 	log.Infof("Prepared in %s", time.Since(now))
 	log.Infof("Starting HTTP server")
@@ -123,7 +123,10 @@ func Notification(w http.ResponseWriter, r *http.Request, s *bittorrent.Service)
 		p.Params().WasSeeked = true
 		resumePosition := float64(0)
 
-		if !config.Get().PlayResume {
+		if p.Params().StartPosition > 0 {
+			// Playback was switched from a stalled torrent
+			resumePosition = p.Params().StartPosition
+		} else if !config.Get().PlayResume {
 			return
 		} else if config.Get().StoreResume && p.Params().StoredResume != nil && p.Params().StoredResume.Position > 0 {
 			resumePosition = p.Params().StoredResume.Position
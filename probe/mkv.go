@@ -0,0 +1,429 @@
+package probe
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"strings"
+)
+
+// Matroska element IDs
+const (
+	mkvSegment                 = 0x18538067
+	mkvSeekHead                = 0x114d9b74
+	mkvSeek                    = 0x4dbb
+	mkvSeekID                  = 0x53ab
+	mkvSeekPosition            = 0x53ac
+	mkvInfo                    = 0x1549a966
+	mkvTimecodeScale           = 0x2ad7b1
+	mkvDuration                = 0x4489
+	mkvTracks                  = 0x1654ae6b
+	mkvTrackEntry              = 0xae
+	mkvTrackType               = 0x83
+	mkvCodecID                 = 0x86
+	mkvLanguage                = 0x22b59c
+	mkvLanguageIETF            = 0x22b59d
+	mkvFlagDefault             = 0x88
+	mkvVideo                   = 0xe0
+	mkvPixelWidth              = 0xb0
+	mkvPixelHeight             = 0xba
+	mkvColour                  = 0x55b0
+	mkvTransferCharacteristics = 0x55ba
+	mkvAudio                   = 0xe1
+	mkvChannels                = 0x9f
+	mkvBlockAdditionMapping    = 0x41e4
+	mkvBlockAddIDType          = 0x41e7
+	mkvCluster                 = 0x1f43b675
+)
+
+// Matroska track types
+const (
+	mkvTrackVideo    = 1
+	mkvTrackAudio    = 2
+	mkvTrackSubtitle = 17
+)
+
+var mkvCodecs = map[string]string{
+	"V_MPEG4/ISO/AVC":  "h264",
+	"V_MPEGH/ISO/HEVC": "hevc",
+	"V_AV1":            "av1",
+	"V_VP9":            "vp9",
+	"V_VP8":            "vp8",
+	"V_MPEG4/ISO/ASP":  "mpeg4",
+	"V_MPEG2":          "mpeg2video",
+	"V_MS/VFW/FOURCC":  "vfw",
+	"A_AAC":            "aac",
+	"A_AC3":            "ac3",
+	"A_EAC3":           "eac3",
+	"A_DTS":            "dts",
+	"A_TRUEHD":         "truehd",
+	"A_FLAC":           "flac",
+	"A_OPUS":           "opus",
+	"A_VORBIS":         "vorbis",
+	"A_MPEG/L3":        "mp3",
+	"A_MPEG/L2":        "mp2",
+	"A_PCM/INT/LIT":    "pcm",
+	"S_TEXT/UTF8":      "srt",
+	"S_TEXT/ASS":       "ass",
+	"S_TEXT/SSA":       "ssa",
+	"S_TEXT/WEBVTT":    "webvtt",
+	"S_HDMV/PGS":       "pgs",
+	"S_VOBSUB":         "vobsub",
+}
+
+type mkvElement struct {
+	id   uint32
+	data []byte
+}
+
+// readElementID reads EBML element ID, keeping the length marker
+func readElementID(b []byte) (id uint32, n int) {
+	if len(b) == 0 || b[0] == 0 {
+		return 0, 0
+	}
+
+	n = 1
+	for mask := byte(0x80); b[0]&mask == 0; mask >>= 1 {
+		n++
+	}
+	if n > 4 || n > len(b) {
+		return 0, 0
+	}
+
+	for i := 0; i < n; i++ {
+		id = id<<8 | uint32(b[i])
+	}
+	return
+}
+
+// readElementSize reads EBML element size, all ones mean the size is unknown
+func readElementSize(b []byte) (size int64, n int, unknown bool) {
+	if len(b) == 0 || b[0] == 0 {
+		return 0, 0, false
+	}
+
+	n = 1
+	mask := byte(0x80)
+	for ; b[0]&mask == 0; mask >>= 1 {
+		n++
+	}
+	if n > len(b) {
+		return 0, 0, false
+	}
+
+	value := uint64(b[0] & (mask - 1))
+	allOnes := value == uint64(mask-1)
+	for i := 1; i < n; i++ {
+		value = value<<8 | uint64(b[i])
+		allOnes = allOnes && b[i] == 0xff
+	}
+	return int64(value), n, allOnes
+}
+
+// mkvChildren splits master element data into child elements
+func mkvChildren(b []byte) (ret []mkvElement) {
+	for len(b) > 0 {
+		id, n := readElementID(b)
+		if n == 0 {
+			return
+		}
+		size, m, unknown := readElementSize(b[n:])
+		if m == 0 || unknown {
+			return
+		}
+
+		b = b[n+m:]
+		if size > int64(len(b)) {
+			size = int64(len(b))
+		}
+		ret = append(ret, mkvElement{id: id, data: b[:size]})
+		b = b[size:]
+	}
+	return
+}
+
+func mkvUint(b []byte) (ret uint64) {
+	for _, v := range b {
+		ret = ret<<8 | uint64(v)
+	}
+	return
+}
+
+func mkvFloat(b []byte) float64 {
+	switch len(b) {
+	case 4:
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(b)))
+	case 8:
+		return math.Float64frombits(binary.BigEndian.Uint64(b))
+	}
+	return 0
+}
+
+func mkvString(b []byte) string {
+	return strings.TrimRight(string(b), "\x00")
+}
+
+// mkvElementAt reads element header at the position
+func mkvElementAt(r io.ReaderAt, pos int64) (id uint32, dataStart, size int64, unknown bool, err error) {
+	hdr, err := readAt(r, pos, 12)
+	if err != nil {
+		return
+	}
+
+	id, n := readElementID(hdr)
+	if n == 0 {
+		err = errors.New("Broken EBML element")
+		return
+	}
+	size, m, unknown := readElementSize(hdr[n:])
+	if m == 0 {
+		err = errors.New("Broken EBML element size")
+		return
+	}
+
+	return id, pos + int64(n+m), size, unknown, nil
+}
+
+func probeMKV(r io.ReaderAt, size int64) (*Info, error) {
+	info := &Info{
+		Container: ContainerMKV,
+		size:      size,
+	}
+
+	// Skipping EBML header
+	_, dataStart, headerSize, _, err := mkvElementAt(r, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	id, segmentStart, segmentSize, unknown, err := mkvElementAt(r, dataStart+headerSize)
+	if err != nil {
+		return nil, err
+	} else if id != mkvSegment {
+		return nil, errors.New("No Matroska segment found")
+	}
+
+	segmentEnd := segmentStart + segmentSize
+	if unknown || segmentEnd > size {
+		segmentEnd = size
+	}
+
+	found := map[uint32]bool{}
+	seeks := map[uint32]int64{}
+
+	parse := func(id uint32, data []byte) {
+		switch id {
+		case mkvSeekHead:
+			parseMKVSeekHead(data, seeks)
+		case mkvInfo:
+			parseMKVInfo(data, info)
+		case mkvTracks:
+			parseMKVTracks(data, info)
+		}
+		found[id] = true
+	}
+
+	// Tracks and info are usually placed before the first cluster
+	for pos := segmentStart; pos < segmentEnd && !(found[mkvInfo] && found[mkvTracks]); {
+		id, dataStart, elementSize, unknown, err := mkvElementAt(r, pos)
+		if err != nil {
+			return nil, err
+		}
+		if id == mkvCluster || unknown {
+			break
+		}
+
+		if id == mkvSeekHead || id == mkvInfo || id == mkvTracks {
+			data, err := readAt(r, dataStart, elementSize)
+			if err != nil {
+				return nil, err
+			}
+			parse(id, data)
+		}
+
+		pos = dataStart + elementSize
+	}
+
+	// Otherwise they can be found with the seek head
+	for _, id := range []uint32{mkvInfo, mkvTracks} {
+		position, ok := seeks[id]
+		if found[id] || !ok || position > segmentEnd-segmentStart {
+			continue
+		}
+
+		elementID, dataStart, elementSize, _, err := mkvElementAt(r, segmentStart+position)
+		if err != nil || elementID != id {
+			log.Debugf("Unable to find element %x by seek head: %v", id, err)
+			continue
+		}
+		data, err := readAt(r, dataStart, elementSize)
+		if err != nil {
+			return nil, err
+		}
+		parse(id, data)
+	}
+
+	if !found[mkvTracks] {
+		return nil, errors.New("No Matroska tracks found")
+	}
+
+	return info, nil
+}
+
+func parseMKVSeekHead(data []byte, seeks map[uint32]int64) {
+	for _, seek := range mkvChildren(data) {
+		if seek.id != mkvSeek {
+			continue
+		}
+
+		var id uint32
+		position := int64(-1)
+		for _, e := range mkvChildren(seek.data) {
+			switch e.id {
+			case mkvSeekID:
+				id = uint32(mkvUint(e.data))
+			case mkvSeekPosition:
+				position = int64(mkvUint(e.data))
+			}
+		}
+		if _, ok := seeks[id]; !ok && id != 0 && position >= 0 {
+			seeks[id] = position
+		}
+	}
+}
+
+func parseMKVInfo(data []byte, info *Info) {
+	scale := float64(1000000)
+	duration := float64(0)
+
+	for _, e := range mkvChildren(data) {
+		switch e.id {
+		case mkvTimecodeScale:
+			if v := mkvUint(e.data); v > 0 {
+				scale = float64(v)
+			}
+		case mkvDuration:
+			duration = mkvFloat(e.data)
+		}
+	}
+
+	// Broken headers should not break bitrate and buffer calculations
+	if duration > 0 && !math.IsInf(duration, 0) {
+		info.Duration = duration * scale / 1e9
+	}
+}
+
+func parseMKVTracks(data []byte, info *Info) {
+	for _, entry := range mkvChildren(data) {
+		if entry.id != mkvTrackEntry {
+			continue
+		}
+
+		var trackType uint64
+		var codec, language, languageIETF, hdr string
+		var width, height, channels int
+		isDefault := true
+
+		for _, e := range mkvChildren(entry.data) {
+			switch e.id {
+			case mkvTrackType:
+				trackType = mkvUint(e.data)
+			case mkvCodecID:
+				codec = mkvString(e.data)
+			case mkvLanguage:
+				language = mkvString(e.data)
+			case mkvLanguageIETF:
+				languageIETF = mkvString(e.data)
+			case mkvFlagDefault:
+				isDefault = mkvUint(e.data) != 0
+			case mkvVideo:
+				for _, v := range mkvChildren(e.data) {
+					switch v.id {
+					case mkvPixelWidth:
+						width = int(mkvUint(v.data))
+					case mkvPixelHeight:
+						height = int(mkvUint(v.data))
+					case mkvColour:
+						for _, c := range mkvChildren(v.data) {
+							if c.id == mkvTransferCharacteristics && hdr != DolbyVision {
+								hdr = transferHDR(mkvUint(c.data))
+							}
+						}
+					}
+				}
+			case mkvAudio:
+				for _, a := range mkvChildren(e.data) {
+					if a.id == mkvChannels {
+						channels = int(mkvUint(a.data))
+					}
+				}
+			case mkvBlockAdditionMapping:
+				for _, m := range mkvChildren(e.data) {
+					if m.id == mkvBlockAddIDType && isDolbyVisionConfig(uint32(mkvUint(m.data))) {
+						hdr = DolbyVision
+					}
+				}
+			}
+		}
+
+		// Language is "eng" by default, "und" means undefined
+		if language == "" && languageIETF != "" {
+			language = languageIETF
+		} else if language == "" {
+			language = "eng"
+		}
+		if language == "und" {
+			language = ""
+		}
+
+		if c, ok := mkvCodecs[codec]; ok {
+			codec = c
+		} else if strings.HasPrefix(codec, "A_AAC") {
+			codec = "aac"
+		} else if strings.HasPrefix(codec, "A_DTS") {
+			codec = "dts"
+		} else {
+			codec = strings.ToLower(codec)
+		}
+
+		switch trackType {
+		case mkvTrackVideo:
+			if info.VideoCodec != "" {
+				continue
+			}
+			info.VideoCodec = codec
+			info.Width = width
+			info.Height = height
+			info.HDR = hdr
+		case mkvTrackAudio:
+			info.Audio = append(info.Audio, &Track{
+				Codec:    codec,
+				Language: language,
+				Channels: channels,
+				Default:  isDefault,
+			})
+		case mkvTrackSubtitle:
+			info.Subtitles = append(info.Subtitles, &Track{
+				Codec:    codec,
+				Language: language,
+				Default:  isDefault,
+			})
+		}
+	}
+}
+
+// transferHDR maps transfer characteristics from ITU-T H.273 to HDR format
+func transferHDR(v uint64) string {
+	switch v {
+	case 16:
+		return HDR10
+	case 18:
+		return HLG
+	}
+	return ""
+}
+
+func isDolbyVisionConfig(fourcc uint32) bool {
+	return fourcc == 0x64766343 || fourcc == 0x64767643
+}
@@ -0,0 +1,192 @@
+package probe
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+func ebml(id uint32, children ...[]byte) []byte {
+	data := bytes.Join(children, nil)
+
+	var b []byte
+	switch {
+	case id > 0xffffff:
+		b = []byte{byte(id >> 24), byte(id >> 16), byte(id >> 8), byte(id)}
+	case id > 0xffff:
+		b = []byte{byte(id >> 16), byte(id >> 8), byte(id)}
+	case id > 0xff:
+		b = []byte{byte(id >> 8), byte(id)}
+	default:
+		b = []byte{byte(id)}
+	}
+
+	size := make([]byte, 8)
+	binary.BigEndian.PutUint64(size, uint64(len(data)))
+	size[0] = 0x01
+	return append(append(b, size...), data...)
+}
+
+func ebmlUint(id uint32, v uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, v)
+	return ebml(id, b)
+}
+
+func ebmlFloat(id uint32, v float64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, math.Float64bits(v))
+	return ebml(id, b)
+}
+
+func ebmlString(id uint32, v string) []byte {
+	return ebml(id, []byte(v))
+}
+
+func mkvFile(segment ...[]byte) []byte {
+	return append(ebml(0x1a45dfa3, ebmlString(0x4282, "matroska")), ebml(mkvSegment, segment...)...)
+}
+
+func mkvTestInfo() []byte {
+	return ebml(mkvInfo, ebmlUint(mkvTimecodeScale, 1000000), ebmlFloat(mkvDuration, 5400000))
+}
+
+func mkvTestTracks() []byte {
+	return ebml(mkvTracks,
+		ebml(mkvTrackEntry,
+			ebmlUint(mkvTrackType, mkvTrackVideo),
+			ebmlString(mkvCodecID, "V_MPEGH/ISO/HEVC"),
+			ebml(mkvVideo,
+				ebmlUint(mkvPixelWidth, 3840),
+				ebmlUint(mkvPixelHeight, 2160),
+				ebml(mkvColour, ebmlUint(mkvTransferCharacteristics, 16)),
+			),
+		),
+		ebml(mkvTrackEntry,
+			ebmlUint(mkvTrackType, mkvTrackAudio),
+			ebmlString(mkvCodecID, "A_EAC3"),
+			ebmlString(mkvLanguage, "rus"),
+			ebmlUint(mkvFlagDefault, 0),
+			ebml(mkvAudio, ebmlUint(mkvChannels, 2)),
+		),
+		ebml(mkvTrackEntry,
+			ebmlUint(mkvTrackType, mkvTrackAudio),
+			ebmlString(mkvCodecID, "A_DTS/MA"),
+			ebml(mkvAudio, ebmlUint(mkvChannels, 6)),
+		),
+		ebml(mkvTrackEntry,
+			ebmlUint(mkvTrackType, mkvTrackSubtitle),
+			ebmlString(mkvCodecID, "S_TEXT/UTF8"),
+			ebmlString(mkvLanguage, "und"),
+		),
+	)
+}
+
+func probeBytes(b []byte) (*Info, error) {
+	return Probe(bytes.NewReader(b), int64(len(b)))
+}
+
+func TestProbeMKV(t *testing.T) {
+	info, err := probeBytes(mkvFile(mkvTestInfo(), mkvTestTracks(), ebml(mkvCluster, make([]byte, 1024))))
+	if err != nil {
+		t.Fatalf("Could not probe: %s", err)
+	}
+
+	if info.Container != ContainerMKV || info.Duration != 5400 || info.Width != 3840 || info.Height != 2160 ||
+		info.VideoCodec != "hevc" || info.HDR != HDR10 || info.Bitrate <= 0 {
+		t.Errorf("Wrong info: %+v", info)
+	}
+	if len(info.Audio) != 2 || len(info.Subtitles) != 1 {
+		t.Fatalf("Wrong tracks: %d audio, %d subtitles", len(info.Audio), len(info.Subtitles))
+	}
+	if a := info.Audio[0]; a.Codec != "eac3" || a.Language != "rus" || a.Channels != 2 || a.Default {
+		t.Errorf("Wrong first audio track: %+v", a)
+	}
+	if a := info.DefaultAudio(); a != info.Audio[1] || a.Codec != "dts" || a.Language != "eng" || a.Channels != 6 {
+		t.Errorf("Wrong default audio track: %+v", a)
+	}
+	if s := info.Subtitles[0]; s.Codec != "srt" || s.Language != "" {
+		t.Errorf("Wrong subtitles track: %+v", s)
+	}
+}
+
+func TestProbeMKVSeekHead(t *testing.T) {
+	cluster := ebml(mkvCluster, make([]byte, 1024))
+	seekHead := func(position uint64) []byte {
+		return ebml(mkvSeekHead, ebml(mkvSeek,
+			ebml(mkvSeekID, []byte{0x16, 0x54, 0xae, 0x6b}),
+			ebmlUint(mkvSeekPosition, position),
+		))
+	}
+	// Seek head has the same size with any position
+	position := uint64(len(seekHead(0)) + len(mkvTestInfo()) + len(cluster))
+
+	info, err := probeBytes(mkvFile(seekHead(position), mkvTestInfo(), cluster, mkvTestTracks()))
+	if err != nil {
+		t.Fatalf("Could not probe: %s", err)
+	} else if info.VideoCodec != "hevc" || len(info.Audio) != 2 {
+		t.Errorf("Tracks are not found by seek head: %+v", info)
+	}
+
+	for _, p := range []uint64{position + 1, 1 << 40, math.MaxInt64, math.MaxUint64} {
+		if _, err := probeBytes(mkvFile(seekHead(p), mkvTestInfo(), cluster, mkvTestTracks())); err == nil {
+			t.Errorf("Expected error with seek position %d", p)
+		}
+	}
+}
+
+func TestProbeMKVMalformed(t *testing.T) {
+	dolbyVision := ebml(mkvTracks, ebml(mkvTrackEntry,
+		ebmlUint(mkvTrackType, mkvTrackVideo),
+		ebmlString(mkvCodecID, "V_MPEGH/ISO/HEVC"),
+		ebml(mkvBlockAdditionMapping, ebmlUint(mkvBlockAddIDType, 0x64766343)),
+	))
+
+	unknownSize := append(ebml(0x1a45dfa3), 0x18, 0x53, 0x80, 0x67, 0x01, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff)
+	unknownSize = append(unknownSize, mkvTestTracks()...)
+
+	tests := []struct {
+		name    string
+		input   []byte
+		wantErr bool
+		check   func(*Info) bool
+	}{
+		{"dolby vision", mkvFile(dolbyVision), false, func(i *Info) bool { return i.HDR == DolbyVision }},
+		{"unknown segment size", unknownSize, false, func(i *Info) bool { return i.VideoCodec == "hevc" }},
+		{"no duration", mkvFile(mkvTestTracks()), false, func(i *Info) bool { return i.Duration == 0 && i.Bitrate == 0 }},
+		{"infinite duration", mkvFile(ebml(mkvInfo, ebmlFloat(mkvDuration, math.Inf(1))), mkvTestTracks()), false, func(i *Info) bool { return i.Duration == 0 }},
+		{"negative duration", mkvFile(ebml(mkvInfo, ebmlFloat(mkvDuration, -1)), mkvTestTracks()), false, func(i *Info) bool { return i.Duration == 0 }},
+		{"broken track entry", mkvFile(ebml(mkvTracks, []byte{0xae, 0x00, 0x83})), false, func(i *Info) bool { return i.VideoCodec == "" }},
+		{"no tracks", mkvFile(mkvTestInfo()), true, nil},
+		{"tracks after cluster", mkvFile(mkvTestInfo(), ebml(mkvCluster), mkvTestTracks()), true, nil},
+		{"no segment", ebml(0x1a45dfa3, ebmlString(0x4282, "matroska")), true, nil},
+		{"wrong segment", append(ebml(0x1a45dfa3), ebml(mkvTracks)...), true, nil},
+		{"zero element id", append(ebml(0x1a45dfa3), 0, 0, 0, 0), true, nil},
+		{"huge tracks", append(ebml(0x1a45dfa3), ebml(mkvSegment, []byte{0x16, 0x54, 0xae, 0x6b, 0x01, 0x7f, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff})...), true, nil},
+	}
+
+	for _, test := range tests {
+		info, err := probeBytes(test.input)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: got error %v, want error %v", test.name, err, test.wantErr)
+		} else if err == nil && !test.check(info) {
+			t.Errorf("%s: wrong info %+v", test.name, info)
+		}
+	}
+}
+
+// Files, cut at any byte, should not crash the parser
+func TestProbeMKVTruncated(t *testing.T) {
+	b := mkvFile(mkvTestInfo(), mkvTestTracks(), ebml(mkvCluster, make([]byte, 16)))
+	for i := 4; i < len(b); i++ {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					t.Errorf("Panic on file cut at %d bytes: %v", i, r)
+				}
+			}()
+			probeBytes(b[:i])
+		}()
+	}
+}
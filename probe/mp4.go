@@ -0,0 +1,288 @@
+package probe
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"strings"
+)
+
+var mp4Codecs = map[string]string{
+	"avc1": "h264",
+	"avc3": "h264",
+	"hvc1": "hevc",
+	"hev1": "hevc",
+	"dvh1": "hevc",
+	"dvhe": "hevc",
+	"av01": "av1",
+	"vp09": "vp9",
+	"mp4v": "mpeg4",
+	"mp4a": "aac",
+	"ac-3": "ac3",
+	"ec-3": "eac3",
+	"dtsc": "dts",
+	"dtsh": "dts",
+	"dtsl": "dts",
+	"mlpa": "truehd",
+	"fLaC": "flac",
+	"Opus": "opus",
+	"tx3g": "mov_text",
+	"wvtt": "webvtt",
+	"stpp": "ttml",
+}
+
+type mp4Box struct {
+	kind string
+	data []byte
+}
+
+// mp4BoxAt reads box header at the position, size includes the header
+func mp4BoxAt(r io.ReaderAt, pos, fileSize int64) (kind string, headerSize, size int64, err error) {
+	hdr, err := readAt(r, pos, 16)
+	if err != nil {
+		return
+	} else if len(hdr) < 8 {
+		err = io.ErrUnexpectedEOF
+		return
+	}
+
+	size = int64(binary.BigEndian.Uint32(hdr))
+	kind = string(hdr[4:8])
+	headerSize = 8
+
+	switch size {
+	case 0:
+		size = fileSize - pos
+	case 1:
+		if len(hdr) < 16 {
+			err = io.ErrUnexpectedEOF
+			return
+		}
+		size = int64(binary.BigEndian.Uint64(hdr[8:]))
+		headerSize = 16
+	}
+	if size < headerSize {
+		err = errors.New("Broken MP4 box")
+	}
+	return
+}
+
+// mp4Children splits box data into child boxes
+func mp4Children(b []byte) (ret []mp4Box) {
+	for len(b) >= 8 {
+		size := int64(binary.BigEndian.Uint32(b))
+		kind := string(b[4:8])
+		headerSize := int64(8)
+
+		if size == 1 {
+			if len(b) < 16 {
+				return
+			}
+			size = int64(binary.BigEndian.Uint64(b[8:]))
+			headerSize = 16
+		} else if size == 0 {
+			size = int64(len(b))
+		}
+		if size < headerSize || size > int64(len(b)) {
+			return
+		}
+
+		ret = append(ret, mp4Box{kind: kind, data: b[headerSize:size]})
+		b = b[size:]
+	}
+	return
+}
+
+func mp4Child(b []byte, kind string) []byte {
+	for _, c := range mp4Children(b) {
+		if c.kind == kind {
+			return c.data
+		}
+	}
+	return nil
+}
+
+func probeMP4(r io.ReaderAt, size int64) (*Info, error) {
+	info := &Info{
+		Container: ContainerMP4,
+		size:      size,
+	}
+
+	seenMdat := false
+	for pos := int64(0); pos < size; {
+		kind, headerSize, boxSize, err := mp4BoxAt(r, pos, size)
+		if err != nil {
+			return nil, err
+		} else if boxSize > size-pos {
+			return nil, errors.New("MP4 box is bigger than the file")
+		}
+
+		switch kind {
+		case "moov":
+			if seenMdat {
+				// Index goes after media data, so reading it requires the end of file
+				info.MoovAtEnd = true
+				info.MoovOffset = pos + headerSize
+				info.MoovSize = boxSize - headerSize
+				return info, nil
+			}
+
+			if err := parseMoovAt(r, info, pos+headerSize, boxSize-headerSize); err != nil {
+				return nil, err
+			}
+			return info, nil
+		case "mdat":
+			seenMdat = true
+		}
+
+		pos += boxSize
+	}
+
+	return nil, errors.New("No moov box found")
+}
+
+func parseMoovAt(r io.ReaderAt, info *Info, offset, size int64) error {
+	data, err := readAt(r, offset, size)
+	if err != nil {
+		return err
+	}
+
+	parseMoov(data, info)
+	return nil
+}
+
+func parseMoov(data []byte, info *Info) {
+	for _, box := range mp4Children(data) {
+		switch box.kind {
+		case "mvhd":
+			parseMvhd(box.data, info)
+		case "trak":
+			parseTrak(box.data, info)
+		}
+	}
+}
+
+func parseMvhd(b []byte, info *Info) {
+	if len(b) < 4 {
+		return
+	}
+
+	var timescale, duration uint64
+	if b[0] == 1 && len(b) >= 32 {
+		timescale = uint64(binary.BigEndian.Uint32(b[20:]))
+		duration = binary.BigEndian.Uint64(b[24:])
+	} else if len(b) >= 20 {
+		timescale = uint64(binary.BigEndian.Uint32(b[12:]))
+		duration = uint64(binary.BigEndian.Uint32(b[16:]))
+	}
+
+	if timescale > 0 {
+		info.Duration = float64(duration) / float64(timescale)
+	}
+}
+
+func parseTrak(b []byte, info *Info) {
+	mdia := mp4Child(b, "mdia")
+	if mdia == nil {
+		return
+	}
+
+	handler := ""
+	if hdlr := mp4Child(mdia, "hdlr"); len(hdlr) >= 12 {
+		handler = string(hdlr[8:12])
+	}
+
+	language := ""
+	if mdhd := mp4Child(mdia, "mdhd"); len(mdhd) > 0 {
+		offset := 20
+		if mdhd[0] == 1 {
+			offset = 32
+		}
+		if len(mdhd) >= offset+2 {
+			language = mp4Language(binary.BigEndian.Uint16(mdhd[offset:]))
+		}
+	}
+
+	isDefault := true
+	if tkhd := mp4Child(b, "tkhd"); len(tkhd) >= 4 {
+		// Track enabled flag
+		isDefault = tkhd[3]&0x01 != 0
+	}
+
+	stsd := mp4Child(mp4Child(mp4Child(mdia, "minf"), "stbl"), "stsd")
+	if len(stsd) < 8 {
+		return
+	}
+	entries := mp4Children(stsd[8:])
+	if len(entries) == 0 {
+		return
+	}
+	entry := entries[0]
+
+	codec, ok := mp4Codecs[entry.kind]
+	if !ok {
+		codec = strings.ToLower(strings.TrimSpace(entry.kind))
+	}
+
+	switch handler {
+	case "vide":
+		if info.VideoCodec != "" {
+			return
+		}
+		info.VideoCodec = codec
+
+		// Visual sample entry: reserved and predefined fields, then width and height
+		if len(entry.data) >= 78 {
+			info.Width = int(binary.BigEndian.Uint16(entry.data[24:]))
+			info.Height = int(binary.BigEndian.Uint16(entry.data[26:]))
+
+			for _, c := range mp4Children(entry.data[78:]) {
+				switch c.kind {
+				case "dvcC", "dvvC":
+					info.HDR = DolbyVision
+				case "colr":
+					if len(c.data) >= 8 && string(c.data[:4]) == "nclx" && info.HDR != DolbyVision {
+						info.HDR = transferHDR(uint64(binary.BigEndian.Uint16(c.data[6:])))
+					}
+				}
+			}
+		}
+		if entry.kind == "dvh1" || entry.kind == "dvhe" {
+			info.HDR = DolbyVision
+		}
+	case "soun":
+		t := &Track{
+			Codec:    codec,
+			Language: language,
+			Default:  isDefault,
+		}
+		// Audio sample entry: reserved fields, then channel count
+		if len(entry.data) >= 18 {
+			t.Channels = int(binary.BigEndian.Uint16(entry.data[16:]))
+		}
+		info.Audio = append(info.Audio, t)
+	case "sbtl", "subt", "text":
+		info.Subtitles = append(info.Subtitles, &Track{
+			Codec:    codec,
+			Language: language,
+			Default:  isDefault,
+		})
+	}
+}
+
+// mp4Language decodes packed ISO-639-2/T language code
+func mp4Language(v uint16) string {
+	if v == 0 || v == 0x7fff {
+		return ""
+	}
+
+	b := []byte{
+		byte((v>>10)&0x1f) + 0x60,
+		byte((v>>5)&0x1f) + 0x60,
+		byte(v&0x1f) + 0x60,
+	}
+	if lang := string(b); lang != "und" {
+		return lang
+	}
+	return ""
+}
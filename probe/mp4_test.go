@@ -0,0 +1,197 @@
+package probe
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func box(kind string, children ...[]byte) []byte {
+	data := bytes.Join(children, nil)
+
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint32(b, uint32(len(data)+8))
+	copy(b[4:], kind)
+	return append(b, data...)
+}
+
+func u16(v uint16) []byte {
+	b := make([]byte, 2)
+	binary.BigEndian.PutUint16(b, v)
+	return b
+}
+
+func u32(v uint32) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, v)
+	return b
+}
+
+// mp4Lang packs ISO-639-2/T code, as it is stored in mdhd
+func mp4Lang(lang string) uint16 {
+	return uint16(lang[0]-0x60)<<10 | uint16(lang[1]-0x60)<<5 | uint16(lang[2]-0x60)
+}
+
+func mp4Trak(handler, lang string, enabled bool, entry []byte) []byte {
+	flags := uint32(0)
+	if enabled {
+		flags = 1
+	}
+
+	return box("trak",
+		box("tkhd", u32(flags), make([]byte, 80)),
+		box("mdia",
+			box("mdhd", make([]byte, 20), u16(mp4Lang(lang)), make([]byte, 2)),
+			box("hdlr", make([]byte, 8), []byte(handler), make([]byte, 13)),
+			box("minf", box("stbl", box("stsd", make([]byte, 4), u32(1), entry))),
+		),
+	)
+}
+
+func mp4TestMoov() []byte {
+	video := make([]byte, 78)
+	copy(video[24:], u16(1920))
+	copy(video[26:], u16(1080))
+	colr := box("colr", []byte("nclx"), u16(9), u16(18), u16(9), []byte{0})
+
+	audio := make([]byte, 28)
+	copy(audio[16:], u16(6))
+
+	return box("moov",
+		box("mvhd", make([]byte, 12), u32(1000), u32(5400000), make([]byte, 80)),
+		mp4Trak("vide", "und", true, box("hvc1", video, colr)),
+		mp4Trak("soun", "eng", true, box("ac-3", audio)),
+		mp4Trak("soun", "fra", false, box("mp4a", audio)),
+		mp4Trak("sbtl", "deu", false, box("tx3g", make([]byte, 8))),
+	)
+}
+
+func mp4TestFile(boxes ...[]byte) []byte {
+	return append(box("ftyp", []byte("isom"), u32(512), []byte("isomiso2")), bytes.Join(boxes, nil)...)
+}
+
+func checkMP4Info(t *testing.T, name string, info *Info) {
+	if info.Container != ContainerMP4 || info.Duration != 5400 || info.Width != 1920 || info.Height != 1080 ||
+		info.VideoCodec != "hevc" || info.HDR != HLG || info.Bitrate <= 0 {
+		t.Errorf("%s: wrong info %+v", name, info)
+	}
+	if len(info.Audio) != 2 || len(info.Subtitles) != 1 {
+		t.Fatalf("%s: wrong tracks: %d audio, %d subtitles", name, len(info.Audio), len(info.Subtitles))
+	}
+	if a := info.DefaultAudio(); a != info.Audio[0] || a.Codec != "ac3" || a.Language != "eng" || a.Channels != 6 {
+		t.Errorf("%s: wrong default audio track %+v", name, a)
+	}
+	if a := info.Audio[1]; a.Codec != "aac" || a.Language != "fra" || a.Default {
+		t.Errorf("%s: wrong second audio track %+v", name, a)
+	}
+	if s := info.Subtitles[0]; s.Codec != "mov_text" || s.Language != "deu" {
+		t.Errorf("%s: wrong subtitles track %+v", name, s)
+	}
+}
+
+func TestProbeMP4(t *testing.T) {
+	info, err := probeBytes(mp4TestFile(mp4TestMoov(), box("mdat", make([]byte, 4096))))
+	if err != nil {
+		t.Fatalf("Could not probe: %s", err)
+	} else if info.MoovAtEnd {
+		t.Errorf("Moov is at the start, but reported at the end")
+	}
+	checkMP4Info(t, "moov at start", info)
+
+	// Media data with 64-bit size
+	largeMdat := append(u32(1), []byte("mdat")...)
+	largeMdat = append(largeMdat, 0, 0, 0, 0, 0, 0, 0x10, 0x10)
+	largeMdat = append(largeMdat, make([]byte, 4096)...)
+
+	info, err = probeBytes(mp4TestFile(box("free"), mp4TestMoov(), largeMdat))
+	if err != nil {
+		t.Fatalf("Could not probe: %s", err)
+	}
+	checkMP4Info(t, "large mdat", info)
+}
+
+func TestProbeMP4MoovAtEnd(t *testing.T) {
+	moov := mp4TestMoov()
+	head := mp4TestFile(box("mdat", make([]byte, 64*1024)))
+	b := append(head, moov...)
+
+	r := bytes.NewReader(b)
+	info, err := Probe(r, int64(len(b)))
+	if err != nil {
+		t.Fatalf("Could not probe: %s", err)
+	}
+
+	if !info.MoovAtEnd || info.MoovOffset != int64(len(head)+8) || info.MoovSize != int64(len(moov)-8) {
+		t.Errorf("Wrong moov location: at end %v, offset %d, size %d", info.MoovAtEnd, info.MoovOffset, info.MoovSize)
+	}
+	if info.Duration != 0 || info.VideoCodec != "" {
+		t.Errorf("Tracks should not be parsed before the index is downloaded: %+v", info)
+	}
+
+	if err := ProbeMoov(r, info); err != nil {
+		t.Fatalf("Could not probe moov: %s", err)
+	}
+	checkMP4Info(t, "moov at end", info)
+
+	if expected := int64(len(b)) * 8 / 5400; info.Bitrate != expected {
+		t.Errorf("Wrong bitrate: got %d, want %d", info.Bitrate, expected)
+	}
+}
+
+func TestProbeMP4Malformed(t *testing.T) {
+	hugeLarge := append(u32(1), []byte("mdat")...)
+	hugeLarge = append(hugeLarge, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff)
+
+	tests := []struct {
+		name    string
+		input   []byte
+		wantErr bool
+		check   func(*Info) bool
+	}{
+		{"moov only", box("moov", box("mvhd", make([]byte, 12), u32(1000), u32(60000))), false, func(i *Info) bool { return i.Duration == 60 }},
+		{"empty moov", mp4TestFile(box("moov")), false, func(i *Info) bool { return i.VideoCodec == "" && len(i.Audio) == 0 }},
+		{"short mvhd", mp4TestFile(box("moov", box("mvhd", make([]byte, 4)))), false, func(i *Info) bool { return i.Duration == 0 }},
+		{"broken child boxes", mp4TestFile(box("moov", u32(0xffff), []byte("trak"))), false, func(i *Info) bool { return i.VideoCodec == "" }},
+		{"trak without stsd", mp4TestFile(box("moov", box("trak", box("mdia", box("hdlr", make([]byte, 8), []byte("vide")))))), false, func(i *Info) bool { return i.VideoCodec == "" }},
+		{"short visual entry", mp4TestFile(box("moov", mp4Trak("vide", "eng", true, box("avc1", make([]byte, 10))))), false, func(i *Info) bool { return i.VideoCodec == "h264" && i.Width == 0 }},
+		{"no moov", mp4TestFile(box("mdat", make([]byte, 16))), true, nil},
+		{"box smaller than header", append(mp4TestFile(), 0, 0, 0, 4, 'f', 'r', 'e', 'e'), true, nil},
+		{"box bigger than file", mp4TestFile(u32(1000), []byte("free"), make([]byte, 8)), true, nil},
+		{"large box with huge size", mp4TestFile(hugeLarge), true, nil},
+		{"large box without size", append(mp4TestFile(), 0, 0, 0, 1, 'm', 'd', 'a', 't', 0, 0), true, nil},
+		{"moov bigger than file", mp4TestFile(u32(1<<20), []byte("moov"), mp4TestMoov()), true, nil},
+		{"moov at end bigger than file", mp4TestFile(box("mdat"), u32(0x7fffffff), []byte("moov")), true, nil},
+	}
+
+	for _, test := range tests {
+		info, err := probeBytes(test.input)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: got error %v, want error %v", test.name, err, test.wantErr)
+		} else if err == nil && !test.check(info) {
+			t.Errorf("%s: wrong info %+v", test.name, info)
+		}
+	}
+}
+
+// Files, cut at any byte, should not crash the parser
+func TestProbeMP4Truncated(t *testing.T) {
+	for name, b := range map[string][]byte{
+		"moov at start": mp4TestFile(mp4TestMoov(), box("mdat", make([]byte, 16))),
+		"moov at end":   mp4TestFile(box("mdat", make([]byte, 16)), mp4TestMoov()),
+	} {
+		for i := 12; i < len(b); i++ {
+			func() {
+				defer func() {
+					if r := recover(); r != nil {
+						t.Errorf("%s: panic on file cut at %d bytes: %v", name, i, r)
+					}
+				}()
+
+				r := bytes.NewReader(b[:i])
+				if info, err := Probe(r, int64(i)); err == nil {
+					ProbeMoov(r, info)
+				}
+			}()
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package probe
+
+import (
+	"errors"
+	"io"
+
+	"github.com/op/go-logging"
+)
+
+// Containers
+const (
+	ContainerMKV = "mkv"
+	ContainerMP4 = "mp4"
+)
+
+// HDR formats
+const (
+	HDR10       = "hdr10"
+	HLG         = "hlg"
+	DolbyVision = "dolbyvision"
+)
+
+// maxHeaderSize limits the size of header elements we read into memory
+const maxHeaderSize = 32 * 1024 * 1024
+
+var log = logging.MustGetLogger("probe")
+
+// ErrUnknownContainer is returned for files, that are not MKV or MP4
+var ErrUnknownContainer = errors.New("Unknown container")
+
+// Track is an audio or subtitle track
+type Track struct {
+	Codec    string `json:"codec,omitempty"`
+	Language string `json:"language,omitempty"`
+	Channels int    `json:"channels,omitempty"`
+	Default  bool   `json:"default,omitempty"`
+}
+
+// Info is a result of container probing
+type Info struct {
+	Container string `json:"container"`
+	// Duration in seconds
+	Duration   float64 `json:"duration"`
+	Width      int     `json:"width,omitempty"`
+	Height     int     `json:"height,omitempty"`
+	VideoCodec string  `json:"video_codec,omitempty"`
+	HDR        string  `json:"hdr,omitempty"`
+	// Bitrate is an average bitrate of the file, in bits per second
+	Bitrate   int64    `json:"bitrate,omitempty"`
+	Audio     []*Track `json:"audio,omitempty"`
+	Subtitles []*Track `json:"subtitles,omitempty"`
+
+	// MoovAtEnd is set for MP4 files, that have the index after media data,
+	// in this case only moov location is known until ProbeMoov is called.
+	MoovAtEnd  bool  `json:"moov_at_end,omitempty"`
+	MoovOffset int64 `json:"-"`
+	MoovSize   int64 `json:"-"`
+
+	size int64
+}
+
+// DefaultAudio returns default or first audio track
+func (i *Info) DefaultAudio() *Track {
+	for _, t := range i.Audio {
+		if t.Default {
+			return t
+		}
+	}
+	if len(i.Audio) > 0 {
+		return i.Audio[0]
+	}
+	return nil
+}
+
+// AudioLanguages returns languages of all audio tracks
+func (i *Info) AudioLanguages() []string {
+	return languages(i.Audio)
+}
+
+// SubtitleLanguages returns languages of all subtitle tracks
+func (i *Info) SubtitleLanguages() []string {
+	return languages(i.Subtitles)
+}
+
+func languages(tracks []*Track) []string {
+	ret := []string{}
+	seen := map[string]bool{}
+	for _, t := range tracks {
+		if t.Language == "" || seen[t.Language] {
+			continue
+		}
+		seen[t.Language] = true
+		ret = append(ret, t.Language)
+	}
+	return ret
+}
+
+func (i *Info) updateBitrate() {
+	if i.Duration > 0 && i.size > 0 {
+		i.Bitrate = int64(float64(i.size*8) / i.Duration)
+	}
+}
+
+// Probe detects the container by first bytes and parses its headers.
+// Reader is expected to block until requested bytes are available.
+func Probe(r io.ReaderAt, size int64) (*Info, error) {
+	head := make([]byte, 12)
+	if _, err := r.ReadAt(head, 0); err != nil {
+		return nil, err
+	}
+
+	var info *Info
+	var err error
+	switch {
+	case head[0] == 0x1a && head[1] == 0x45 && head[2] == 0xdf && head[3] == 0xa3:
+		info, err = probeMKV(r, size)
+	case string(head[4:8]) == "ftyp" || string(head[4:8]) == "moov" || string(head[4:8]) == "free" || string(head[4:8]) == "mdat":
+		info, err = probeMP4(r, size)
+	default:
+		return nil, ErrUnknownContainer
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	info.updateBitrate()
+	return info, nil
+}
+
+// ProbeMoov parses the moov box, that was found at the end of MP4 file
+func ProbeMoov(r io.ReaderAt, info *Info) error {
+	if !info.MoovAtEnd {
+		return nil
+	}
+
+	if err := parseMoovAt(r, info, info.MoovOffset, info.MoovSize); err != nil {
+		return err
+	}
+
+	info.updateBitrate()
+	return nil
+}
+
+func readAt(r io.ReaderAt, off, size int64) ([]byte, error) {
+	if size < 0 || size > maxHeaderSize {
+		return nil, errors.New("Header is too big")
+	}
+
+	b := make([]byte, size)
+	n, err := r.ReadAt(b, off)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	return b[:n], nil
+}
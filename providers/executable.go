@@ -0,0 +1,257 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/op/go-logging"
+
+	"github.com/mrjdainc/da-inc/bittorrent"
+	"github.com/mrjdainc/da-inc/config"
+	"github.com/mrjdainc/da-inc/tmdb"
+)
+
+var (
+	executableMu    sync.Mutex
+	executableSlots chan struct{}
+)
+
+// ExecutableSearcher runs a local executable, which reads SearchPayload JSON from stdin
+// and writes a JSON array of torrents, or a final ProviderResponse, to stdout
+type ExecutableSearcher struct {
+	path string
+	name string
+	log  *logging.Logger
+
+	// Search objects are the same, as for addons
+	objects AddonSearcher
+}
+
+// GetExecutableSearchers returns searchers for all configured executables
+func GetExecutableSearchers() []*ExecutableSearcher {
+	list := make([]*ExecutableSearcher, 0)
+	if !config.Get().ExecutableProvidersEnabled {
+		return list
+	}
+
+	for _, path := range strings.FieldsFunc(config.Get().ExecutableProviders, func(r rune) bool {
+		return r == ',' || r == ';' || r == '\n'
+	}) {
+		if s, err := NewExecutableSearcher(strings.TrimSpace(path)); err == nil {
+			list = append(list, s)
+		} else {
+			log.Warningf("Skipping executable provider %s: %s", path, err)
+		}
+	}
+	return list
+}
+
+// NewExecutableSearcher ...
+func NewExecutableSearcher(path string) (*ExecutableSearcher, error) {
+	if path == "" {
+		return nil, fmt.Errorf("Empty path")
+	}
+
+	found, err := exec.LookPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	name := strings.TrimSuffix(filepath.Base(found), filepath.Ext(found))
+	return &ExecutableSearcher{
+		path: found,
+		name: name,
+		log:  logging.MustGetLogger(fmt.Sprintf("ExecutableSearcher %s", name)),
+	}, nil
+}
+
+// executableSemaphore returns the channel, limiting concurrently running executables,
+// it is recreated when the limit is changed in settings
+func executableSemaphore() chan struct{} {
+	executableMu.Lock()
+	defer executableMu.Unlock()
+
+	limit := config.Get().ExecutableProvidersConcurrency
+	if limit <= 0 {
+		limit = 1
+	}
+	if executableSlots == nil || cap(executableSlots) != limit {
+		executableSlots = make(chan struct{}, limit)
+	}
+	return executableSlots
+}
+
+func (es *ExecutableSearcher) call(method string, searchObject interface{}) []*bittorrent.TorrentFile {
+	torrents := make([]*bittorrent.TorrentFile, 0)
+
+	if IsProviderSkipped(es.name) {
+		es.log.Infof("Provider %s is cooling off after timeouts, skipping", es.name)
+		return torrents
+	}
+
+	payload, err := json.Marshal(&SearchPayload{
+		Method:          method,
+		ProtocolVersion: ProtocolVersion,
+		SearchObject:    searchObject,
+	})
+	if err != nil {
+		es.log.Errorf("Could not marshal payload: %s", err)
+		return torrents
+	}
+
+	timeout := providerTimeout()
+	if config.Get().CustomProviderTimeoutEnabled == true {
+		timeout = time.Duration(config.Get().CustomProviderTimeout) * time.Second
+	}
+
+	// Waiting for a free slot counts towards the timeout as well
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	slots := executableSemaphore()
+	select {
+	case slots <- struct{}{}:
+		defer func() { <-slots }()
+	case <-ctx.Done():
+		es.log.Warningf("Provider %s was waiting too long for a free slot. Ignored.", es.name)
+		return torrents
+	}
+
+	started := time.Now()
+	cmd := exec.Command(es.path)
+	cmd.Stdin = bytes.NewReader(payload)
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	stderr := &logWriter{log: es.log}
+	cmd.Stderr = stderr
+	setProcessGroup(cmd)
+
+	if err := cmd.Start(); err != nil {
+		es.log.Errorf("Could not start %s: %s", es.path, err)
+		return torrents
+	}
+
+	// Wait returns only when output pipes are closed, so children are killed as well
+	finished := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			if err := killProcessGroup(cmd); err != nil {
+				es.log.Debugf("Could not kill %s: %s", es.name, err)
+			}
+		case <-finished:
+		}
+	}()
+
+	err = cmd.Wait()
+	close(finished)
+	stderr.Flush()
+
+	if ctx.Err() == context.DeadlineExceeded {
+		es.log.Warningf("Provider %s was too slow. Ignored.", es.name)
+		recordCall(es.name, started, 0, true, false)
+		return torrents
+	} else if err != nil {
+		es.log.Warningf("Provider %s failed: %s", es.name, err)
+		recordCall(es.name, started, 0, false, true)
+		return torrents
+	}
+
+	resp, err := parseResponse(stdout.Bytes())
+	if err != nil {
+		es.log.Warningf("Could not parse output of %s: %s", es.name, err)
+		recordCall(es.name, started, 0, false, true)
+		return torrents
+	} else if resp.Error != nil {
+		es.log.Warningf("Provider %s failed with %s: %s", es.name, resp.Error.Code, resp.Error.Message)
+	}
+
+	torrents = append(torrents, resp.Results...)
+	for _, t := range torrents {
+		t.Searcher = es.name
+		if t.Provider == "" {
+			t.Provider = es.name
+		}
+	}
+	recordCall(es.name, started, len(torrents), false, resp.Error != nil)
+
+	es.log.Debugf("Received %d results", len(torrents))
+	return torrents
+}
+
+// logWriter writes executable's stderr to the log, line by line,
+// too long lines are split to keep the buffer bounded
+type logWriter struct {
+	log *logging.Logger
+	buf []byte
+}
+
+const maxLogLine = 4096
+
+func (w *logWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 && len(w.buf) < maxLogLine {
+			break
+		} else if i < 0 || i > maxLogLine {
+			w.writeLine(w.buf[:maxLogLine])
+			w.buf = append(w.buf[:0], w.buf[maxLogLine:]...)
+			continue
+		}
+
+		w.writeLine(w.buf[:i])
+		w.buf = append(w.buf[:0], w.buf[i+1:]...)
+	}
+
+	return len(p), nil
+}
+
+// Flush writes the rest of output, that has no trailing new line
+func (w *logWriter) Flush() {
+	w.writeLine(w.buf)
+	w.buf = nil
+}
+
+func (w *logWriter) writeLine(line []byte) {
+	if line := strings.TrimSpace(string(line)); line != "" {
+		w.log.Info(line)
+	}
+}
+
+// SearchLinks ...
+func (es *ExecutableSearcher) SearchLinks(query string) []*bittorrent.TorrentFile {
+	return es.call("search", es.objects.GetQuerySearchObject(query))
+}
+
+// SearchMovieLinks ...
+func (es *ExecutableSearcher) SearchMovieLinks(movie *tmdb.Movie) []*bittorrent.TorrentFile {
+	return es.call("search_movie", es.objects.GetMovieSearchObject(movie))
+}
+
+// SearchMovieLinksSilent ...
+func (es *ExecutableSearcher) SearchMovieLinksSilent(movie *tmdb.Movie, withAuth bool) []*bittorrent.TorrentFile {
+	return es.call("search_movie", es.objects.GetMovieSearchSilentObject(movie, withAuth))
+}
+
+// SearchSeasonLinks ...
+func (es *ExecutableSearcher) SearchSeasonLinks(show *tmdb.Show, season *tmdb.Season) []*bittorrent.TorrentFile {
+	return es.call("search_season", es.objects.GetSeasonSearchObject(show, season))
+}
+
+// SearchEpisodeLinks ...
+func (es *ExecutableSearcher) SearchEpisodeLinks(show *tmdb.Show, episode *tmdb.Episode) []*bittorrent.TorrentFile {
+	return es.call("search_episode", es.objects.GetEpisodeSearchObject(show, episode))
+}
+
+// SearchEpisodeLinksSilent ...
+func (es *ExecutableSearcher) SearchEpisodeLinksSilent(show *tmdb.Show, episode *tmdb.Episode, withAuth bool) []*bittorrent.TorrentFile {
+	return es.call("search_episode", es.objects.GetEpisodeSearchSilentObject(show, episode, withAuth))
+}
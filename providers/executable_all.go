@@ -0,0 +1,19 @@
+// +build !windows
+
+package providers
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup starts executable in own process group, to be killed with its children
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// killProcessGroup kills executable and all processes it has started,
+// so none of them keeps output pipes open
+func killProcessGroup(cmd *exec.Cmd) error {
+	return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
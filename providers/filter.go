@@ -0,0 +1,195 @@
+package providers
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+
+	"github.com/mrjdainc/da-inc/bittorrent"
+	"github.com/mrjdainc/da-inc/database"
+	"github.com/mrjdainc/da-inc/release"
+)
+
+// Filter rule actions
+const (
+	// FilterDrop ...
+	FilterDrop = iota
+	// FilterFlag ...
+	FilterFlag
+)
+
+// CompileFilterRule compiles include and exclude expressions of the rule,
+// empty or wrong expressions are returned as nil and are not checked.
+func CompileFilterRule(r *database.FilterRule) (include, exclude *regexp.Regexp) {
+	var err error
+	if r.Include != "" {
+		if include, err = regexp.Compile("(?i)" + r.Include); err != nil {
+			log.Warningf("Wrong include expression in filter %s: %s", r.Name, err)
+		}
+	}
+	if r.Exclude != "" {
+		if exclude, err = regexp.Compile("(?i)" + r.Exclude); err != nil {
+			log.Warningf("Wrong exclude expression in filter %s: %s", r.Name, err)
+		}
+	}
+	return
+}
+
+// CheckFilterRule returns the reason, why the torrent breaks the rule, or empty string.
+// Include and exclude are expressions, compiled with CompileFilterRule.
+// Runtime is in minutes, 0 skips per minute size checks.
+func CheckFilterRule(r *database.FilterRule, include, exclude *regexp.Regexp, t *bittorrent.TorrentFile, runtime int) string {
+	if include != nil && !include.MatchString(t.Name) && !include.MatchString(t.Title) {
+		return fmt.Sprintf("Name does not match '%s'", r.Include)
+	}
+	if exclude != nil && (exclude.MatchString(t.Name) || exclude.MatchString(t.Title)) {
+		return fmt.Sprintf("Name matches '%s'", r.Exclude)
+	}
+
+	if r.MinSeeders > 0 && t.Seeds < r.MinSeeders {
+		return fmt.Sprintf("Less than %d seeders", r.MinSeeders)
+	}
+	if r.PrivateOnly && !t.IsPrivate {
+		return "Not a private tracker"
+	}
+	for _, p := range r.BlockedProviders {
+		if strings.Contains(strings.ToLower(t.Provider), strings.ToLower(p)) {
+			return fmt.Sprintf("Provider %s is blocked", p)
+		}
+	}
+	if indexOfInt(r.BlockedRipTypes, t.RipType) >= 0 && t.RipType < len(bittorrent.Rips) {
+		return fmt.Sprintf("%s is blocked", bittorrent.Rips[t.RipType])
+	}
+	for _, codec := range []int{t.VideoCodec, t.AudioCodec} {
+		if indexOfInt(r.BlockedCodecs, codec) >= 0 && codec < len(bittorrent.Codecs) {
+			return fmt.Sprintf("%s is blocked", bittorrent.Codecs[codec])
+		}
+	}
+
+	info := t.Release
+	if info == nil {
+		info = release.Parse(t.Name)
+	}
+
+	if len(r.RequiredLanguages) > 0 {
+		found := false
+		for _, l := range r.RequiredLanguages {
+			l = strings.ToLower(l)
+			if strings.ToLower(t.Language) == l || indexOfString(info.Languages, l) >= 0 {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Sprintf("No %s language", strings.Join(r.RequiredLanguages, ", "))
+		}
+	}
+
+	if t.SizeParsed > 0 {
+		size := int64(t.SizeParsed)
+		if r.MinSize > 0 && size < r.MinSize {
+			return fmt.Sprintf("Smaller than %s", humanize.IBytes(uint64(r.MinSize)))
+		} else if r.MaxSize > 0 && size > r.MaxSize {
+			return fmt.Sprintf("Bigger than %s", humanize.IBytes(uint64(r.MaxSize)))
+		}
+
+		if runtime > 0 && !info.IsSeasonPack && !info.IsCompleteSeries && !info.IsMultiEpisode {
+			perMinute := float64(t.SizeParsed) / 1024 / 1024 / float64(runtime)
+			if r.MinSizePerMinute > 0 && perMinute < r.MinSizePerMinute {
+				return fmt.Sprintf("Less than %.1f MB per minute", r.MinSizePerMinute)
+			} else if r.MaxSizePerMinute > 0 && perMinute > r.MaxSizePerMinute {
+				return fmt.Sprintf("More than %.1f MB per minute", r.MaxSizePerMinute)
+			}
+		}
+	}
+
+	return ""
+}
+
+// ValidateFilterRule checks that rule's expressions can be compiled
+func ValidateFilterRule(r *database.FilterRule) error {
+	for _, expr := range []string{r.Include, r.Exclude} {
+		if _, err := regexp.Compile("(?i)" + expr); err != nil {
+			return err
+		}
+	}
+	if r.MaxSize > 0 && r.MinSize > r.MaxSize {
+		return fmt.Errorf("Minimal size is bigger than maximal")
+	} else if r.MaxSizePerMinute > 0 && r.MinSizePerMinute > r.MaxSizePerMinute {
+		return fmt.Errorf("Minimal size per minute is bigger than maximal")
+	} else if r.Action != FilterDrop && r.Action != FilterFlag {
+		return fmt.Errorf("Unknown action: %d", r.Action)
+	}
+
+	return nil
+}
+
+// applyFilterRules checks torrents against enabled rules for the media type,
+// flagged torrents are kept, dropped ones are returned separately
+func applyFilterRules(torrents []*bittorrent.TorrentFile, sortType int, runtime int) (kept, dropped []*bittorrent.TorrentFile) {
+	mediaType := "movie"
+	if sortType == SortShows {
+		mediaType = "show"
+	}
+
+	rules := []database.FilterRule{}
+	for _, r := range database.GetStorm().GetFilterRules() {
+		if r.Enabled && (r.MediaType == "" || r.MediaType == mediaType) {
+			rules = append(rules, r)
+		}
+	}
+	if len(rules) == 0 {
+		return torrents, nil
+	}
+
+	includes := make([]*regexp.Regexp, len(rules))
+	excludes := make([]*regexp.Regexp, len(rules))
+	for i := range rules {
+		includes[i], excludes[i] = CompileFilterRule(&rules[i])
+	}
+
+	kept = make([]*bittorrent.TorrentFile, 0, len(torrents))
+	for _, t := range torrents {
+		t.Flagged = ""
+		t.Dropped = ""
+
+		for i := range rules {
+			reason := CheckFilterRule(&rules[i], includes[i], excludes[i], t, runtime)
+			if reason == "" {
+				continue
+			}
+
+			reason = fmt.Sprintf("%s: %s", rules[i].Name, reason)
+			if rules[i].Action == FilterDrop {
+				t.Dropped = reason
+				break
+			} else if t.Flagged == "" {
+				t.Flagged = reason
+			}
+		}
+
+		if t.Dropped != "" {
+			log.Debugf("Dropped %s (%s)", t.Name, t.Dropped)
+			dropped = append(dropped, t)
+		} else {
+			kept = append(kept, t)
+		}
+	}
+
+	return
+}
+
+// SplitDropped separates torrents, dropped by filter rules, from the rest
+func SplitDropped(torrents []*bittorrent.TorrentFile) (kept, dropped []*bittorrent.TorrentFile) {
+	kept = make([]*bittorrent.TorrentFile, 0, len(torrents))
+	for _, t := range torrents {
+		if t.Dropped != "" {
+			dropped = append(dropped, t)
+		} else {
+			kept = append(kept, t)
+		}
+	}
+	return
+}
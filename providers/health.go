@@ -0,0 +1,111 @@
+package providers
+
+import (
+	"sort"
+	"time"
+
+	"github.com/mrjdainc/da-inc/bittorrent"
+	"github.com/mrjdainc/da-inc/config"
+	"github.com/mrjdainc/da-inc/database"
+)
+
+// Number of last calls, used for median latency
+const maxLatencies = 50
+
+// IsProviderSkipped checks whether provider is cooling off after too many timeouts in a row,
+// first call after the cool-off probes the provider again
+func IsProviderSkipped(name string) bool {
+	if config.Get().ProviderTimeoutsLimit <= 0 {
+		return false
+	}
+	return time.Now().Before(database.GetStorm().GetProviderStat(name).SkipUntil)
+}
+
+// ResetProviderCircuit lets provider be called again, before its cool-off ends
+func ResetProviderCircuit(name string) error {
+	return database.GetStorm().UpdateProviderStats(name, func(s *database.ProviderStats) {
+		s.TimeoutsInRow = 0
+		s.SkipUntil = time.Time{}
+	})
+}
+
+// RecordFailure counts provider's failure, reported outside of search calls
+func RecordFailure(name string) {
+	if err := database.GetStorm().UpdateProviderStats(name, func(s *database.ProviderStats) {
+		s.Errors++
+	}); err != nil {
+		log.Warningf("Could not save stats of %s: %s", name, err)
+	}
+}
+
+// MedianLatency returns median latency of provider's last calls
+func MedianLatency(s *database.ProviderStats) time.Duration {
+	if len(s.Latencies) == 0 {
+		return 0
+	}
+
+	latencies := make([]int64, len(s.Latencies))
+	copy(latencies, s.Latencies)
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+
+	median := latencies[len(latencies)/2]
+	if len(latencies)%2 == 0 {
+		median = (latencies[len(latencies)/2-1] + median) / 2
+	}
+	return time.Duration(median) * time.Millisecond
+}
+
+// recordCall updates provider's stats after a search call,
+// provider is skipped for a cool-off period after too many timeouts in a row
+func recordCall(name string, started time.Time, results int, timedOut, failed bool) {
+	err := database.GetStorm().UpdateProviderStats(name, func(s *database.ProviderStats) {
+		s.Calls++
+		s.LastCall = time.Now()
+		if failed {
+			s.Errors++
+		}
+
+		if timedOut {
+			s.Timeouts++
+			s.TimeoutsInRow++
+			if limit := config.Get().ProviderTimeoutsLimit; limit > 0 && s.TimeoutsInRow >= limit {
+				s.SkipUntil = time.Now().Add(time.Duration(config.Get().ProviderCooloff) * time.Minute)
+				log.Warningf("Provider %s timed out %d times in a row, skipping it until %s", name, s.TimeoutsInRow, s.SkipUntil.Format("15:04"))
+			}
+			return
+		}
+
+		s.TimeoutsInRow = 0
+		s.SkipUntil = time.Time{}
+		if results == 0 && !failed {
+			s.Empty++
+		}
+
+		s.Latencies = append(s.Latencies, int64(time.Since(started)/time.Millisecond))
+		if len(s.Latencies) > maxLatencies {
+			s.Latencies = s.Latencies[len(s.Latencies)-maxLatencies:]
+		}
+	})
+	if err != nil {
+		log.Warningf("Could not save stats of %s: %s", name, err)
+	}
+}
+
+// recordDistinct counts results, which were found by a single provider only
+func recordDistinct(torrents []*bittorrent.TorrentFile) {
+	distinct := map[string]int{}
+	for _, t := range torrents {
+		if !t.Multi && t.Searcher != "" {
+			distinct[t.Searcher]++
+		}
+	}
+
+	for name, count := range distinct {
+		count := count
+		if err := database.GetStorm().UpdateProviderStats(name, func(s *database.ProviderStats) {
+			s.Distinct += count
+		}); err != nil {
+			log.Warningf("Could not save stats of %s: %s", name, err)
+		}
+	}
+}
@@ -7,9 +7,10 @@ import (
 
 // SearchPayload ...
 type SearchPayload struct {
-	Method       string      `json:"method"`
-	CallbackURL  string      `json:"callback_url"`
-	SearchObject interface{} `json:"search_object"`
+	Method          string      `json:"method"`
+	CallbackURL     string      `json:"callback_url"`
+	ProtocolVersion int         `json:"protocol_version"`
+	SearchObject    interface{} `json:"search_object"`
 }
 
 // GeneralSearchObject ...
@@ -0,0 +1,115 @@
+package providers
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mrjdainc/da-inc/bittorrent"
+	"github.com/mrjdainc/da-inc/config"
+	"github.com/mrjdainc/da-inc/database"
+	"github.com/mrjdainc/da-inc/xbmc"
+)
+
+var btService *bittorrent.Service
+
+// SetService sets torrent service, used to prefetch metadata of magnets
+func SetService(s *bittorrent.Service) {
+	btService = s
+}
+
+// prefetchMetadata fetches metadata of the top magnets by seeds to fill their files and real size,
+// fakes, containing executables instead of video, are removed
+func prefetchMetadata(torrents []*bittorrent.TorrentFile, isSilent bool) []*bittorrent.TorrentFile {
+	if !config.Get().MagnetPrefetchEnabled || btService == nil {
+		return torrents
+	}
+
+	// Private torrents can't use DHT
+	magnets := make([]*bittorrent.TorrentFile, 0)
+	for _, t := range torrents {
+		if t.IsMagnet() && !t.IsPrivate && len(t.Files) == 0 {
+			magnets = append(magnets, t)
+		}
+	}
+	if len(magnets) == 0 {
+		return torrents
+	}
+
+	sort.SliceStable(magnets, func(i, j int) bool { return magnets[i].Seeds > magnets[j].Seeds })
+	if count := config.Get().MagnetPrefetchCount; count > 0 && len(magnets) > count {
+		magnets = magnets[:count]
+	}
+
+	log.Infof("Fetching metadata of %d magnets...", len(magnets))
+
+	var dialog *xbmc.DialogProgressBG
+	if !isSilent {
+		dialog = xbmc.NewDialogProgressBG("dainc", "LOCALIZE[30618]", "LOCALIZE[30618]")
+		defer func() {
+			if dialog != nil {
+				dialog.Close()
+			}
+		}()
+	}
+
+	parallel := config.Get().MagnetPrefetchParallel
+	if parallel <= 0 {
+		parallel = 1
+	}
+	timeout := time.Duration(config.Get().MagnetResolveTimeout) * time.Second
+
+	slots := make(chan struct{}, parallel)
+	mu := sync.Mutex{}
+	done := 0
+
+	wg := sync.WaitGroup{}
+	for _, t := range magnets {
+		wg.Add(1)
+		go func(t *bittorrent.TorrentFile) {
+			defer wg.Done()
+
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			if err := loadMetadata(t, timeout); err != nil {
+				log.Debugf("Could not fetch metadata of %s: %s", t.InfoHash, err)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			done++
+			if dialog != nil {
+				dialog.Update(done*100/len(magnets), "dainc", "LOCALIZE[30618]")
+			}
+		}(t)
+	}
+	wg.Wait()
+
+	ret := make([]*bittorrent.TorrentFile, 0, len(torrents))
+	for _, t := range torrents {
+		if t.IsFake() {
+			log.Infof("Rejecting %s, it has no video files, only executables", t.Name)
+			continue
+		}
+		ret = append(ret, t)
+	}
+	return ret
+}
+
+// loadMetadata fills torrent from stored metadata, or fetches it over DHT and stores it
+func loadMetadata(t *bittorrent.TorrentFile, timeout time.Duration) error {
+	// Stored metadata can also be a JSON of the torrent file, which has no files
+	b := database.GetStorm().GetTorrentMetadata(t.InfoHash)
+	if len(b) == 0 || b[0] == '{' {
+		var err error
+		if b, err = btService.FetchMetadata(t.URI, timeout); err != nil {
+			return err
+		}
+		if err := database.GetStorm().SaveTorrentMetadata(t.InfoHash, b); err != nil {
+			log.Warningf("Could not save metadata of %s: %s", t.InfoHash, err)
+		}
+	}
+
+	return t.LoadMetadata(b)
+}
@@ -0,0 +1,173 @@
+package providers
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/mrjdainc/da-inc/bittorrent"
+	"github.com/mrjdainc/da-inc/config"
+	"github.com/mrjdainc/da-inc/database"
+	"github.com/mrjdainc/da-inc/release"
+)
+
+// HDR preferences of a quality profile
+const (
+	// HDRAny ...
+	HDRAny = iota
+	// HDRPreferred ...
+	HDRPreferred
+	// HDRRequired ...
+	HDRRequired
+	// HDRForbidden ...
+	HDRForbidden
+)
+
+// Score weights, preferred resolutions and codecs are multiplied by their rank
+const (
+	scoreResolution = 1000
+	scoreVideoCodec = 100
+	scoreTerm       = 200
+	scoreHDR        = 300
+	scoreProper     = 50
+	scoreSeeders    = 100
+)
+
+// GetQualityProfile returns the profile for media type,
+// profile of a library show wins over media type profile, which wins over the global one
+func GetQualityProfile(sortType int, showID int) *database.QualityProfile {
+	id := config.Get().QualityProfile
+	if sortType == SortMovies && config.Get().QualityProfileMovies != 0 {
+		id = config.Get().QualityProfileMovies
+	} else if sortType == SortShows && config.Get().QualityProfileShows != 0 {
+		id = config.Get().QualityProfileShows
+	}
+	if showID != 0 {
+		if showProfile := database.GetStorm().GetShowQualityProfile(showID); showProfile != 0 {
+			id = showProfile
+		}
+	}
+
+	if id == 0 {
+		return nil
+	}
+	return database.GetStorm().GetQualityProfile(id)
+}
+
+// ScoreTorrent returns the score of the torrent in the profile,
+// false means the torrent is not acceptable. Runtime is in minutes, 0 skips size checks.
+func ScoreTorrent(t *bittorrent.TorrentFile, profile *database.QualityProfile, runtime int) (int, bool) {
+	info := t.Release
+	if info == nil {
+		info = release.Parse(t.Name)
+	}
+	name := strings.ToLower(t.Name)
+
+	if len(profile.AllowedResolutions) > 0 && indexOfInt(profile.AllowedResolutions, t.Resolution) < 0 {
+		return 0, false
+	} else if t.Seeds < profile.MinSeeders {
+		return 0, false
+	} else if profile.HDR == HDRRequired && info.HDR == "" {
+		return 0, false
+	} else if profile.HDR == HDRForbidden && info.HDR != "" {
+		return 0, false
+	}
+
+	// Packs contain several episodes, so their size says nothing about quality
+	if runtime > 0 && t.SizeParsed > 0 && !info.IsSeasonPack && !info.IsCompleteSeries && !info.IsMultiEpisode {
+		perMinute := float64(t.SizeParsed) / 1024 / 1024 / float64(runtime)
+		if profile.MinSizePerMinute > 0 && perMinute < profile.MinSizePerMinute {
+			return 0, false
+		} else if profile.MaxSizePerMinute > 0 && perMinute > profile.MaxSizePerMinute {
+			return 0, false
+		}
+	}
+
+	for _, term := range profile.RequiredTerms {
+		if !strings.Contains(name, strings.ToLower(term)) {
+			return 0, false
+		}
+	}
+	for _, term := range profile.ForbiddenTerms {
+		if strings.Contains(name, strings.ToLower(term)) {
+			return 0, false
+		}
+	}
+
+	if len(profile.RequiredLanguages) > 0 {
+		found := false
+		for _, l := range profile.RequiredLanguages {
+			l = strings.ToLower(l)
+			if strings.ToLower(t.Language) == l || indexOfString(info.Languages, l) >= 0 {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return 0, false
+		}
+	}
+
+	score := 0
+	if i := indexOfInt(profile.PreferredResolutions, t.Resolution); i >= 0 {
+		score += (len(profile.PreferredResolutions) - i) * scoreResolution
+	}
+	if i := indexOfInt(profile.PreferredVideoCodecs, t.VideoCodec); i >= 0 {
+		score += (len(profile.PreferredVideoCodecs) - i) * scoreVideoCodec
+	}
+	for _, term := range profile.PreferredTerms {
+		if strings.Contains(name, strings.ToLower(term)) {
+			score += scoreTerm
+		}
+	}
+	if profile.HDR == HDRPreferred && info.HDR != "" {
+		score += scoreHDR
+	}
+	if t.SceneRating == bittorrent.RatingProper {
+		score += scoreProper
+	}
+	if profile.PreferredSeeders > 0 && t.Seeds >= profile.PreferredSeeders {
+		score += scoreSeeders
+	}
+
+	return score, true
+}
+
+// ApplyQualityProfile removes torrents, not allowed by the profile,
+// and sorts the rest by score and seeds
+func ApplyQualityProfile(torrents []*bittorrent.TorrentFile, profile *database.QualityProfile, runtime int) []*bittorrent.TorrentFile {
+	ret := make([]*bittorrent.TorrentFile, 0, len(torrents))
+	for _, t := range torrents {
+		if score, ok := ScoreTorrent(t, profile, runtime); ok {
+			t.Score = score
+			ret = append(ret, t)
+		}
+	}
+
+	log.Debugf("Quality profile '%s' allowed %d of %d torrents", profile.Name, len(ret), len(torrents))
+
+	sort.SliceStable(ret, func(i, j int) bool {
+		if ret[i].Score != ret[j].Score {
+			return ret[i].Score > ret[j].Score
+		}
+		return ret[i].Seeds > ret[j].Seeds
+	})
+	return ret
+}
+
+func indexOfInt(list []int, value int) int {
+	for i, v := range list {
+		if v == value {
+			return i
+		}
+	}
+	return -1
+}
+
+func indexOfString(list []string, value string) int {
+	for i, v := range list {
+		if v == value {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,91 @@
+package providers
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/mrjdainc/da-inc/bittorrent"
+)
+
+// ProtocolVersion is sent to providers in every payload
+const ProtocolVersion = 2
+
+// Provider error codes
+const (
+	// ErrorAuthFailed ...
+	ErrorAuthFailed = "auth_failed"
+	// ErrorBlocked ...
+	ErrorBlocked = "blocked"
+	// ErrorRateLimited ...
+	ErrorRateLimited = "rate_limited"
+	// ErrorInvalidResponse is used when provider's response could not be parsed
+	ErrorInvalidResponse = "invalid_response"
+)
+
+// ProviderError is a structured error, reported by a provider
+type ProviderError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Capabilities are reported by a provider of protocol v2 along with its responses,
+// providers, which never report them, are treated as protocol v1 ones
+type Capabilities struct {
+	ProtocolVersion int      `json:"protocol_version"`
+	Methods         []string `json:"methods"`
+	NeedsAuth       bool     `json:"needs_auth"`
+}
+
+// ProviderResponse is a single callback of a provider. Providers of protocol v2
+// can post several responses with partial results, the last one is marked as final.
+type ProviderResponse struct {
+	ProtocolVersion int                       `json:"protocol_version"`
+	Results         []*bittorrent.TorrentFile `json:"results"`
+	Final           bool                      `json:"final"`
+	Error           *ProviderError            `json:"error,omitempty"`
+	Capabilities    *Capabilities             `json:"capabilities,omitempty"`
+}
+
+// Supports checks whether provider supports the method, empty list means all methods
+func (c *Capabilities) Supports(method string) bool {
+	if len(c.Methods) == 0 {
+		return true
+	}
+	return indexOfString(c.Methods, method) >= 0
+}
+
+// parseResponse reads provider's callback, protocol v1 providers
+// post a plain list of results, which is the final response
+func parseResponse(body []byte) (*ProviderResponse, error) {
+	body = bytes.TrimSpace(body)
+	if len(body) > 0 && body[0] == '[' {
+		resp := &ProviderResponse{Final: true}
+		err := json.Unmarshal(body, &resp.Results)
+		return resp, err
+	}
+
+	resp := &ProviderResponse{}
+	if err := json.Unmarshal(body, resp); err != nil {
+		return nil, err
+	}
+
+	// Errors do not have any results to wait for
+	if resp.Error != nil {
+		resp.Final = true
+	}
+	if resp.Capabilities != nil && resp.Capabilities.ProtocolVersion == 0 {
+		resp.Capabilities.ProtocolVersion = resp.ProtocolVersion
+	}
+	return resp, nil
+}
+
+// skipsAuth checks whether search object asks providers not to authenticate
+func skipsAuth(searchObject interface{}) bool {
+	switch o := searchObject.(type) {
+	case *MovieSearchObject:
+		return o.SkipAuth
+	case *EpisodeSearchObject:
+		return o.SkipAuth
+	}
+	return false
+}
@@ -21,7 +21,13 @@ type SeasonSearcher interface {
 	SearchSeasonLinks(show *tmdb.Show, season *tmdb.Season) []*bittorrent.TorrentFile
 }
 
+// PartialSearcher is a searcher, which can send results in batches, before the search is complete
+type PartialSearcher interface {
+	SetResultsChan(c chan<- *bittorrent.TorrentFile)
+}
+
 // EpisodeSearcher ...
 type EpisodeSearcher interface {
 	SearchEpisodeLinks(show *tmdb.Show, episode *tmdb.Episode) []*bittorrent.TorrentFile
+	SearchEpisodeLinksSilent(show *tmdb.Show, episode *tmdb.Episode, withAuth bool) []*bittorrent.TorrentFile
 }
@@ -13,6 +13,7 @@ import (
 
 	"github.com/mrjdainc/da-inc/bittorrent"
 	"github.com/mrjdainc/da-inc/config"
+	"github.com/mrjdainc/da-inc/database"
 	"github.com/mrjdainc/da-inc/tmdb"
 	"github.com/mrjdainc/da-inc/util"
 	"github.com/mrjdainc/da-inc/xbmc"
@@ -61,6 +62,9 @@ func Search(searchers []Searcher, query string) []*bittorrent.TorrentFile {
 			wg.Add(1)
 			go func(searcher Searcher) {
 				defer wg.Done()
+				if s, ok := searcher.(PartialSearcher); ok {
+					s.SetResultsChan(torrentsChan)
+				}
 				for _, torrent := range searcher.SearchLinks(query) {
 					torrentsChan <- torrent
 				}
@@ -70,7 +74,7 @@ func Search(searchers []Searcher, query string) []*bittorrent.TorrentFile {
 		close(torrentsChan)
 	}()
 
-	return processLinks(torrentsChan, SortMovies, false)
+	return processLinks(torrentsChan, SortMovies, false, GetQualityProfile(SortMovies, 0), 0)
 }
 
 // SearchMovie ...
@@ -82,6 +86,9 @@ func SearchMovie(searchers []MovieSearcher, movie *tmdb.Movie) []*bittorrent.Tor
 			wg.Add(1)
 			go func(searcher MovieSearcher) {
 				defer wg.Done()
+				if s, ok := searcher.(PartialSearcher); ok {
+					s.SetResultsChan(torrentsChan)
+				}
 				for _, torrent := range searcher.SearchMovieLinks(movie) {
 					torrentsChan <- torrent
 				}
@@ -91,11 +98,16 @@ func SearchMovie(searchers []MovieSearcher, movie *tmdb.Movie) []*bittorrent.Tor
 		close(torrentsChan)
 	}()
 
-	return processLinks(torrentsChan, SortMovies, false)
+	return processLinks(torrentsChan, SortMovies, false, GetQualityProfile(SortMovies, 0), movie.Runtime)
 }
 
 // SearchMovieSilent ...
 func SearchMovieSilent(searchers []MovieSearcher, movie *tmdb.Movie, withAuth bool) []*bittorrent.TorrentFile {
+	return SearchMovieSilentWithProfile(searchers, movie, withAuth, GetQualityProfile(SortMovies, 0))
+}
+
+// SearchMovieSilentWithProfile searches without progress dialog, using given quality profile instead of configured one
+func SearchMovieSilentWithProfile(searchers []MovieSearcher, movie *tmdb.Movie, withAuth bool, profile *database.QualityProfile) []*bittorrent.TorrentFile {
 	torrentsChan := make(chan *bittorrent.TorrentFile)
 	go func() {
 		wg := sync.WaitGroup{}
@@ -103,6 +115,9 @@ func SearchMovieSilent(searchers []MovieSearcher, movie *tmdb.Movie, withAuth bo
 			wg.Add(1)
 			go func(searcher MovieSearcher) {
 				defer wg.Done()
+				if s, ok := searcher.(PartialSearcher); ok {
+					s.SetResultsChan(torrentsChan)
+				}
 				for _, torrent := range searcher.SearchMovieLinksSilent(movie, withAuth) {
 					torrentsChan <- torrent
 				}
@@ -112,7 +127,7 @@ func SearchMovieSilent(searchers []MovieSearcher, movie *tmdb.Movie, withAuth bo
 		close(torrentsChan)
 	}()
 
-	return processLinks(torrentsChan, SortMovies, true)
+	return processLinks(torrentsChan, SortMovies, true, profile, movie.Runtime)
 }
 
 // SearchSeason ...
@@ -124,6 +139,9 @@ func SearchSeason(searchers []SeasonSearcher, show *tmdb.Show, season *tmdb.Seas
 			wg.Add(1)
 			go func(searcher SeasonSearcher) {
 				defer wg.Done()
+				if s, ok := searcher.(PartialSearcher); ok {
+					s.SetResultsChan(torrentsChan)
+				}
 				for _, torrent := range searcher.SearchSeasonLinks(show, season) {
 					torrentsChan <- torrent
 				}
@@ -133,7 +151,7 @@ func SearchSeason(searchers []SeasonSearcher, show *tmdb.Show, season *tmdb.Seas
 		close(torrentsChan)
 	}()
 
-	return processLinks(torrentsChan, SortShows, false)
+	return processLinks(torrentsChan, SortShows, false, GetQualityProfile(SortShows, show.ID), 0)
 }
 
 // SearchEpisode ...
@@ -145,6 +163,9 @@ func SearchEpisode(searchers []EpisodeSearcher, show *tmdb.Show, episode *tmdb.E
 			wg.Add(1)
 			go func(searcher EpisodeSearcher) {
 				defer wg.Done()
+				if s, ok := searcher.(PartialSearcher); ok {
+					s.SetResultsChan(torrentsChan)
+				}
 				for _, torrent := range searcher.SearchEpisodeLinks(show, episode) {
 					torrentsChan <- torrent
 				}
@@ -154,10 +175,44 @@ func SearchEpisode(searchers []EpisodeSearcher, show *tmdb.Show, episode *tmdb.E
 		close(torrentsChan)
 	}()
 
-	return processLinks(torrentsChan, SortShows, false)
+	return processLinks(torrentsChan, SortShows, false, GetQualityProfile(SortShows, show.ID), episodeRuntime(show))
+}
+
+// SearchEpisodeSilent ...
+func SearchEpisodeSilent(searchers []EpisodeSearcher, show *tmdb.Show, episode *tmdb.Episode, withAuth bool) []*bittorrent.TorrentFile {
+	torrentsChan := make(chan *bittorrent.TorrentFile)
+	go func() {
+		wg := sync.WaitGroup{}
+		for _, searcher := range searchers {
+			wg.Add(1)
+			go func(searcher EpisodeSearcher) {
+				defer wg.Done()
+				if s, ok := searcher.(PartialSearcher); ok {
+					s.SetResultsChan(torrentsChan)
+				}
+				for _, torrent := range searcher.SearchEpisodeLinksSilent(show, episode, withAuth) {
+					torrentsChan <- torrent
+				}
+			}(searcher)
+		}
+		wg.Wait()
+		close(torrentsChan)
+	}()
+
+	return processLinks(torrentsChan, SortShows, true, GetQualityProfile(SortShows, show.ID), episodeRuntime(show))
+}
+
+// episodeRuntime returns usual episode runtime of the show in minutes
+func episodeRuntime(show *tmdb.Show) int {
+	if len(show.EpisodeRunTime) == 0 {
+		return 0
+	}
+	return show.EpisodeRunTime[len(show.EpisodeRunTime)-1]
 }
 
-func processLinks(torrentsChan chan *bittorrent.TorrentFile, sortType int, isSilent bool) []*bittorrent.TorrentFile {
+// processLinks resolves and merges links from providers, then sorts them
+// by quality profile, if it is set, or by sorting settings
+func processLinks(torrentsChan chan *bittorrent.TorrentFile, sortType int, isSilent bool, profile *database.QualityProfile, runtime int) []*bittorrent.TorrentFile {
 	trackers := map[string]*bittorrent.Tracker{}
 	torrentsMap := map[string]*bittorrent.TorrentFile{}
 
@@ -266,6 +321,7 @@ func processLinks(torrentsChan chan *bittorrent.TorrentFile, sortType int, isSil
 			if torrent.Resolution > existingTorrent.Resolution {
 				existingTorrent.Name = torrent.Name
 				existingTorrent.Resolution = torrent.Resolution
+				existingTorrent.Release = torrent.Release
 			}
 			if torrent.VideoCodec > existingTorrent.VideoCodec {
 				existingTorrent.VideoCodec = torrent.VideoCodec
@@ -285,6 +341,13 @@ func processLinks(torrentsChan chan *bittorrent.TorrentFile, sortType int, isSil
 			if existingTorrent.IsMagnet() && !torrent.IsMagnet() {
 				existingTorrent.URI = torrent.URI
 			}
+			if len(existingTorrent.Files) == 0 {
+				existingTorrent.Files = torrent.Files
+			}
+			if torrent.UploadDate > 0 && (existingTorrent.UploadDate == 0 || torrent.UploadDate < existingTorrent.UploadDate) {
+				existingTorrent.UploadDate = torrent.UploadDate
+			}
+			existingTorrent.Freeleech = existingTorrent.Freeleech || torrent.Freeleech
 
 			existingTorrent.Multi = true
 		} else {
@@ -314,6 +377,7 @@ func processLinks(torrentsChan chan *bittorrent.TorrentFile, sortType int, isSil
 	}
 
 	log.Infof("Received %d unique links.", len(torrents))
+	recordDistinct(torrents)
 
 	if len(torrents) == 0 {
 		if !isSilent {
@@ -473,6 +537,12 @@ func processLinks(torrentsChan chan *bittorrent.TorrentFile, sortType int, isSil
 
 	}
 
+	// Real contents of magnets are needed for filters and sorting
+	torrents = prefetchMetadata(torrents, isSilent)
+
+	// Filter rules are applied before sorting, dropped torrents are kept only to be shown in dialogs
+	torrents, dropped := applyFilterRules(torrents, sortType, runtime)
+
 	// Sorting resulting list of torrents
 	conf := config.Get()
 	sortMode := conf.SortingModeMovies
@@ -490,7 +560,9 @@ func processLinks(torrentsChan chan *bittorrent.TorrentFile, sortType int, isSil
 	resolution720p480p := func(c1, c2 *bittorrent.TorrentFile) bool { return Resolution720p480p(c1) < Resolution720p480p(c2) }
 	balanced := func(c1, c2 *bittorrent.TorrentFile) bool { return float64(c1.Seeds) > Balanced(c2) }
 
-	if sortMode == SortBySize {
+	if profile != nil {
+		torrents = ApplyQualityProfile(torrents, profile, runtime)
+	} else if sortMode == SortBySize {
 		sort.Slice(torrents, func(i, j int) bool {
 			return torrents[i].SizeParsed > torrents[j].SizeParsed
 		})
@@ -529,6 +601,15 @@ func processLinks(torrentsChan chan *bittorrent.TorrentFile, sortType int, isSil
 		}
 	}
 
+	// Nuked releases go last, keeping the order of the rest
+	sort.SliceStable(torrents, func(i, j int) bool {
+		return torrents[i].SceneRating != bittorrent.RatingNuked && torrents[j].SceneRating == bittorrent.RatingNuked
+	})
+
+	if !isSilent {
+		torrents = append(torrents, dropped...)
+	}
+
 	// log.Info("Sorted torrent candidates.")
 	// for _, torrent := range torrents {
 	// 	log.Infof("S:%d P:%d %s - %s - %s", torrent.Seeds, torrent.Peers, torrent.Name, torrent.Provider, torrent.URI)
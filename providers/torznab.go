@@ -0,0 +1,360 @@
+package providers
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/op/go-logging"
+
+	"github.com/mrjdainc/da-inc/bittorrent"
+	"github.com/mrjdainc/da-inc/config"
+	"github.com/mrjdainc/da-inc/tmdb"
+	"github.com/mrjdainc/da-inc/util"
+)
+
+const (
+	torznabCategoryMovies = "2000"
+	torznabCategoryTV     = "5000"
+)
+
+var torznabClient = &http.Client{}
+
+// TorznabSearcher queries Torznab compatible endpoints, like Jackett or Prowlarr, directly
+type TorznabSearcher struct {
+	endpoint string
+	name     string
+	log      *logging.Logger
+}
+
+type torznabResponse struct {
+	XMLName xml.Name      `xml:"rss"`
+	Items   []torznabItem `xml:"channel>item"`
+}
+
+type torznabError struct {
+	XMLName     xml.Name `xml:"error"`
+	Code        int      `xml:"code,attr"`
+	Description string   `xml:"description,attr"`
+}
+
+type torznabItem struct {
+	Title     string `xml:"title"`
+	GUID      string `xml:"guid"`
+	Link      string `xml:"link"`
+	Size      uint64 `xml:"size"`
+	Indexer   string `xml:"jackettindexer"`
+	Enclosure struct {
+		URL    string `xml:"url,attr"`
+		Length uint64 `xml:"length,attr"`
+	} `xml:"enclosure"`
+	Attrs []struct {
+		Name  string `xml:"name,attr"`
+		Value string `xml:"value,attr"`
+	} `xml:"attr"`
+}
+
+// GetTorznabSearchers returns searchers for all configured Torznab endpoints
+func GetTorznabSearchers() []*TorznabSearcher {
+	list := make([]*TorznabSearcher, 0)
+	if !config.Get().TorznabEnabled {
+		return list
+	}
+
+	for _, endpoint := range strings.FieldsFunc(config.Get().TorznabEndpoints, func(r rune) bool {
+		return r == ',' || r == ';' || r == '\n' || r == ' '
+	}) {
+		if s, err := NewTorznabSearcher(endpoint); err == nil {
+			list = append(list, s)
+		} else {
+			log.Warningf("Skipping Torznab endpoint %s: %s", redactURL(endpoint), redactError(err))
+		}
+	}
+
+	return list
+}
+
+// NewTorznabSearcher ...
+func NewTorznabSearcher(endpoint string) (*TorznabSearcher, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	} else if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("Endpoint should be a full url")
+	}
+
+	// Jackett's urls look like /api/v2.0/indexers/<indexer>/results/torznab/api
+	name := u.Host
+	if tokens := strings.Split(u.Path, "/"); len(tokens) > 4 && tokens[3] == "indexers" {
+		name = tokens[4]
+	}
+
+	return &TorznabSearcher{
+		endpoint: endpoint,
+		name:     name,
+		log:      logging.MustGetLogger(fmt.Sprintf("TorznabSearcher %s", name)),
+	}, nil
+}
+
+// redactURL removes query and credentials, which contain API key, from endpoint for logging
+func redactURL(endpoint string) string {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "(invalid url)"
+	}
+
+	u.User = nil
+	u.RawQuery = ""
+	return u.String()
+}
+
+// redactError drops request url, which contains API key, from url errors
+func redactError(err error) error {
+	if e, ok := err.(*url.Error); ok {
+		return e.Err
+	}
+	return err
+}
+
+func (ts *TorznabSearcher) call(params url.Values) []*bittorrent.TorrentFile {
+	torrents := make([]*bittorrent.TorrentFile, 0)
+
+	u, err := url.Parse(ts.endpoint)
+	if err != nil {
+		return torrents
+	}
+
+	query := u.Query()
+	for k, v := range params {
+		query[k] = v
+	}
+	u.RawQuery = query.Encode()
+
+	timeout := providerTimeout()
+	if config.Get().CustomProviderTimeoutEnabled == true {
+		timeout = time.Duration(config.Get().CustomProviderTimeout) * time.Second
+	}
+
+	if IsProviderSkipped(ts.name) {
+		ts.log.Infof("Endpoint %s is cooling off after timeouts, skipping", ts.name)
+		return torrents
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	started := time.Now()
+	timedOut, failed := false, false
+	defer func() {
+		recordCall(ts.name, started, len(torrents), timedOut, failed)
+	}()
+
+	req, _ := http.NewRequest("GET", u.String(), nil)
+	resp, err := torznabClient.Do(req.WithContext(ctx))
+	if err != nil {
+		ts.log.Warningf("Request to %s at %s failed: %s", ts.name, u.Host, redactError(err))
+		timedOut = ctx.Err() == context.DeadlineExceeded
+		failed = !timedOut
+		return torrents
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		ts.log.Warningf("Could not read response from %s: %s", ts.name, redactError(err))
+		timedOut = ctx.Err() == context.DeadlineExceeded
+		failed = !timedOut
+		return torrents
+	} else if resp.StatusCode != http.StatusOK {
+		ts.log.Warningf("Endpoint %s returned status %d", ts.name, resp.StatusCode)
+		failed = true
+		return torrents
+	}
+
+	var respError torznabError
+	if err := xml.Unmarshal(body, &respError); err == nil {
+		ts.log.Warningf("Endpoint %s returned error %d: %s", ts.name, respError.Code, respError.Description)
+		failed = true
+		return torrents
+	}
+
+	var response torznabResponse
+	if err := xml.Unmarshal(body, &response); err != nil {
+		ts.log.Warningf("Could not parse response from %s: %s", ts.name, err)
+		failed = true
+		return torrents
+	}
+
+	for _, item := range response.Items {
+		if t := ts.toTorrentFile(&item); t != nil {
+			t.Searcher = ts.name
+			torrents = append(torrents, t)
+		}
+	}
+
+	ts.log.Debugf("Received %d results", len(torrents))
+	return torrents
+}
+
+func (ts *TorznabSearcher) toTorrentFile(item *torznabItem) *bittorrent.TorrentFile {
+	t := &bittorrent.TorrentFile{
+		Name:     item.Title,
+		Title:    item.Title,
+		Provider: ts.name,
+	}
+	if item.Indexer != "" {
+		t.Provider = item.Indexer
+	}
+
+	size := item.Size
+	if size == 0 {
+		size = item.Enclosure.Length
+	}
+
+	var peers int64
+	for _, attr := range item.Attrs {
+		switch attr.Name {
+		case "seeders":
+			t.Seeds, _ = strconv.ParseInt(attr.Value, 10, 64)
+		case "peers":
+			peers, _ = strconv.ParseInt(attr.Value, 10, 64)
+		case "infohash":
+			t.InfoHash = strings.ToLower(attr.Value)
+		case "magneturl":
+			t.URI = attr.Value
+		case "size":
+			if size == 0 {
+				size, _ = strconv.ParseUint(attr.Value, 10, 64)
+			}
+		}
+	}
+
+	// Torznab's peers are seeders and leechers together
+	if peers > t.Seeds {
+		t.Peers = peers - t.Seeds
+	}
+	if size > 0 {
+		t.Size = humanize.Bytes(size)
+	}
+
+	if t.URI == "" {
+		t.URI = item.Enclosure.URL
+	}
+	if t.URI == "" {
+		t.URI = item.Link
+	}
+	if t.URI == "" && t.InfoHash != "" {
+		t.URI = "magnet:?xt=urn:btih:" + t.InfoHash
+	}
+	if t.URI == "" {
+		return nil
+	}
+
+	t.Initialize()
+	return t
+}
+
+func (ts *TorznabSearcher) callWithFallback(params url.Values, query string) []*bittorrent.TorrentFile {
+	torrents := ts.call(params)
+	if len(torrents) > 0 || query == "" {
+		return torrents
+	}
+
+	// Not every indexer supports searching by IDs, so we try with text query
+	for _, k := range []string{"imdbid", "tmdbid", "tvdbid"} {
+		params.Del(k)
+	}
+	params.Set("q", query)
+	return ts.call(params)
+}
+
+// SearchLinks ...
+func (ts *TorznabSearcher) SearchLinks(query string) []*bittorrent.TorrentFile {
+	return ts.call(url.Values{
+		"t": []string{"search"},
+		"q": []string{query},
+	})
+}
+
+// SearchMovieLinks ...
+func (ts *TorznabSearcher) SearchMovieLinks(movie *tmdb.Movie) []*bittorrent.TorrentFile {
+	params := url.Values{
+		"t":   []string{"movie"},
+		"cat": []string{torznabCategoryMovies},
+	}
+	if movie.IMDBId != "" {
+		params.Set("imdbid", strings.TrimPrefix(movie.IMDBId, "tt"))
+	}
+	if movie.ID != 0 {
+		params.Set("tmdbid", strconv.Itoa(movie.ID))
+	}
+
+	query := NormalizeTitle(movie.Title)
+	if year, _ := strconv.Atoi(strings.Split(movie.ReleaseDate, "-")[0]); year > 0 {
+		query = fmt.Sprintf("%s %d", query, year)
+	}
+	if len(params) == 2 {
+		params.Set("q", query)
+	}
+
+	return ts.callWithFallback(params, query)
+}
+
+// SearchMovieLinksSilent ...
+func (ts *TorznabSearcher) SearchMovieLinksSilent(movie *tmdb.Movie, withAuth bool) []*bittorrent.TorrentFile {
+	return ts.SearchMovieLinks(movie)
+}
+
+// SearchSeasonLinks ...
+func (ts *TorznabSearcher) SearchSeasonLinks(show *tmdb.Show, season *tmdb.Season) []*bittorrent.TorrentFile {
+	params := ts.showParams(show)
+	params.Set("season", strconv.Itoa(season.Season))
+
+	query := fmt.Sprintf("%s S%02d", NormalizeTitle(show.Name), season.Season)
+	return ts.callWithFallback(params, query)
+}
+
+// SearchEpisodeLinks ...
+func (ts *TorznabSearcher) SearchEpisodeLinks(show *tmdb.Show, episode *tmdb.Episode) []*bittorrent.TorrentFile {
+	params := ts.showParams(show)
+	params.Set("season", strconv.Itoa(episode.SeasonNumber))
+	params.Set("ep", strconv.Itoa(episode.EpisodeNumber))
+
+	query := fmt.Sprintf("%s S%02dE%02d", NormalizeTitle(show.Name), episode.SeasonNumber, episode.EpisodeNumber)
+	return ts.callWithFallback(params, query)
+}
+
+// SearchEpisodeLinksSilent ...
+func (ts *TorznabSearcher) SearchEpisodeLinksSilent(show *tmdb.Show, episode *tmdb.Episode, withAuth bool) []*bittorrent.TorrentFile {
+	return ts.SearchEpisodeLinks(show, episode)
+}
+
+func (ts *TorznabSearcher) showParams(show *tmdb.Show) url.Values {
+	params := url.Values{
+		"t":   []string{"tvsearch"},
+		"cat": []string{torznabCategoryTV},
+	}
+	if show.ExternalIDs != nil {
+		if tvdbID := util.StrInterfaceToInt(show.ExternalIDs.TVDBID); tvdbID != 0 {
+			params.Set("tvdbid", strconv.Itoa(tvdbID))
+		}
+		if show.ExternalIDs.IMDBId != "" {
+			params.Set("imdbid", strings.TrimPrefix(show.ExternalIDs.IMDBId, "tt"))
+		}
+	}
+	if show.ID != 0 {
+		params.Set("tmdbid", strconv.Itoa(show.ID))
+	}
+	if len(params) == 2 {
+		params.Set("q", NormalizeTitle(show.Name))
+	}
+
+	return params
+}
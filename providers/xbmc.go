@@ -1,7 +1,6 @@
 package providers
 
 import (
-	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"math/rand"
@@ -22,6 +21,9 @@ import (
 const (
 	// if >= 80% of episodes have absolute numbers, assume it's because we need it
 	mixAbsoluteNumberPercentage = 0.8
+
+	// protocol v2 providers can post several responses for one search
+	callbackBuffer = 32
 )
 
 // AddonSearcher ...
@@ -31,16 +33,20 @@ type AddonSearcher struct {
 	EpisodeSearcher
 
 	addonID string
+	results chan<- *bittorrent.TorrentFile
 	log     *logging.Logger
 }
 
 var cbLock = sync.RWMutex{}
-var callbacks = map[string]chan []byte{}
+var callbacks = map[string]chan *ProviderResponse{}
+
+var capsLock = sync.RWMutex{}
+var addonCapabilities = map[string]*Capabilities{}
 
 // GetCallback ...
-func GetCallback() (string, chan []byte) {
+func GetCallback() (string, chan *ProviderResponse) {
 	cid := strconv.Itoa(rand.Int())
-	c := make(chan []byte, 1) // make sure we don't block clients when we write on it
+	c := make(chan *ProviderResponse, callbackBuffer) // make sure we don't block clients when we write on it
 	cbLock.Lock()
 	callbacks[cid] = c
 	cbLock.Unlock()
@@ -66,10 +72,25 @@ func CallbackHandler(ctx *gin.Context) {
 	if !ok {
 		return
 	}
-	RemoveCallback(cid)
+
 	body, _ := ioutil.ReadAll(ctx.Request.Body)
-	c <- body
-	close(c)
+	resp, err := parseResponse(body)
+	if err != nil {
+		log.Warningf("Could not parse provider response: %s", err)
+		resp = &ProviderResponse{
+			Final: true,
+			Error: &ProviderError{Code: ErrorInvalidResponse, Message: err.Error()},
+		}
+	}
+	if resp.Final {
+		RemoveCallback(cid)
+	}
+
+	select {
+	case c <- resp:
+	default:
+		log.Warningf("Too many responses for callback %s, dropping", cid)
+	}
 }
 
 func getSearchers() []interface{} {
@@ -79,6 +100,12 @@ func getSearchers() []interface{} {
 			list = append(list, NewAddonSearcher(addon.ID))
 		}
 	}
+	for _, searcher := range GetTorznabSearchers() {
+		list = append(list, searcher)
+	}
+	for _, searcher := range GetExecutableSearchers() {
+		list = append(list, searcher)
+	}
 	return list
 }
 
@@ -281,6 +308,15 @@ func (as *AddonSearcher) GetSeasonSearchObject(show *tmdb.Show, season *tmdb.Sea
 	return sObject
 }
 
+// GetEpisodeSearchSilentObject ...
+func (as *AddonSearcher) GetEpisodeSearchSilentObject(show *tmdb.Show, episode *tmdb.Episode, withAuth bool) *EpisodeSearchObject {
+	o := as.GetEpisodeSearchObject(show, episode)
+	o.Silent = true
+	o.SkipAuth = !withAuth
+
+	return o
+}
+
 // GetEpisodeSearchObject ...
 func (as *AddonSearcher) GetEpisodeSearchObject(show *tmdb.Show, episode *tmdb.Episode) *EpisodeSearchObject {
 	year, _ := strconv.Atoi(strings.Split(episode.AirDate, "-")[0])
@@ -351,15 +387,73 @@ func (as *AddonSearcher) GetEpisodeSearchObject(show *tmdb.Show, episode *tmdb.E
 	return sObject
 }
 
+// SetResultsChan makes searcher send results to the channel as soon as they arrive,
+// instead of returning them when the search is complete
+func (as *AddonSearcher) SetResultsChan(c chan<- *bittorrent.TorrentFile) {
+	as.results = c
+}
+
+// Capabilities returns what provider has advertised in its responses, until restart.
+// Providers, which have not advertised anything, are treated as protocol v1 ones, supporting all methods.
+func (as *AddonSearcher) Capabilities() *Capabilities {
+	capsLock.RLock()
+	defer capsLock.RUnlock()
+
+	if caps, ok := addonCapabilities[as.addonID]; ok {
+		return caps
+	}
+	return &Capabilities{ProtocolVersion: 1}
+}
+
+// updateCapabilities remembers protocol version and capabilities, advertised in provider's response
+func (as *AddonSearcher) updateCapabilities(resp *ProviderResponse) {
+	caps := resp.Capabilities
+	if caps == nil {
+		if resp.ProtocolVersion < 2 || as.Capabilities().ProtocolVersion == resp.ProtocolVersion {
+			return
+		}
+		caps = &Capabilities{ProtocolVersion: resp.ProtocolVersion}
+	}
+
+	capsLock.Lock()
+	addonCapabilities[as.addonID] = caps
+	capsLock.Unlock()
+
+	as.log.Debugf("Provider %s supports protocol v%d, methods: %v", as.addonID, caps.ProtocolVersion, caps.Methods)
+}
+
+// failure logs provider's error and counts it in provider's failures
+func (as *AddonSearcher) failure(e *ProviderError) {
+	as.log.Warningf("Provider %s failed with %s: %s", as.addonID, e.Code, e.Message)
+	xbmc.AddonFailure(as.addonID)
+}
+
 func (as *AddonSearcher) call(method string, searchObject interface{}) []*bittorrent.TorrentFile {
 	torrents := make([]*bittorrent.TorrentFile, 0)
+
+	if IsProviderSkipped(as.addonID) {
+		as.log.Infof("Provider %s is cooling off after timeouts, skipping", as.addonID)
+		return torrents
+	}
+
+	caps := as.Capabilities()
+	if !caps.Supports(method) {
+		as.log.Debugf("Provider %s does not support %s", as.addonID, method)
+		return torrents
+	} else if caps.NeedsAuth && skipsAuth(searchObject) {
+		as.log.Debugf("Provider %s needs authentication, skipping", as.addonID)
+		return torrents
+	}
+
 	cid, c := GetCallback()
+	defer RemoveCallback(cid)
 	cbURL := fmt.Sprintf("%s/callbacks/%s", util.GetHTTPHost(), cid)
 
 	payload := &SearchPayload{
-		Method:       method,
-		CallbackURL:  cbURL,
-		SearchObject: searchObject,
+		Method:          method,
+		CallbackURL:     cbURL,
+		ProtocolVersion: ProtocolVersion,
+		SearchObject:    searchObject,
 	}
 
 	xbmc.ExecuteAddon(as.addonID, payload.String())
@@ -369,15 +463,42 @@ func (as *AddonSearcher) call(method string, searchObject interface{}) []*bittor
 		timeout = time.Duration(config.Get().CustomProviderTimeout) * time.Second
 	}
 
-	select {
-	case <-time.After(timeout):
-		as.log.Warningf("Provider %s was too slow. Ignored.", as.addonID)
-		RemoveCallback(cid)
-	case result := <-c:
-		json.Unmarshal(result, &torrents)
-	}
+	// Partial results, received before the timeout, are kept
+	started := time.Now()
+	deadline := time.After(timeout)
+	received := 0
+	failed := false
+	for {
+		select {
+		case <-deadline:
+			as.log.Warningf("Provider %s was too slow, using %d received results.", as.addonID, received)
+			recordCall(as.addonID, started, received, true, failed)
+			return torrents
+		case resp := <-c:
+			as.updateCapabilities(resp)
+			if resp.Error != nil {
+				as.failure(resp.Error)
+				failed = true
+			}
+
+			received += len(resp.Results)
+			for _, torrent := range resp.Results {
+				torrent.Searcher = as.addonID
+			}
+			if as.results != nil {
+				for _, torrent := range resp.Results {
+					as.results <- torrent
+				}
+			} else {
+				torrents = append(torrents, resp.Results...)
+			}
 
-	return torrents
+			if resp.Final {
+				recordCall(as.addonID, started, received, false, failed)
+				return torrents
+			}
+		}
+	}
 }
 
 // SearchLinks ...
@@ -404,3 +525,8 @@ func (as *AddonSearcher) SearchSeasonLinks(show *tmdb.Show, season *tmdb.Season)
 func (as *AddonSearcher) SearchEpisodeLinks(show *tmdb.Show, episode *tmdb.Episode) []*bittorrent.TorrentFile {
 	return as.call("search_episode", as.GetEpisodeSearchObject(show, episode))
 }
+
+// SearchEpisodeLinksSilent ...
+func (as *AddonSearcher) SearchEpisodeLinksSilent(show *tmdb.Show, episode *tmdb.Episode, withAuth bool) []*bittorrent.TorrentFile {
+	return as.call("search_episode", as.GetEpisodeSearchSilentObject(show, episode, withAuth))
+}
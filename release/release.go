@@ -0,0 +1,257 @@
+package release
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Info is a result of release name parsing
+type Info struct {
+	Title string `json:"title"`
+	Year  int    `json:"year,omitempty"`
+
+	Seasons          []int `json:"seasons,omitempty"`
+	Episodes         []int `json:"episodes,omitempty"`
+	IsAbsolute       bool  `json:"is_absolute,omitempty"`
+	IsMultiEpisode   bool  `json:"is_multi_episode,omitempty"`
+	IsSeasonPack     bool  `json:"is_season_pack,omitempty"`
+	IsCompleteSeries bool  `json:"is_complete_series,omitempty"`
+
+	Resolution    int      `json:"resolution"`
+	Source        int      `json:"source"`
+	VideoCodec    int      `json:"video_codec"`
+	HDR           string   `json:"hdr,omitempty"`
+	AudioCodec    int      `json:"audio_codec"`
+	AudioChannels string   `json:"audio_channels,omitempty"`
+	Atmos         bool     `json:"atmos,omitempty"`
+	Languages     []string `json:"languages,omitempty"`
+	Dubbed        bool     `json:"dubbed,omitempty"`
+
+	Group   string `json:"group,omitempty"`
+	Proper  bool   `json:"proper,omitempty"`
+	Repack  bool   `json:"repack,omitempty"`
+	Nuked   bool   `json:"nuked,omitempty"`
+	Edition string `json:"edition,omitempty"`
+}
+
+var (
+	extensionRegexp = regexp.MustCompile(`(?i)\.(mkv|mp4|m4v|avi|wmv|mov|webm|ts|m2ts|iso|torrent)$`)
+	prefixRegexp    = regexp.MustCompile(`^\s*\[([^\]]+)\]\s*`)
+	groupRegexp     = regexp.MustCompile(`-\s?([A-Za-z0-9]+)(\s?\[[^\]]+\])?\s*$`)
+	yearRegexp      = regexp.MustCompile(`\b(19\d{2}|20\d{2})\b`)
+	channelsRegexp  = regexp.MustCompile(`(?i)(^|[^\d])([1-7])[\. ]([01])(ch)?([^\d]|$)`)
+
+	episodesRegexp    = regexp.MustCompile(`(?i)\bs(\d{1,3})\s?((e\d{1,4}(\s?-\s?e?\d{1,4})?\s?)+)`)
+	episodeRegexp     = regexp.MustCompile(`(?i)e(\d{1,4})(\s?-\s?e?(\d{1,4}))?`)
+	crossRegexp       = regexp.MustCompile(`(?i)\b(\d{1,2})x(\d{2,3})(\s?-\s?(\d{1,2}x)?(\d{2,3}))?\b`)
+	seasonRangeRegexp = regexp.MustCompile(`(?i)\b(s|seasons?\s?)(\d{1,2})\s?(-|to|&)\s?s?(\d{1,2})\b`)
+	seasonRegexp      = regexp.MustCompile(`(?i)\b(s|season\s?)(\d{1,2})\b`)
+	absoluteRegexp    = regexp.MustCompile(`\s-\s(\d{2,4})(v\d)?(\s|$)`)
+)
+
+// Parse extracts release information from a torrent or a file name
+func Parse(name string) *Info {
+	ret := &Info{}
+
+	name = extensionRegexp.ReplaceAllString(strings.TrimSpace(name), "")
+
+	// Anime releases put the group in front: "[Group] Title - 01 [1080p]"
+	if m := prefixRegexp.FindStringSubmatch(name); m != nil {
+		ret.Group = m[1]
+		name = name[len(m[0]):]
+	} else if m := groupRegexp.FindStringSubmatch(name); m != nil {
+		ret.Group = m[1]
+	}
+
+	// Both are of the same length as name, so positions can be shared
+	spaced := strings.Map(func(r rune) rune {
+		if r == '.' || r == '_' {
+			return ' '
+		}
+		return r
+	}, name)
+	normalized := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(".,_-[](){}:;/\\", r) {
+			return ' '
+		}
+		return r
+	}, name)
+
+	anchor := len(name)
+	setAnchor := func(pos int) {
+		if pos >= 0 && pos < anchor {
+			anchor = pos
+		}
+	}
+
+	setAnchor(ret.parseEpisodes(spaced))
+
+	// Year is the last one, so "2001 A Space Odyssey 1968" and "Blade Runner 2049 2017" keep the title
+	if locs := yearRegexp.FindAllStringSubmatchIndex(normalized, -1); len(locs) > 0 {
+		if loc := locs[len(locs)-1]; loc[0] > 0 {
+			ret.Year, _ = strconv.Atoi(normalized[loc[2]:loc[3]])
+			setAnchor(loc[0])
+		}
+	}
+
+	var pos int
+	if ret.Resolution, pos = match(normalized, resolutionTags); pos >= 0 {
+		setAnchor(pos)
+	}
+	if ret.VideoCodec, pos = match(normalized, videoTags); pos >= 0 {
+		setAnchor(pos)
+	}
+	if anchor == len(name) {
+		_, pos = match(normalized, sourceTags)
+		setAnchor(pos)
+	}
+
+	// Tags are matched only after the title, so short tags do not match words of the title
+	tail := normalized[anchor:]
+	if anchor == 0 {
+		tail = normalized
+	}
+
+	ret.Title = strings.Join(strings.Fields(normalized[:anchor]), " ")
+	ret.Source, _ = match(tail, sourceTags)
+	if ret.Resolution == ResolutionUnknown {
+		ret.Resolution, _ = match(tail, resolutionFallbackTags)
+	}
+	if ret.VideoCodec == CodecUnknown {
+		ret.VideoCodec, _ = match(tail, videoTags)
+	}
+	ret.AudioCodec, _ = match(tail, audioTags)
+	ret.HDR = matchLabel(tail, hdrTags)
+	ret.Edition = matchLabel(tail, editionTags)
+
+	for _, l := range languageTags {
+		if l.re.MatchString(tail) {
+			ret.Languages = append(ret.Languages, l.value)
+		}
+	}
+
+	if m := channelsRegexp.FindStringSubmatch(name[anchor:]); m != nil {
+		ret.AudioChannels = m[2] + "." + m[3]
+	}
+
+	ret.Atmos = atmosTag.MatchString(tail)
+	ret.Dubbed = dubbedTag.MatchString(tail)
+	ret.Proper = properTag.MatchString(tail)
+	ret.Repack = repackTag.MatchString(tail)
+	ret.Nuked = nukedTag.MatchString(tail)
+	ret.IsCompleteSeries = completeTag.MatchString(normalized) && len(ret.Episodes) == 0 && len(ret.Seasons) != 1
+
+	return ret
+}
+
+// parseEpisodes fills seasons and episodes and returns the position of the first match, or -1
+func (r *Info) parseEpisodes(name string) int {
+	if loc := episodesRegexp.FindStringSubmatchIndex(name); loc != nil {
+		season, _ := strconv.Atoi(name[loc[2]:loc[3]])
+		r.Seasons = []int{season}
+
+		for _, m := range episodeRegexp.FindAllStringSubmatch(name[loc[4]:loc[5]], -1) {
+			from, _ := strconv.Atoi(m[1])
+			to := from
+			if m[3] != "" {
+				to, _ = strconv.Atoi(m[3])
+			}
+			r.Episodes = appendRange(r.Episodes, from, to)
+		}
+		r.IsMultiEpisode = len(r.Episodes) > 1
+		return loc[0]
+	}
+
+	if loc := crossRegexp.FindStringSubmatchIndex(name); loc != nil {
+		season, _ := strconv.Atoi(name[loc[2]:loc[3]])
+		from, _ := strconv.Atoi(name[loc[4]:loc[5]])
+		to := from
+		if loc[10] >= 0 {
+			to, _ = strconv.Atoi(name[loc[10]:loc[11]])
+		}
+
+		r.Seasons = []int{season}
+		r.Episodes = appendRange(nil, from, to)
+		r.IsMultiEpisode = len(r.Episodes) > 1
+		return loc[0]
+	}
+
+	if loc := seasonRangeRegexp.FindStringSubmatchIndex(name); loc != nil {
+		from, _ := strconv.Atoi(name[loc[4]:loc[5]])
+		to, _ := strconv.Atoi(name[loc[8]:loc[9]])
+		if name[loc[6]:loc[7]] == "&" {
+			r.Seasons = []int{from, to}
+		} else {
+			r.Seasons = appendRange(nil, from, to)
+		}
+		r.IsSeasonPack = true
+		return loc[0]
+	}
+
+	if loc := seasonRegexp.FindStringSubmatchIndex(name); loc != nil {
+		season, _ := strconv.Atoi(name[loc[4]:loc[5]])
+		r.Seasons = []int{season}
+		r.IsSeasonPack = true
+		return loc[0]
+	}
+
+	if loc := absoluteRegexp.FindStringSubmatchIndex(name); loc != nil {
+		episode, _ := strconv.Atoi(name[loc[2]:loc[3]])
+		if episode < 1900 || episode > 2100 {
+			r.Episodes = []int{episode}
+			r.IsAbsolute = true
+			return loc[0]
+		}
+	}
+
+	return -1
+}
+
+// HasEpisode checks whether release contains the episode, season packs are not counted,
+// as they do not say which file is the episode
+func (r *Info) HasEpisode(season, episode int) bool {
+	if r.IsAbsolute || !containsInt(r.Seasons, season) {
+		return false
+	}
+	return containsInt(r.Episodes, episode)
+}
+
+// HasSeason checks whether release contains the whole season
+func (r *Info) HasSeason(season int) bool {
+	return r.IsCompleteSeries || (r.IsSeasonPack && containsInt(r.Seasons, season))
+}
+
+// IsEpisode ...
+func (r *Info) IsEpisode() bool {
+	return len(r.Episodes) > 0
+}
+
+func matchLabel(name string, labels []label) string {
+	for _, l := range labels {
+		if l.re.MatchString(name) {
+			return l.value
+		}
+	}
+	return ""
+}
+
+// appendRange adds numbers from..to, reversed or too long ranges are treated as two numbers
+func appendRange(list []int, from, to int) []int {
+	if to < from || to-from > 100 {
+		return append(list, from, to)
+	}
+	for i := from; i <= to; i++ {
+		list = append(list, i)
+	}
+	return list
+}
+
+func containsInt(list []int, value int) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,374 @@
+package release
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTitle(t *testing.T) {
+	tests := []struct {
+		name  string
+		title string
+		year  int
+	}{
+		{"The.Matrix.1999.1080p.BluRay.x264-GROUP", "The Matrix", 1999},
+		{"The Matrix (1999) [1080p]", "The Matrix", 1999},
+		{"The_Matrix_1999_720p_HDTV", "The Matrix", 1999},
+		{"Blade.Runner.2049.2017.2160p.UHD.BluRay.x265-TERMiNAL", "Blade Runner 2049", 2017},
+		{"2001.A.Space.Odyssey.1968.1080p.BluRay.x264", "2001 A Space Odyssey", 1968},
+		{"1917.2019.1080p.WEB-DL.DD5.1.H264-FGT", "1917", 2019},
+		{"Movie.2019.2K.WEBRip", "Movie", 2019},
+		{"Inception.2010.720p.BRRip.x264.AAC-ETRG.mkv", "Inception", 2010},
+		{"Parasite.2019.KOREAN.1080p.BluRay.x264.DTS-FGT", "Parasite", 2019},
+		{"Amelie.2001.FRENCH.720p.BluRay.x264", "Amelie", 2001},
+		{"Joker 2019 1080p WEB-DL H264 AC3-EVO", "Joker", 2019},
+		{"Dune.Part.Two.2024.2160p.WEB-DL.DDP5.1.Atmos.DV.HDR.H.265-FLUX", "Dune Part Two", 2024},
+		{"Alien.Directors.Cut.1979.1080p.BluRay.x264", "Alien Directors Cut", 1979},
+		{"Some Movie 720p", "Some Movie", 0},
+		{"Some.Movie.x264", "Some Movie", 0},
+		{"Some Movie", "Some Movie", 0},
+		{"Breaking.Bad.S01E01.720p.HDTV.x264-CTU", "Breaking Bad", 0},
+		{"Game.of.Thrones.S08E03.1080p.WEB.H264-MEMENTO", "Game of Thrones", 0},
+		{"The.Office.US.S02.1080p.BluRay.x264-SHORTBREHD", "The Office US", 0},
+		{"Doctor.Who.2005.S10E01.720p.HDTV.x264", "Doctor Who", 2005},
+		{"[HorribleSubs] One Punch Man - 01 [1080p].mkv", "One Punch Man", 0},
+		{"[SubsPlease] Jujutsu Kaisen - 24 (720p) [ABCD1234].mkv", "Jujutsu Kaisen", 0},
+	}
+
+	for _, test := range tests {
+		info := Parse(test.name)
+		if info.Title != test.title {
+			t.Errorf("%s: got title %q, want %q", test.name, info.Title, test.title)
+		}
+		if info.Year != test.year {
+			t.Errorf("%s: got year %d, want %d", test.name, info.Year, test.year)
+		}
+	}
+}
+
+func TestParseEpisodes(t *testing.T) {
+	tests := []struct {
+		name       string
+		seasons    []int
+		episodes   []int
+		absolute   bool
+		multi      bool
+		seasonPack bool
+		complete   bool
+	}{
+		{"Breaking.Bad.S01E01.720p.HDTV.x264-CTU", []int{1}, []int{1}, false, false, false, false},
+		{"Breaking Bad S05E16 Felina 1080p", []int{5}, []int{16}, false, false, false, false},
+		{"Show.s3e7.480p", []int{3}, []int{7}, false, false, false, false},
+		{"Show.S01E01E02.720p", []int{1}, []int{1, 2}, false, true, false, false},
+		{"Show.S01E01-E03.720p", []int{1}, []int{1, 2, 3}, false, true, false, false},
+		{"Show.S01E01-03.720p", []int{1}, []int{1, 2, 3}, false, true, false, false},
+		{"Show S01 E05 720p", []int{1}, []int{5}, false, false, false, false},
+		{"Show.S100E1000.1080p", []int{100}, []int{1000}, false, false, false, false},
+		{"Show.1x05.HDTV", []int{1}, []int{5}, false, false, false, false},
+		{"Show.2x10-2x11.HDTV", []int{2}, []int{10, 11}, false, true, false, false},
+		{"Show.2x10-11.HDTV", []int{2}, []int{10, 11}, false, true, false, false},
+		{"The.Office.US.S02.1080p.BluRay.x264-SHORTBREHD", []int{2}, nil, false, false, true, false},
+		{"Show Season 3 1080p WEB-DL", []int{3}, nil, false, false, true, false},
+		{"Show.S01-S03.720p", []int{1, 2, 3}, nil, false, false, true, false},
+		{"Show Seasons 1 to 4 1080p", []int{1, 2, 3, 4}, nil, false, false, true, false},
+		{"Show S01 & S03 720p", []int{1, 3}, nil, false, false, true, false},
+		{"Show.Complete.Series.1080p.BluRay", nil, nil, false, false, false, true},
+		{"Show.S01-S05.Complete.720p", []int{1, 2, 3, 4, 5}, nil, false, false, true, true},
+		{"Show.S02.Complete.720p", []int{2}, nil, false, false, true, false},
+		{"[HorribleSubs] One Punch Man - 01 [1080p].mkv", nil, []int{1}, true, false, false, false},
+		{"[SubsPlease] One Piece - 1071 (1080p) [ABCD1234].mkv", nil, []int{1071}, true, false, false, false},
+		{"[Group] Show - 12v2 [720p].mkv", nil, []int{12}, true, false, false, false},
+		{"Band - 2019 - Live", nil, nil, false, false, false, false},
+		{"The.Matrix.1999.1080p.BluRay.x264-GROUP", nil, nil, false, false, false, false},
+	}
+
+	for _, test := range tests {
+		info := Parse(test.name)
+		if !reflect.DeepEqual(info.Seasons, test.seasons) {
+			t.Errorf("%s: got seasons %v, want %v", test.name, info.Seasons, test.seasons)
+		}
+		if !reflect.DeepEqual(info.Episodes, test.episodes) {
+			t.Errorf("%s: got episodes %v, want %v", test.name, info.Episodes, test.episodes)
+		}
+		if info.IsAbsolute != test.absolute {
+			t.Errorf("%s: got absolute %v, want %v", test.name, info.IsAbsolute, test.absolute)
+		}
+		if info.IsMultiEpisode != test.multi {
+			t.Errorf("%s: got multi-episode %v, want %v", test.name, info.IsMultiEpisode, test.multi)
+		}
+		if info.IsSeasonPack != test.seasonPack {
+			t.Errorf("%s: got season pack %v, want %v", test.name, info.IsSeasonPack, test.seasonPack)
+		}
+		if info.IsCompleteSeries != test.complete {
+			t.Errorf("%s: got complete series %v, want %v", test.name, info.IsCompleteSeries, test.complete)
+		}
+	}
+}
+
+func TestParseResolution(t *testing.T) {
+	tests := []struct {
+		name       string
+		resolution int
+	}{
+		{"Movie.2019.2160p.WEB-DL", Resolution4k},
+		{"Movie.2019.4K.HDR", Resolution4k},
+		{"Movie.2019.UHD.BluRay", Resolution4k},
+		{"Movie 2019 3840x2160", Resolution4k},
+		{"Movie.2019.HD4K", Resolution4k},
+		{"Movie.2019.2K.WEBRip", Resolution2K},
+		{"Movie.2019.1440p.WEB", Resolution2K},
+		{"Movie.2019.1080p.BluRay", Resolution1080p},
+		{"Movie.2019.1080i.HDTV", Resolution1080p},
+		{"Movie 2019 1920x1080", Resolution1080p},
+		{"Фильм 2019 1080р", Resolution1080p},
+		{"Movie.2019.720p.HDTV", Resolution720p},
+		{"Movie 2019 1280x720", Resolution720p},
+		{"Movie.2019.480p.DVDRip", Resolution480p},
+		{"Movie.2019.576p.DVD", Resolution480p},
+		{"Movie.2019.360p", Resolution240p},
+		{"Movie.2019.240p", Resolution240p},
+		{"Movie.2019.FullHD", Resolution1080p},
+		{"Movie.2019.BluRay", Resolution1080p},
+		{"Movie.2019.BDRemux", Resolution1080p},
+		{"Movie.2019.HDRip", Resolution720p},
+		{"Movie.2019.BDRip", Resolution720p},
+		{"Movie.2019.BRRip", Resolution720p},
+		{"Movie.2019.DVDRip", Resolution480p},
+		{"Movie.2019.WEBRip", Resolution480p},
+		{"Movie.2019.HDTV", Resolution480p},
+		{"Movie.2019.XviD", Resolution480p},
+		{"Movie.2019.VHSRip", Resolution240p},
+		{"Movie.2019", ResolutionUnknown},
+		{"Movie.2019.720p.BluRay", Resolution720p},
+		{"2K.Movie.2019.1080p", Resolution1080p},
+	}
+
+	for _, test := range tests {
+		if info := Parse(test.name); info.Resolution != test.resolution {
+			t.Errorf("%s: got resolution %d, want %d", test.name, info.Resolution, test.resolution)
+		}
+	}
+}
+
+func TestParseSource(t *testing.T) {
+	tests := []struct {
+		name   string
+		source int
+	}{
+		{"Movie.2019.1080p.BluRay.x264", SourceBluRay},
+		{"Movie.2019.1080p.Blu-ray.Remux", SourceBluRay},
+		{"Movie.2019.720p.BDRip", SourceBluRay},
+		{"Movie.2019.720p.BRRip", SourceBluRay},
+		{"Movie.2019.2160p.UHD.BD", SourceBluRay},
+		{"Movie.2019.1080p.WEB-DL", SourceWeb},
+		{"Movie.2019.1080p.WEBRip", SourceWeb},
+		{"Movie.2019.1080p.WEB.H264", SourceWeb},
+		{"Movie.2019.1080p.AMZN.WEB-DL", SourceWeb},
+		{"Movie.2019.1080p.NF.WEBRip", SourceWeb},
+		{"Movie.2019.DVDScr.XviD", SourceDVDScr},
+		{"Movie.2019.DVDRip.XviD", SourceDVD},
+		{"Movie.2019.DVD5", SourceDVD},
+		{"Movie.2019.DVDR", SourceDVD},
+		{"Movie.2019.720p.HDTV.x264", SourceHDTV},
+		{"Movie.2019.HDRip", SourceHDTV},
+		{"Movie.2019.SATRip", SourceHDTV},
+		{"Movie.2019.SCREENER", SourceScr},
+		{"Movie.2019.TC", SourceTC},
+		{"Movie.2019.HDTC", SourceTC},
+		{"Movie.2019.TS.XviD", SourceTS},
+		{"Movie.2019.TELESYNC", SourceTS},
+		{"Movie.2019.HDCAM", SourceCam},
+		{"Movie.2019.CAMRip", SourceCam},
+		{"Movie.2019.1080p", SourceUnknown},
+		{"The.Cam.Girl.2019.1080p.WEB", SourceWeb},
+	}
+
+	for _, test := range tests {
+		if info := Parse(test.name); info.Source != test.source {
+			t.Errorf("%s: got source %d, want %d", test.name, info.Source, test.source)
+		}
+	}
+}
+
+func TestParseCodecs(t *testing.T) {
+	tests := []struct {
+		name     string
+		video    int
+		audio    int
+		channels string
+		atmos    bool
+	}{
+		{"Movie.2019.1080p.BluRay.x264.DTS-GROUP", CodecH264, CodecDTS, "", false},
+		{"Movie.2019.1080p.WEB-DL.DD5.1.H.264-GROUP", CodecH264, CodecAC3, "5.1", false},
+		{"Movie.2019.1080p.WEB-DL.DDP5.1.H.265-GROUP", CodecH265, CodecEAC3, "5.1", false},
+		{"Movie.2019.2160p.WEB-DL.DDP5.1.Atmos.HEVC-GROUP", CodecH265, CodecEAC3, "5.1", true},
+		{"Movie.2019.2160p.BluRay.REMUX.HEVC.TrueHD.7.1.Atmos-GROUP", CodecH265, CodecTrueHD, "7.1", true},
+		{"Movie.2019.1080p.BluRay.x264.DTS-HD.MA.5.1-GROUP", CodecH264, CodecDTSHDMA, "5.1", false},
+		{"Movie.2019.1080p.BluRay.x264.DTS-HD.5.1-GROUP", CodecH264, CodecDTSHD, "5.1", false},
+		{"Movie.2019.1080p.BluRay.x264.DTS-X-GROUP", CodecH264, CodecDTSHD, "", false},
+		{"Movie.2019.720p.BRRip.x264.AAC-ETRG", CodecH264, CodecAAC, "", false},
+		{"Movie.2019.720p.WEBRip.AAC2.0.x264", CodecH264, CodecAAC, "2.0", false},
+		{"Movie.2019.DVDRip.XviD.MP3-GROUP", CodecXVid, CodecMp3, "", false},
+		{"Movie.2019.DVDRip.DivX.AC3", CodecXVid, CodecAC3, "", false},
+		{"Movie.2019.1080p.WEB.AV1.Opus", CodecAV1, CodecUnknown, "", false},
+		{"Movie.2019.1080p.WEB.AVC.EAC3", CodecH264, CodecEAC3, "", false},
+		{"Movie.2019.1080p.BluRay.x265.10bit", CodecH265, CodecUnknown, "", false},
+		{"Movie.2019.1080p.BluRay", CodecUnknown, CodecUnknown, "", false},
+		{"Movie 2019 1080p WEB-DL H264 AC3-EVO", CodecH264, CodecAC3, "", false},
+	}
+
+	for _, test := range tests {
+		info := Parse(test.name)
+		if info.VideoCodec != test.video {
+			t.Errorf("%s: got video codec %d, want %d", test.name, info.VideoCodec, test.video)
+		}
+		if info.AudioCodec != test.audio {
+			t.Errorf("%s: got audio codec %d, want %d", test.name, info.AudioCodec, test.audio)
+		}
+		if info.AudioChannels != test.channels {
+			t.Errorf("%s: got audio channels %q, want %q", test.name, info.AudioChannels, test.channels)
+		}
+		if info.Atmos != test.atmos {
+			t.Errorf("%s: got atmos %v, want %v", test.name, info.Atmos, test.atmos)
+		}
+	}
+}
+
+func TestParseHDR(t *testing.T) {
+	tests := []struct {
+		name string
+		hdr  string
+	}{
+		{"Movie.2019.2160p.WEB-DL.HDR.HEVC", HDR10},
+		{"Movie.2019.2160p.WEB-DL.HDR10.HEVC", HDR10},
+		{"Movie.2019.2160p.WEB-DL.HDR10+.HEVC", HDR10Plus},
+		{"Movie.2019.2160p.WEB-DL.HDR10Plus.HEVC", HDR10Plus},
+		{"Movie.2019.2160p.WEB-DL.DV.HEVC", DolbyVision},
+		{"Movie.2019.2160p.WEB-DL.DoVi.HEVC", DolbyVision},
+		{"Movie.2019.2160p.WEB-DL.Dolby.Vision.HEVC", DolbyVision},
+		{"Movie.2019.2160p.WEB-DL.DV.HDR.HEVC", DolbyVision},
+		{"Movie.2019.2160p.HLG.HEVC", HLG},
+		{"Movie.2019.1080p.BluRay.x264", ""},
+		{"Movie.2019.1080p.HDRip.x264", ""},
+	}
+
+	for _, test := range tests {
+		if info := Parse(test.name); info.HDR != test.hdr {
+			t.Errorf("%s: got HDR %q, want %q", test.name, info.HDR, test.hdr)
+		}
+	}
+}
+
+func TestParseLanguages(t *testing.T) {
+	tests := []struct {
+		name      string
+		languages []string
+		dubbed    bool
+	}{
+		{"Movie.2019.1080p.BluRay.x264", nil, false},
+		{"Movie.2019.MULTi.1080p.BluRay.x264", []string{"multi"}, false},
+		{"Movie.2019.1080p.BluRay.x264.ENG.RUS", []string{"en", "ru"}, false},
+		{"Movie.2019.FRENCH.720p.BluRay.x264", []string{"fr"}, false},
+		{"Movie.2019.TRUEFRENCH.720p.BluRay.x264", []string{"fr"}, false},
+		{"Movie.2019.German.DL.1080p.BluRay.x264", []string{"de"}, false},
+		{"Movie.2019.1080p.WEB-DL.Rus.Ukr.Eng", []string{"en", "ru", "uk"}, false},
+		{"Movie.2019.iTALiAN.1080p.BluRay", []string{"it"}, false},
+		{"Movie.2019.1080p.BluRay.Latino", []string{"es"}, false},
+		{"Movie.2019.1080p.BluRay.Dual.Audio", nil, true},
+		{"Movie.2019.1080p.BDRip.MVO", nil, true},
+		{"Movie.2019.1080p.BDRip.Dubbed.Hindi", []string{"hi"}, true},
+		{"Movie.2019.KOREAN.1080p.WEB", []string{"ko"}, false},
+		{"Russian.Doll.S01E01.1080p.WEB", nil, false},
+	}
+
+	for _, test := range tests {
+		info := Parse(test.name)
+		if !reflect.DeepEqual(info.Languages, test.languages) {
+			t.Errorf("%s: got languages %v, want %v", test.name, info.Languages, test.languages)
+		}
+		if info.Dubbed != test.dubbed {
+			t.Errorf("%s: got dubbed %v, want %v", test.name, info.Dubbed, test.dubbed)
+		}
+	}
+}
+
+func TestParseFlags(t *testing.T) {
+	tests := []struct {
+		name    string
+		group   string
+		edition string
+		proper  bool
+		repack  bool
+		nuked   bool
+	}{
+		{"The.Matrix.1999.1080p.BluRay.x264-GROUP", "GROUP", "", false, false, false},
+		{"The.Matrix.1999.1080p.BluRay.x264-GROUP.mkv", "GROUP", "", false, false, false},
+		{"The.Matrix.1999.1080p.BluRay.x264-GROUP [rarbg]", "GROUP", "", false, false, false},
+		{"[HorribleSubs] One Punch Man - 01 [1080p].mkv", "HorribleSubs", "", false, false, false},
+		{"Movie.2019.PROPER.1080p.WEB.x264-GROUP", "GROUP", "", true, false, false},
+		{"Movie.2019.REAL.1080p.WEB.x264-GROUP", "GROUP", "", true, false, false},
+		{"Movie.2019.REPACK.1080p.WEB.x264-GROUP", "GROUP", "", false, true, false},
+		{"Movie.2019.RERIP.1080p.WEB.x264-GROUP", "GROUP", "", false, true, false},
+		{"Movie.2019.1080p.WEB.x264.NUKED-GROUP", "GROUP", "", false, false, true},
+		{"Alien.1979.Directors.Cut.1080p.BluRay.x264-GROUP", "GROUP", "Director's Cut", false, false, false},
+		{"Movie.2019.Extended.Edition.1080p.BluRay", "", "Extended", false, false, false},
+		{"Movie.2019.EXTENDED.1080p.BluRay", "", "Extended", false, false, false},
+		{"Movie.2019.UNRATED.720p.BluRay", "", "Unrated", false, false, false},
+		{"Movie.2019.Theatrical.Cut.1080p", "", "Theatrical", false, false, false},
+		{"Movie.2019.REMASTERED.1080p", "", "Remastered", false, false, false},
+		{"Movie.2019.IMAX.2160p.WEB", "", "IMAX", false, false, false},
+		{"Movie.2019.Criterion.1080p.BluRay", "", "Criterion", false, false, false},
+		{"Real.Steel.2011.1080p.BluRay", "", "", false, false, false},
+	}
+
+	for _, test := range tests {
+		info := Parse(test.name)
+		if info.Group != test.group {
+			t.Errorf("%s: got group %q, want %q", test.name, info.Group, test.group)
+		}
+		if info.Edition != test.edition {
+			t.Errorf("%s: got edition %q, want %q", test.name, info.Edition, test.edition)
+		}
+		if info.Proper != test.proper {
+			t.Errorf("%s: got proper %v, want %v", test.name, info.Proper, test.proper)
+		}
+		if info.Repack != test.repack {
+			t.Errorf("%s: got repack %v, want %v", test.name, info.Repack, test.repack)
+		}
+		if info.Nuked != test.nuked {
+			t.Errorf("%s: got nuked %v, want %v", test.name, info.Nuked, test.nuked)
+		}
+	}
+}
+
+func TestHasEpisode(t *testing.T) {
+	tests := []struct {
+		name    string
+		season  int
+		episode int
+		has     bool
+		hasPack bool
+	}{
+		{"Show.S01E05.720p", 1, 5, true, false},
+		{"Show.S01E05.720p", 1, 6, false, false},
+		{"Show.S01E05.720p", 2, 5, false, false},
+		{"Show.S01E05E06.720p", 1, 6, true, false},
+		{"Show.S01E01-E10.720p", 1, 7, true, false},
+		{"Show.1x05.HDTV", 1, 5, true, false},
+		{"Show.S01.720p", 1, 5, false, true},
+		{"Show.S01-S03.720p", 2, 5, false, true},
+		{"Show.S01-S03.720p", 4, 5, false, false},
+		{"Show.Complete.Series.720p", 4, 5, false, true},
+		{"[Group] Show - 05 [720p]", 1, 5, false, false},
+	}
+
+	for _, test := range tests {
+		info := Parse(test.name)
+		if has := info.HasEpisode(test.season, test.episode); has != test.has {
+			t.Errorf("%s: HasEpisode(%d, %d) = %v, want %v", test.name, test.season, test.episode, has, test.has)
+		}
+		if hasPack := info.HasSeason(test.season); hasPack != test.hasPack {
+			t.Errorf("%s: HasSeason(%d) = %v, want %v", test.name, test.season, hasPack, test.hasPack)
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package release
+
+import (
+	"regexp"
+)
+
+const (
+	// ResolutionUnknown ...
+	ResolutionUnknown = iota
+	// Resolution240p ...
+	Resolution240p
+	// Resolution480p ...
+	Resolution480p
+	// Resolution720p ...
+	Resolution720p
+	// Resolution1080p ...
+	Resolution1080p
+	// Resolution2K ...
+	Resolution2K
+	// Resolution4k ...
+	Resolution4k
+)
+
+const (
+	// SourceUnknown ...
+	SourceUnknown = iota
+	// SourceCam ...
+	SourceCam
+	// SourceTS ...
+	SourceTS
+	// SourceTC ...
+	SourceTC
+	// SourceScr ...
+	SourceScr
+	// SourceDVDScr ...
+	SourceDVDScr
+	// SourceDVD ...
+	SourceDVD
+	// SourceHDTV ...
+	SourceHDTV
+	// SourceWeb ...
+	SourceWeb
+	// SourceBluRay ...
+	SourceBluRay
+)
+
+// Video and audio codecs share the same numbering
+const (
+	// CodecUnknown ...
+	CodecUnknown = iota
+
+	// CodecXVid ...
+	CodecXVid
+	// CodecH264 ...
+	CodecH264
+	// CodecH265 ...
+	CodecH265
+
+	// CodecMp3 ...
+	CodecMp3
+	// CodecAAC ...
+	CodecAAC
+	// CodecAC3 ...
+	CodecAC3
+	// CodecDTS ...
+	CodecDTS
+	// CodecDTSHD ...
+	CodecDTSHD
+	// CodecDTSHDMA ...
+	CodecDTSHDMA
+
+	// CodecAV1 ...
+	CodecAV1
+	// CodecEAC3 ...
+	CodecEAC3
+	// CodecTrueHD ...
+	CodecTrueHD
+)
+
+// HDR formats
+const (
+	HDR10       = "hdr10"
+	HDR10Plus   = "hdr10+"
+	HLG         = "hlg"
+	DolbyVision = "dolbyvision"
+)
+
+// tag is a single release tag, checked against the normalized name
+type tag struct {
+	re    *regexp.Regexp
+	value int
+}
+
+// label is a tag with a string value
+type label struct {
+	re    *regexp.Regexp
+	value string
+}
+
+// Tags are wrapped by separators, which are spaces after normalization
+func tagRegexp(expr string) *regexp.Regexp {
+	return regexp.MustCompile(`(?i)(^|\s)(` + expr + `)(\s|$)`)
+}
+
+func newTag(expr string, value int) tag {
+	return tag{tagRegexp(expr), value}
+}
+
+func newLabel(expr string, value string) label {
+	return label{tagRegexp(expr), value}
+}
+
+var (
+	// [pр] actually contains "p" from latin and "р" from cyrillic, which looks the same, but it's not.
+	resolutionTags = []tag{
+		newTag(`(4k|2160[pр]|uhd|3840x2160)`, Resolution4k),
+		newTag(`1440[pр]`, Resolution2K),
+		newTag(`(1080[piр]|1920x1080)`, Resolution1080p),
+		newTag(`(720[pр]|1280x720)`, Resolution720p),
+		newTag(`(480[pр]|576[pр])`, Resolution480p),
+		newTag(`(240[pр]|360[pр])`, Resolution240p),
+	}
+	// Resolution is guessed from source tags only when no explicit resolution is found
+	resolutionFallbackTags = []tag{
+		newTag(`hd4k`, Resolution4k),
+		newTag(`2k`, Resolution2K),
+		newTag(`(hd1080p?|full\s?hd|fhd|blu\s?ray|bd\s?remux|remux)`, Resolution1080p),
+		newTag(`(hd720p?|hd\s?rip|b[rd]\s?rip)`, Resolution720p),
+		newTag(`(tv\s?rip|sat\s?rip|iptv\s?rip|xvid|divx|dvd\w*|hdtv|sdtv|pdtv|web\s?(dl\s?)?rip)`, Resolution480p),
+		newTag(`vhs\s?rip`, Resolution240p),
+	}
+
+	// Sources are checked in order, so more specific ones go first
+	sourceTags = []tag{
+		newTag(`(blu\s?ray|bd\s?remux|remux|b[rd]\s?rip|bdmv|uhd\s?bd)`, SourceBluRay),
+		newTag(`(web\s?dl|web\s?rip|web|amzn|nf|dsnp|hmax|atvp)`, SourceWeb),
+		newTag(`(dvd\s?scr)`, SourceDVDScr),
+		newTag(`(dvd\s?rip|dvd\s?r|dvd\s?\d|dvd)`, SourceDVD),
+		newTag(`(hd\s?tv|hd\s?rip|sd\s?tv|pd\s?tv|tv\s?rip|sat\s?rip)`, SourceHDTV),
+		newTag(`(scr|screener)`, SourceScr),
+		newTag(`(tc|telecine|hd\s?tc)`, SourceTC),
+		newTag(`(ts|telesync|hd\s?ts|pdvd)`, SourceTS),
+		newTag(`(cam|cam\s?rip|hd\s?cam)`, SourceCam),
+	}
+
+	videoTags = []tag{
+		newTag(`av1`, CodecAV1),
+		newTag(`([hx]\s?265|hevc)`, CodecH265),
+		newTag(`([hx]\s?264|avc)`, CodecH264),
+		newTag(`(xvid|divx)`, CodecXVid),
+	}
+
+	audioTags = []tag{
+		newTag(`(truehd|true\s?hd)`, CodecTrueHD),
+		newTag(`dts\s?(-\s?)?hd\s?ma`, CodecDTSHDMA),
+		newTag(`(dts\s?(-\s?)?hd|dts\s?x)`, CodecDTSHD),
+		newTag(`dts`, CodecDTS),
+		newTag(`(e\s?-?ac\s?-?3|ddp\d?|dd\+\d?|dd\s?plus)`, CodecEAC3),
+		newTag(`(ac\s?-?3|dd\d?|dolby\s?digital)`, CodecAC3),
+		newTag(`aac\d?`, CodecAAC),
+		newTag(`mp3`, CodecMp3),
+	}
+
+	hdrTags = []label{
+		newLabel(`(dv|dovi|dolby\s?vision)`, DolbyVision),
+		newLabel(`(hdr10\+|hdr10plus|hdr10p)`, HDR10Plus),
+		newLabel(`(hdr10|hdr)`, HDR10),
+		newLabel(`hlg`, HLG),
+	}
+
+	// Language tags are mapped to ISO 639-1 codes, "multi" means several audio tracks
+	languageTags = []label{
+		newLabel(`(multi|multi\s?audio|multi\s?lang)`, "multi"),
+		newLabel(`(eng|english)`, "en"),
+		newLabel(`(rus|russian|ru)`, "ru"),
+		newLabel(`(ukr|ukrainian|ua)`, "uk"),
+		newLabel(`(ger|german|deutsch)`, "de"),
+		newLabel(`(fre|french|truefrench|vff|vfq|vf2|vostfr)`, "fr"),
+		newLabel(`(spa|spanish|castellano|esp|latino)`, "es"),
+		newLabel(`(ita|italian)`, "it"),
+		newLabel(`(por|portuguese|pt\s?br)`, "pt"),
+		newLabel(`(pol|polish|pl)`, "pl"),
+		newLabel(`(jpn|japanese)`, "ja"),
+		newLabel(`(kor|korean)`, "ko"),
+		newLabel(`(chi|chinese|chs|cht)`, "zh"),
+		newLabel(`(hin|hindi)`, "hi"),
+		newLabel(`(tur|turkish)`, "tr"),
+		newLabel(`(cze|czech)`, "cs"),
+		newLabel(`(hun|hungarian)`, "hu"),
+		newLabel(`(dut|dutch|nl)`, "nl"),
+	}
+
+	editionTags = []label{
+		newLabel(`(directors?\s?cut|dc)`, "Director's Cut"),
+		newLabel(`extended(\s?(cut|edition))?`, "Extended"),
+		newLabel(`unrated`, "Unrated"),
+		newLabel(`uncut`, "Uncut"),
+		newLabel(`theatrical(\s?cut)?`, "Theatrical"),
+		newLabel(`remastered`, "Remastered"),
+		newLabel(`imax`, "IMAX"),
+		newLabel(`criterion`, "Criterion"),
+		newLabel(`special\s?edition`, "Special Edition"),
+	}
+
+	dubbedTag   = tagRegexp(`(dub|dubbed|dual(\s?audio)?|mvo|dvo)`)
+	properTag   = tagRegexp(`(proper|real)`)
+	repackTag   = tagRegexp(`(repack|rerip)`)
+	nukedTag    = tagRegexp(`nuked`)
+	atmosTag    = tagRegexp(`atmos`)
+	completeTag = tagRegexp(`(complete(\s?series)?|all\s?seasons|integrale|collection)`)
+)
+
+// match returns the value of the first matched tag and its position, or -1
+func match(name string, tags []tag) (int, int) {
+	for _, t := range tags {
+		if loc := t.re.FindStringIndex(name); loc != nil {
+			return t.value, loc[0]
+		}
+	}
+	return 0, -1
+}
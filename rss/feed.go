@@ -0,0 +1,154 @@
+package rss
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/mrjdainc/da-inc/proxy"
+)
+
+// Item is a normalized entry of RSS or Atom feed
+type Item struct {
+	GUID  string `json:"guid"`
+	Title string `json:"title"`
+	URI   string `json:"uri"`
+	Size  int64  `json:"size"`
+}
+
+type feedDocument struct {
+	Items   []rssItem   `xml:"channel>item"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+// Torrent specific elements are matched by local name,
+// so torrent:magnetURI (ezRSS) and tv:info_hash (showRSS) work without namespaces.
+type rssItem struct {
+	Title     string `xml:"title"`
+	Link      string `xml:"link"`
+	GUID      string `xml:"guid"`
+	Enclosure struct {
+		URL    string `xml:"url,attr"`
+		Length int64  `xml:"length,attr"`
+		Type   string `xml:"type,attr"`
+	} `xml:"enclosure"`
+	MagnetURI     string `xml:"magnetURI"`
+	InfoHash      string `xml:"infoHash"`
+	TVInfoHash    string `xml:"info_hash"`
+	ContentLength int64  `xml:"contentLength"`
+	Size          int64  `xml:"size"`
+}
+
+type atomEntry struct {
+	ID    string `xml:"id"`
+	Title string `xml:"title"`
+	Links []struct {
+		Href   string `xml:"href,attr"`
+		Rel    string `xml:"rel,attr"`
+		Type   string `xml:"type,attr"`
+		Length int64  `xml:"length,attr"`
+	} `xml:"link"`
+}
+
+// Fetch downloads and parses RSS or Atom feed
+func Fetch(url string) ([]*Item, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := proxy.GetClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Bad status: %d", resp.StatusCode)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	return Parse(body)
+}
+
+// Parse reads items from RSS or Atom document
+func Parse(body []byte) ([]*Item, error) {
+	var doc feedDocument
+	if err := xml.Unmarshal(body, &doc); err != nil {
+		return nil, err
+	}
+
+	ret := make([]*Item, 0, len(doc.Items)+len(doc.Entries))
+	for _, i := range doc.Items {
+		item := &Item{
+			GUID:  strings.TrimSpace(i.GUID),
+			Title: strings.TrimSpace(i.Title),
+			Size:  i.ContentLength,
+		}
+		if item.Size == 0 {
+			item.Size = i.Size
+		}
+		if item.Size == 0 {
+			item.Size = i.Enclosure.Length
+		}
+
+		infoHash := i.InfoHash
+		if infoHash == "" {
+			infoHash = i.TVInfoHash
+		}
+
+		switch {
+		case i.MagnetURI != "":
+			item.URI = i.MagnetURI
+		case strings.HasPrefix(strings.TrimSpace(i.Link), "magnet:"):
+			item.URI = strings.TrimSpace(i.Link)
+		case i.Enclosure.URL != "" && (i.Enclosure.Type == "application/x-bittorrent" || strings.HasPrefix(i.Enclosure.URL, "magnet:") || strings.Contains(i.Enclosure.URL, ".torrent")):
+			item.URI = i.Enclosure.URL
+		case infoHash != "":
+			item.URI = "magnet:?xt=urn:btih:" + strings.ToLower(infoHash)
+		case i.Enclosure.URL != "":
+			item.URI = i.Enclosure.URL
+		default:
+			item.URI = strings.TrimSpace(i.Link)
+		}
+
+		ret = appendItem(ret, item)
+	}
+
+	for _, e := range doc.Entries {
+		item := &Item{
+			GUID:  strings.TrimSpace(e.ID),
+			Title: strings.TrimSpace(e.Title),
+		}
+		for _, l := range e.Links {
+			if l.Rel == "enclosure" || l.Type == "application/x-bittorrent" || strings.HasPrefix(l.Href, "magnet:") {
+				item.URI = l.Href
+				item.Size = l.Length
+				break
+			} else if item.URI == "" {
+				item.URI = l.Href
+			}
+		}
+
+		ret = appendItem(ret, item)
+	}
+
+	return ret, nil
+}
+
+func appendItem(items []*Item, item *Item) []*Item {
+	if item.URI == "" || item.Title == "" {
+		return items
+	}
+	if item.GUID == "" {
+		item.GUID = item.URI
+	}
+
+	return append(items, item)
+}
@@ -0,0 +1,290 @@
+package rss
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/op/go-logging"
+
+	"github.com/mrjdainc/da-inc/bittorrent"
+	"github.com/mrjdainc/da-inc/config"
+	"github.com/mrjdainc/da-inc/database"
+	"github.com/mrjdainc/da-inc/tmdb"
+	"github.com/mrjdainc/da-inc/util"
+)
+
+const (
+	checkInterval = 1 * time.Minute
+
+	// Item, that could not be added that many times, is skipped
+	maxAddFailures = 5
+	// Processed items are kept while they are in the feed, and for some time after
+	seenItemsTTL = 30 * 24 * time.Hour
+)
+
+var (
+	log = logging.MustGetLogger("rss")
+
+	closer = util.Event{}
+
+	// Items, that are being added, to not add them twice from parallel checks
+	adding   = map[string]bool{}
+	muAdding sync.Mutex
+
+	episodeMatchers = []*regexp.Regexp{
+		regexp.MustCompile(`(?i)\bs(\d{1,2})\W?e(\d{1,3})\b`),
+		regexp.MustCompile(`(?i)\b(\d{1,2})x(\d{2,3})\b`),
+	}
+	seasonMatcher = regexp.MustCompile(`(?i)\b(?:s|season\W?)(\d{1,2})\b`)
+)
+
+// Stop cancels feeds polling
+func Stop() {
+	closer.Set()
+}
+
+// Start runs polling of enabled feeds
+func Start(s *bittorrent.Service) {
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	closing := closer.C()
+	for {
+		select {
+		case <-closing:
+			return
+		case <-ticker.C:
+			if !config.Get().RSSEnabled || s.Closer.IsSet() {
+				continue
+			}
+
+			for _, feed := range database.GetStorm().GetRSSFeeds() {
+				interval := feed.Interval
+				if interval <= 0 {
+					interval = config.Get().RSSInterval
+				}
+
+				if !feed.Enabled || time.Since(feed.LastChecked) < time.Duration(interval)*time.Minute {
+					continue
+				}
+
+				CheckFeed(s, &feed)
+			}
+		}
+	}
+}
+
+// CheckFeed fetches feed items and adds torrents for items, that match any of feed rules
+func CheckFeed(s *bittorrent.Service, feed *database.RSSFeed) (added int, err error) {
+	defer func() {
+		feed.LastChecked = time.Now()
+		feed.LastError = ""
+		if err != nil {
+			feed.LastError = err.Error()
+		}
+		database.GetStorm().SaveRSSFeed(feed)
+	}()
+
+	log.Debugf("Checking feed %s", feed.URL)
+	items, err := Fetch(feed.URL)
+	if err != nil {
+		log.Warningf("Could not fetch feed %s: %s", feed.URL, err)
+		return
+	}
+
+	rules := database.GetStorm().GetRSSRules(feed.ID)
+	current := map[string]bool{}
+	for _, item := range items {
+		guid := fmt.Sprintf("%d:%s", feed.ID, item.GUID)
+		current[guid] = true
+
+		if seen := database.GetStorm().GetRSSSeenItem(guid); seen != nil && seen.Failures == 0 {
+			continue
+		}
+
+		var rule *database.RSSRule
+		for i := range rules {
+			if rules[i].Enabled && Matches(&rules[i], item) {
+				rule = &rules[i]
+				break
+			}
+		}
+		if rule == nil {
+			database.GetStorm().MarkRSSItemSeen(guid, feed.ID)
+			continue
+		}
+
+		if !startAdding(guid) {
+			continue
+		}
+
+		log.Infof("Item %s matches rule %s", item.Title, rule.Name)
+		if errAdd := addItem(s, rule, item); errAdd != nil {
+			log.Warningf("Could not add %s: %s", item.Title, errAdd)
+			// Item is tried again with next checks, until it fails too many times
+			if failures := database.GetStorm().AddRSSItemFailure(guid, feed.ID); failures >= maxAddFailures {
+				log.Warningf("Skipping %s after %d failed attempts", item.Title, failures)
+				database.GetStorm().MarkRSSItemSeen(guid, feed.ID)
+			}
+		} else {
+			added++
+			database.GetStorm().MarkRSSItemSeen(guid, feed.ID)
+		}
+
+		finishAdding(guid)
+	}
+
+	pruneSeenItems(feed.ID, current)
+
+	return
+}
+
+// startAdding reserves item for adding, unless it is added by another check right now or was added already
+func startAdding(guid string) bool {
+	muAdding.Lock()
+	defer muAdding.Unlock()
+
+	if adding[guid] {
+		return false
+	} else if seen := database.GetStorm().GetRSSSeenItem(guid); seen != nil && seen.Failures == 0 {
+		return false
+	}
+	adding[guid] = true
+	return true
+}
+
+func finishAdding(guid string) {
+	muAdding.Lock()
+	defer muAdding.Unlock()
+
+	delete(adding, guid)
+}
+
+// pruneSeenItems removes old processed items, that are not in the feed anymore
+func pruneSeenItems(feedID int, current map[string]bool) {
+	for _, i := range database.GetStorm().GetRSSSeenItems(feedID) {
+		if !current[i.GUID] && time.Since(i.Dt) > seenItemsTTL {
+			database.GetStorm().DeleteRSSSeenItem(i.GUID)
+		}
+	}
+}
+
+// Matches checks item against rule's filters
+func Matches(r *database.RSSRule, item *Item) bool {
+	if r.Include != "" {
+		re, err := regexp.Compile("(?i)" + r.Include)
+		if err != nil {
+			log.Warningf("Wrong include expression in rule %s: %s", r.Name, err)
+			return false
+		} else if !re.MatchString(item.Title) {
+			return false
+		}
+	}
+	if r.Exclude != "" {
+		re, err := regexp.Compile("(?i)" + r.Exclude)
+		if err != nil {
+			log.Warningf("Wrong exclude expression in rule %s: %s", r.Name, err)
+			return false
+		} else if re.MatchString(item.Title) {
+			return false
+		}
+	}
+
+	if item.Size > 0 {
+		if r.MinSize > 0 && item.Size < r.MinSize {
+			return false
+		} else if r.MaxSize > 0 && item.Size > r.MaxSize {
+			return false
+		}
+	}
+
+	if r.Resolution != bittorrent.ResolutionUnknown && toTorrentFile(item).Resolution != r.Resolution {
+		return false
+	}
+
+	return true
+}
+
+// ValidateRule checks that rule's expressions can be compiled
+func ValidateRule(r *database.RSSRule) error {
+	for _, expr := range []string{r.Include, r.Exclude} {
+		if _, err := regexp.Compile("(?i)" + expr); err != nil {
+			return err
+		}
+	}
+	if r.MaxSize > 0 && r.MinSize > r.MaxSize {
+		return fmt.Errorf("Minimal size is bigger than maximal")
+	}
+
+	return nil
+}
+
+func toTorrentFile(item *Item) *bittorrent.TorrentFile {
+	t := &bittorrent.TorrentFile{
+		URI:   item.URI,
+		Name:  item.Title,
+		Title: item.Title,
+	}
+	if item.Size > 0 {
+		t.Size = humanize.Bytes(uint64(item.Size))
+	}
+
+	t.Initialize()
+	return t
+}
+
+func addItem(s *bittorrent.Service, r *database.RSSRule, item *Item) error {
+	t, err := s.AddTorrent(item.URI, r.Paused)
+	if err != nil {
+		return err
+	}
+
+	t.DownloadAllFiles()
+	t.SetPriority(bittorrent.PriorityAutoScrape)
+
+	if r.ShowID == 0 {
+		return nil
+	}
+
+	season, episode := r.Season, r.Episode
+	if season == 0 && episode == 0 {
+		season, episode = parseEpisode(item.Title)
+	}
+
+	mediaID := 0
+	if episode > 0 {
+		if ep := tmdb.GetEpisode(r.ShowID, season, episode, config.Get().Language); ep != nil {
+			mediaID = ep.ID
+		}
+	}
+
+	files := []string{}
+	for _, f := range t.GetFiles() {
+		files = append(files, f.Path)
+	}
+
+	log.Infof("Assigning %s to show %d, season %d, episode %d", t.Name(), r.ShowID, season, episode)
+	database.GetStorm().UpdateBTItem(t.InfoHash(), mediaID, "episode", files, item.Title, r.ShowID, season, episode)
+	t.FetchDBItem()
+
+	return nil
+}
+
+func parseEpisode(title string) (season, episode int) {
+	for _, re := range episodeMatchers {
+		if m := re.FindStringSubmatch(title); m != nil {
+			season, _ = strconv.Atoi(m[1])
+			episode, _ = strconv.Atoi(m[2])
+			return
+		}
+	}
+
+	if m := seasonMatcher.FindStringSubmatch(title); m != nil {
+		season, _ = strconv.Atoi(m[1])
+	}
+	return
+}
@@ -33,7 +33,7 @@ const (
 	cacheKeyMoviesList      = "scraper.movies.list.%d"
 
 	cacheDurationMovieExists = 60 * 60 * 24 * 365
-	cacheKeyMovieExists      = "scraper.movie.exists.%d.%d.%t"
+	cacheKeyMovieExists      = "scraper.movie.exists.%d.%d.%t.%d"
 )
 
 const (
@@ -254,10 +254,17 @@ func getTorrents(m *trakt.Movie, withAuth bool) []*bittorrent.TorrentFile {
 		return nil
 	}
 
+	// Scrape runs can use own quality profile, so only wanted releases are counted
+	if id := config.Get().AutoScrapeProfile; id != 0 {
+		if profile := database.GetStorm().GetQualityProfile(id); profile != nil {
+			return providers.SearchMovieSilentWithProfile(searchers, movie, withAuth, profile)
+		}
+	}
+
 	return providers.SearchMovieSilent(searchers, movie, withAuth)
 }
 
 // GetMovieExistsKey ...
 func GetMovieExistsKey(tmdbID int) string {
-	return fmt.Sprintf(cacheKeyMovieExists, tmdbID, config.Get().AutoScrapeStrategy, config.Get().AutoScrapeLibraryEnabled)
+	return fmt.Sprintf(cacheKeyMovieExists, tmdbID, config.Get().AutoScrapeStrategy, config.Get().AutoScrapeLibraryEnabled, config.Get().AutoScrapeProfile)
 }
@@ -384,7 +384,7 @@ var (
 	WarmingUp = util.Event{}
 )
 
-var rl = util.NewRateLimiter(burstRate, burstTime, simultaneousConnections)
+var rl = util.NewRateLimiter("tmdb", burstRate, burstTime, simultaneousConnections)
 
 // CheckAPIKey ...
 func CheckAPIKey() {
@@ -73,7 +73,7 @@ const (
 	ProgressSortAiredOlder
 )
 
-var rl = util.NewRateLimiter(burstRate, burstTime, simultaneousConnections)
+var rl = util.NewRateLimiter("trakt", burstRate, burstTime, simultaneousConnections)
 
 // Object ...
 type Object struct {
@@ -873,10 +873,7 @@ func TokenRefreshHandler() {
 						xbmc.Notify("dainc", errUnm.Error(), config.AddonIcon())
 						log.Error(errUnm)
 					} else {
-						expiry := time.Now().Unix() + int64(token.ExpiresIn)
-						xbmc.SetSetting("trakt_token_expiry", strconv.Itoa(int(expiry)))
-						xbmc.SetSetting("trakt_token", token.AccessToken)
-						xbmc.SetSetting("trakt_refresh_token", token.RefreshToken)
+						saveToken(token)
 						log.Noticef("Token refreshed for Trakt authorization, next refresh in %s", time.Duration(token.ExpiresIn-259200)*time.Second)
 					}
 				} else {
@@ -889,6 +886,19 @@ func TokenRefreshHandler() {
 	}
 }
 
+// saveToken stores received token in settings and in current configuration,
+// as settings are not reloaded in headless mode
+func saveToken(token *Token) {
+	expiry := time.Now().Unix() + int64(token.ExpiresIn)
+	xbmc.SetSetting("trakt_token_expiry", strconv.Itoa(int(expiry)))
+	xbmc.SetSetting("trakt_token", token.AccessToken)
+	xbmc.SetSetting("trakt_refresh_token", token.RefreshToken)
+
+	config.Get().TraktToken = token.AccessToken
+	config.Get().TraktRefreshToken = token.RefreshToken
+	config.Get().TraktTokenExpiry = int(expiry)
+}
+
 // Authorize ...
 func Authorize(fromSettings bool) error {
 	code, err := GetCode()
@@ -920,12 +930,7 @@ func Authorize(fromSettings bool) error {
 		success += " (Save your settings!)"
 	}
 
-	expiry := time.Now().Unix() + int64(token.ExpiresIn)
-	xbmc.SetSetting("trakt_token_expiry", strconv.Itoa(int(expiry)))
-	xbmc.SetSetting("trakt_token", token.AccessToken)
-	xbmc.SetSetting("trakt_refresh_token", token.RefreshToken)
-
-	config.Get().TraktToken = token.AccessToken
+	saveToken(token)
 
 	// Getting username for currently authorized user
 	params := napping.Params{}.AsUrlValues()
@@ -9,9 +9,16 @@ import (
 	"time"
 
 	"github.com/op/go-logging"
+
+	"github.com/mrjdainc/da-inc/metrics"
 )
 
-var log = logging.MustGetLogger("ratelimit")
+var (
+	log = logging.MustGetLogger("ratelimit")
+
+	requestsMetric  = metrics.NewCounter("api_requests_total", "Requests made to external APIs.", "api")
+	cooldownsMetric = metrics.NewCounter("api_cooldowns_total", "Cooldowns requested by external APIs with Retry-After.", "api")
+)
 
 // A RateLimiter limits the rate at which an action can be performed.  It
 // applies neither smoothing (like one could achieve in a token bucket system)
@@ -19,6 +26,7 @@ var log = logging.MustGetLogger("ratelimit")
 // granted are steadily increased until a steady throughput equilibrium is
 // reached.
 type RateLimiter struct {
+	name         string
 	limit        int
 	interval     time.Duration
 	mtx          sync.Mutex
@@ -34,9 +42,11 @@ var (
 	ErrHTTP     = errors.New("HTTP error")
 )
 
-// NewRateLimiter creates a new rate limiter for the limit and interval.
-func NewRateLimiter(limit int, interval time.Duration, parallelCount int) *RateLimiter {
+// NewRateLimiter creates a new rate limiter for the limit and interval,
+// name is used to report metrics of the API it is limiting.
+func NewRateLimiter(name string, limit int, interval time.Duration, parallelCount int) *RateLimiter {
 	lim := &RateLimiter{
+		name:         name,
 		limit:        limit,
 		interval:     interval,
 		parallelChan: make(chan bool, parallelCount),
@@ -105,6 +115,8 @@ func (r *RateLimiter) CoolDown(headers http.Header) {
 			return
 		}
 
+		cooldownsMetric.Inc(r.name)
+
 		r.mtx.Lock()
 		log.Debugf("Met a cooldown, sleeping for %#v seconds. Headers: %#v", coolDown, headers)
 
@@ -136,6 +148,8 @@ func (r *RateLimiter) Call(f func() error) {
 
 	tries := 0
 	for {
+		requestsMetric.Inc(r.name)
+
 		err := f()
 		// If fail occur, we should rerun
 		if err == nil || err != ErrExceeded || tries >= 2 {
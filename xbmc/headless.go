@@ -0,0 +1,34 @@
+package xbmc
+
+import (
+	"errors"
+)
+
+// Headless is set when daemon runs without Kodi instance.
+// All JSON-RPC calls are not sent anywhere and UI calls are written to the log.
+var Headless = false
+
+var errHeadless = errors.New("Kodi is not available in headless mode")
+
+// HeadlessSetSetting saves addon settings in headless mode, instead of Kodi
+var HeadlessSetSetting func(id string, value interface{}) error
+
+// headlessLogged contains methods, that are showing something to the user,
+// so in headless mode we should at least write them into the log.
+var headlessLogged = map[string]bool{
+	"Notify":                  true,
+	"Dialog":                  true,
+	"Dialog_Confirm":          true,
+	"Dialog_Text":             true,
+	"DialogProgress_Create":   true,
+	"DialogProgressBG_Create": true,
+	"OverlayStatus_Create":    true,
+}
+
+func executeHeadless(method string, args interface{}) error {
+	if headlessLogged[method] {
+		log.Infof("[headless] %s: %v", method, args)
+	}
+
+	return errHeadless
+}
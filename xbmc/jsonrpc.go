@@ -41,6 +41,9 @@ func getConnection(hosts ...string) (net.Conn, error) {
 }
 
 func executeJSONRPC(method string, retVal interface{}, args Args) error {
+	if Headless {
+		return executeHeadless(method, args)
+	}
 	if args == nil {
 		args = Args{}
 	}
@@ -59,6 +62,9 @@ func executeJSONRPC(method string, retVal interface{}, args Args) error {
 }
 
 func executeJSONRPCO(method string, retVal interface{}, args Object) error {
+	if Headless {
+		return executeHeadless(method, args)
+	}
 	if args == nil {
 		args = Object{}
 	}
@@ -77,6 +83,9 @@ func executeJSONRPCO(method string, retVal interface{}, args Object) error {
 }
 
 func executeJSONRPCEx(method string, retVal interface{}, args Args) error {
+	if Headless {
+		return executeHeadless(method, args)
+	}
 	if args == nil {
 		args = Args{}
 	}
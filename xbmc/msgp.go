@@ -1928,9 +1928,9 @@ func (z *VideoLibraryEpisode) Msgsize() (s int) {
 // MarshalMsg implements msgp.Marshaler
 func (z *VideoLibraryEpisodeItem) MarshalMsg(b []byte) (o []byte, err error) {
 	o = msgp.Require(b, z.Msgsize())
-	// map header, size 10
+	// map header, size 11
 	// string "ID"
-	o = append(o, 0x8a, 0xa2, 0x49, 0x44)
+	o = append(o, 0x8b, 0xa2, 0x49, 0x44)
 	o = msgp.AppendInt(o, z.ID)
 	// string "Title"
 	o = append(o, 0xa5, 0x54, 0x69, 0x74, 0x6c, 0x65)
@@ -1955,6 +1955,11 @@ func (z *VideoLibraryEpisodeItem) MarshalMsg(b []byte) (o []byte, err error) {
 	// string "Time"
 	o = append(o, 0xa9, 0x44, 0x61, 0x74, 0x65, 0x41, 0x64, 0x64, 0x65, 0x64, 0x81, 0xa4, 0x54, 0x69, 0x6d, 0x65)
 	o = msgp.AppendTime(o, z.DateAdded.Time)
+	// string "LastPlayed"
+	// map header, size 1
+	// string "Time"
+	o = append(o, 0xaa, 0x4c, 0x61, 0x73, 0x74, 0x50, 0x6c, 0x61, 0x79, 0x65, 0x64, 0x81, 0xa4, 0x54, 0x69, 0x6d, 0x65)
+	o = msgp.AppendTime(o, z.LastPlayed.Time)
 	// string "UniqueIDs"
 	o = append(o, 0xa9, 0x55, 0x6e, 0x69, 0x71, 0x75, 0x65, 0x49, 0x44, 0x73)
 	o, err = z.UniqueIDs.MarshalMsg(o)
@@ -2053,6 +2058,31 @@ func (z *VideoLibraryEpisodeItem) UnmarshalMsg(bts []byte) (o []byte, err error)
 					}
 				}
 			}
+		case "LastPlayed":
+			var zb0003 uint32
+			zb0003, bts, err = msgp.ReadMapHeaderBytes(bts)
+			if err != nil {
+				return
+			}
+			for zb0003 > 0 {
+				zb0003--
+				field, bts, err = msgp.ReadMapKeyZC(bts)
+				if err != nil {
+					return
+				}
+				switch msgp.UnsafeString(field) {
+				case "Time":
+					z.LastPlayed.Time, bts, err = msgp.ReadTimeBytes(bts)
+					if err != nil {
+						return
+					}
+				default:
+					bts, err = msgp.Skip(bts)
+					if err != nil {
+						return
+					}
+				}
+			}
 		case "UniqueIDs":
 			bts, err = z.UniqueIDs.UnmarshalMsg(bts)
 			if err != nil {
@@ -2069,13 +2099,13 @@ func (z *VideoLibraryEpisodeItem) UnmarshalMsg(bts []byte) (o []byte, err error)
 				if z.Resume == nil {
 					z.Resume = new(Resume)
 				}
-				var zb0003 uint32
-				zb0003, bts, err = msgp.ReadMapHeaderBytes(bts)
+				var zb0004 uint32
+				zb0004, bts, err = msgp.ReadMapHeaderBytes(bts)
 				if err != nil {
 					return
 				}
-				for zb0003 > 0 {
-					zb0003--
+				for zb0004 > 0 {
+					zb0004--
 					field, bts, err = msgp.ReadMapKeyZC(bts)
 					if err != nil {
 						return
@@ -2112,7 +2142,7 @@ func (z *VideoLibraryEpisodeItem) UnmarshalMsg(bts []byte) (o []byte, err error)
 
 // Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
 func (z *VideoLibraryEpisodeItem) Msgsize() (s int) {
-	s = 1 + 3 + msgp.IntSize + 6 + msgp.StringPrefixSize + len(z.Title) + 7 + msgp.IntSize + 8 + msgp.IntSize + 9 + msgp.IntSize + 10 + msgp.IntSize + 5 + msgp.StringPrefixSize + len(z.File) + 10 + 1 + 5 + msgp.TimeSize + 10 + z.UniqueIDs.Msgsize() + 7
+	s = 1 + 3 + msgp.IntSize + 6 + msgp.StringPrefixSize + len(z.Title) + 7 + msgp.IntSize + 8 + msgp.IntSize + 9 + msgp.IntSize + 10 + msgp.IntSize + 5 + msgp.StringPrefixSize + len(z.File) + 10 + 1 + 5 + msgp.TimeSize + 11 + 1 + 5 + msgp.TimeSize + 10 + z.UniqueIDs.Msgsize() + 7
 	if z.Resume == nil {
 		s += msgp.NilSize
 	} else {
@@ -2276,9 +2306,9 @@ func (z VideoLibraryLimits) Msgsize() (s int) {
 // MarshalMsg implements msgp.Marshaler
 func (z *VideoLibraryMovieItem) MarshalMsg(b []byte) (o []byte, err error) {
 	o = msgp.Require(b, z.Msgsize())
-	// map header, size 9
+	// map header, size 10
 	// string "ID"
-	o = append(o, 0x89, 0xa2, 0x49, 0x44)
+	o = append(o, 0x8a, 0xa2, 0x49, 0x44)
 	o = msgp.AppendInt(o, z.ID)
 	// string "Title"
 	o = append(o, 0xa5, 0x54, 0x69, 0x74, 0x6c, 0x65)
@@ -2300,6 +2330,11 @@ func (z *VideoLibraryMovieItem) MarshalMsg(b []byte) (o []byte, err error) {
 	// string "Time"
 	o = append(o, 0xa9, 0x44, 0x61, 0x74, 0x65, 0x41, 0x64, 0x64, 0x65, 0x64, 0x81, 0xa4, 0x54, 0x69, 0x6d, 0x65)
 	o = msgp.AppendTime(o, z.DateAdded.Time)
+	// string "LastPlayed"
+	// map header, size 1
+	// string "Time"
+	o = append(o, 0xaa, 0x4c, 0x61, 0x73, 0x74, 0x50, 0x6c, 0x61, 0x79, 0x65, 0x64, 0x81, 0xa4, 0x54, 0x69, 0x6d, 0x65)
+	o = msgp.AppendTime(o, z.LastPlayed.Time)
 	// string "UniqueIDs"
 	o = append(o, 0xa9, 0x55, 0x6e, 0x69, 0x71, 0x75, 0x65, 0x49, 0x44, 0x73)
 	o, err = z.UniqueIDs.MarshalMsg(o)
@@ -2393,6 +2428,31 @@ func (z *VideoLibraryMovieItem) UnmarshalMsg(bts []byte) (o []byte, err error) {
 					}
 				}
 			}
+		case "LastPlayed":
+			var zb0003 uint32
+			zb0003, bts, err = msgp.ReadMapHeaderBytes(bts)
+			if err != nil {
+				return
+			}
+			for zb0003 > 0 {
+				zb0003--
+				field, bts, err = msgp.ReadMapKeyZC(bts)
+				if err != nil {
+					return
+				}
+				switch msgp.UnsafeString(field) {
+				case "Time":
+					z.LastPlayed.Time, bts, err = msgp.ReadTimeBytes(bts)
+					if err != nil {
+						return
+					}
+				default:
+					bts, err = msgp.Skip(bts)
+					if err != nil {
+						return
+					}
+				}
+			}
 		case "UniqueIDs":
 			bts, err = z.UniqueIDs.UnmarshalMsg(bts)
 			if err != nil {
@@ -2409,13 +2469,13 @@ func (z *VideoLibraryMovieItem) UnmarshalMsg(bts []byte) (o []byte, err error) {
 				if z.Resume == nil {
 					z.Resume = new(Resume)
 				}
-				var zb0003 uint32
-				zb0003, bts, err = msgp.ReadMapHeaderBytes(bts)
+				var zb0004 uint32
+				zb0004, bts, err = msgp.ReadMapHeaderBytes(bts)
 				if err != nil {
 					return
 				}
-				for zb0003 > 0 {
-					zb0003--
+				for zb0004 > 0 {
+					zb0004--
 					field, bts, err = msgp.ReadMapKeyZC(bts)
 					if err != nil {
 						return
@@ -2452,7 +2512,7 @@ func (z *VideoLibraryMovieItem) UnmarshalMsg(bts []byte) (o []byte, err error) {
 
 // Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
 func (z *VideoLibraryMovieItem) Msgsize() (s int) {
-	s = 1 + 3 + msgp.IntSize + 6 + msgp.StringPrefixSize + len(z.Title) + 11 + msgp.StringPrefixSize + len(z.IMDBNumber) + 10 + msgp.IntSize + 5 + msgp.StringPrefixSize + len(z.File) + 5 + msgp.IntSize + 10 + 1 + 5 + msgp.TimeSize + 10 + z.UniqueIDs.Msgsize() + 7
+	s = 1 + 3 + msgp.IntSize + 6 + msgp.StringPrefixSize + len(z.Title) + 11 + msgp.StringPrefixSize + len(z.IMDBNumber) + 10 + msgp.IntSize + 5 + msgp.StringPrefixSize + len(z.File) + 5 + msgp.IntSize + 10 + 1 + 5 + msgp.TimeSize + 11 + 1 + 5 + msgp.TimeSize + 10 + z.UniqueIDs.Msgsize() + 7
 	if z.Resume == nil {
 		s += msgp.NilSize
 	} else {
@@ -175,6 +175,7 @@ type VideoLibraryMovieItem struct {
 	File       string    `json:"file"`
 	Year       int       `json:"year"`
 	DateAdded  KodiTime  `json:"dateadded"`
+	LastPlayed KodiTime  `json:"lastplayed"`
 	UniqueIDs  UniqueIDs `json:"uniqueid"`
 	Resume     *Resume
 }
@@ -230,16 +231,17 @@ type VideoLibraryEpisode struct {
 
 // VideoLibraryEpisodeItem ...
 type VideoLibraryEpisodeItem struct {
-	ID        int       `json:"episodeid"`
-	Title     string    `json:"label"`
-	Season    int       `json:"season"`
-	Episode   int       `json:"episode"`
-	TVShowID  int       `json:"tvshowid"`
-	PlayCount int       `json:"playcount"`
-	File      string    `json:"file"`
-	DateAdded KodiTime  `json:"dateadded"`
-	UniqueIDs UniqueIDs `json:"uniqueid"`
-	Resume    *Resume
+	ID         int       `json:"episodeid"`
+	Title      string    `json:"label"`
+	Season     int       `json:"season"`
+	Episode    int       `json:"episode"`
+	TVShowID   int       `json:"tvshowid"`
+	PlayCount  int       `json:"playcount"`
+	File       string    `json:"file"`
+	DateAdded  KodiTime  `json:"dateadded"`
+	LastPlayed KodiTime  `json:"lastplayed"`
+	UniqueIDs  UniqueIDs `json:"uniqueid"`
+	Resume     *Resume
 }
 
 // UniqueIDs ...
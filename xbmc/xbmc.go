@@ -52,6 +52,7 @@ func VideoLibraryGetMovies() (movies *VideoLibraryMovies, err error) {
 		"playcount",
 		"file",
 		"dateadded",
+		"lastplayed",
 		"resume",
 	}
 	if KodiVersion > 16 {
@@ -283,6 +284,7 @@ func VideoLibraryGetEpisodes(tvshowID int) (episodes *VideoLibraryEpisodes, err
 		"playcount",
 		"file",
 		"dateadded",
+		"lastplayed",
 		"resume",
 	}}
 	err = executeJSONRPCO("VideoLibrary.GetEpisodes", &episodes, params)
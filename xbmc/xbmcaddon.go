@@ -94,6 +94,13 @@ func GetSettingBool(id string) bool {
 
 // SetSetting ...
 func SetSetting(id string, value interface{}) {
+	if Headless && HeadlessSetSetting != nil {
+		if err := HeadlessSetSetting(id, value); err != nil {
+			log.Warningf("Could not save setting %s: %s", id, err)
+		}
+		return
+	}
+
 	retVal := 0
 	executeJSONRPCEx("SetSetting", &retVal, Args{id, value})
 }